@@ -3,36 +3,221 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/steeling/InterUSS-Platform/pkg/certs"
 	"github.com/steeling/InterUSS-Platform/pkg/dss"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/auth"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/cockroach"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/dispatcher"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/events"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/memstore"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/metrics"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/ratelimit"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/reaper"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/validations"
 	"github.com/steeling/InterUSS-Platform/pkg/dssproto"
 	"github.com/steeling/InterUSS-Platform/pkg/errors"
+	"github.com/steeling/InterUSS-Platform/pkg/health"
 	"github.com/steeling/InterUSS-Platform/pkg/logging"
+	"github.com/steeling/InterUSS-Platform/pkg/tracing"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
+const (
+	// jwksTimeout bounds the duration of fetching a JWKS document.
+	jwksTimeout = 10 * time.Second
+	// dssService is the name of the DSS gRPC service.
+	dssService = "dssproto.DSService"
+)
+
 var (
 	address    = flag.String("addr", ":8081", "address")
+	adminAddr  = flag.String("admin_addr", ":9081", "address of the admin HTTP server exposing /metrics, /healthz and /readyz, empty to disable it")
 	pkFile     = flag.String("public_key_file", "", "Path to public Key to use for JWT decoding.")
 	reflectAPI = flag.Bool("reflect_api", false, "Whether to reflect the API.")
 	logFormat  = flag.String("log_format", logging.DefaultFormat, "The log format in {json, console}")
 	logLevel   = flag.String("log_level", logging.DefaultLevel.String(), "The log level")
+	storeType  = flag.String("store", "cockroach", "The store implementation in {cockroach, memory}")
+
+	healthCheckInterval = flag.Duration("health_check_interval", health.DefaultConfig.Interval, "interval between two checks of the store backing readiness")
+	healthCheckTimeout  = flag.Duration("health_check_timeout", health.DefaultConfig.Timeout, "timeout of a single check of the store")
+	shutdownDelay       = flag.Duration("shutdown_delay", 5*time.Second, "duration for which the backend reports not ready before it stops accepting calls on shutdown")
+
+	tlsCertFile          = flag.String("tls_cert_file", "", "path to the PEM-encoded certificate chain of the backend, empty to serve without TLS")
+	tlsKeyFile           = flag.String("tls_key_file", "", "path to the PEM-encoded private key of tls_cert_file")
+	tlsClientCAFile      = flag.String("tls_client_ca_file", "", "path to the PEM-encoded certificates of the CAs verifying client certificates")
+	tlsRequireClientCert = flag.Bool("tls_require_client_cert", false, "whether to reject clients without a certificate issued by a CA in tls_client_ca_file")
+	tlsReloadInterval    = flag.Duration("tls_reload_interval", certs.DefaultReloadInterval, "interval between two checks of the TLS files for changes")
+
+	maxPageSize     = flag.Int("max_page_size", dss.DefaultMaxPageSize, "maximum number of results returned by a single search")
+	eventBufferSize = flag.Int("event_buffer_size", events.DefaultBufferSize, "number of events buffered per WatchSubscriptionEvents stream before the stream is closed")
+
+	maxPolygonVertices     = flag.Int("max_polygon_vertices", geo.DefaultMaxVertices, "maximum number of vertices of a polygon in a request")
+	maxSearchAreaKm2       = flag.Float64("max_search_area_km2", geo.DefaultMaxAreaKm2, "maximum area of a search in square kilometers")
+	maxISAAreaKm2          = flag.Float64("max_isa_area_km2", geo.DefaultMaxAreaKm2, "maximum area of an ISA footprint in square kilometers")
+	maxSubscriptionAreaKm2 = flag.Float64("max_subscription_area_km2", geo.DefaultMaxAreaKm2, "maximum area of a subscription footprint in square kilometers")
+
+	maxISADuration              = flag.Duration("max_isa_duration", dss.DefaultISATimePolicy.MaxDuration, "maximum duration of the time window of an ISA")
+	maxISALeadTime              = flag.Duration("max_isa_lead_time", dss.DefaultISATimePolicy.MaxLeadTime, "maximum duration until the time window of an ISA starts")
+	defaultISADuration          = flag.Duration("default_isa_duration", dss.DefaultISATimePolicy.DefaultDuration, "duration of the time window of an ISA without time_end")
+	maxSubscriptionDuration     = flag.Duration("max_subscription_duration", dss.DefaultSubscriptionTimePolicy.MaxDuration, "maximum duration of the time window of a subscription")
+	maxSubscriptionLeadTime     = flag.Duration("max_subscription_lead_time", dss.DefaultSubscriptionTimePolicy.MaxLeadTime, "maximum duration until the time window of a subscription starts")
+	defaultSubscriptionDuration = flag.Duration("default_subscription_duration", dss.DefaultSubscriptionTimePolicy.DefaultDuration, "duration of the time window of a subscription without time_end")
+
+	maxSubscriptionsPerArea = flag.Int("max_subscriptions_per_area", dss.DefaultMaxSubscriptionsPerArea, "maximum number of active subscriptions an owner may hold in any area")
+
+	cellMinLevel = flag.Int("cell_min_level", geo.DefaultCoveringConfig.MinLevel, "level of the largest S2 cells indexing an area")
+	cellMaxLevel = flag.Int("cell_max_level", geo.DefaultCoveringConfig.MaxLevel, "level of the smallest S2 cells indexing an area")
+	cellMaxCells = flag.Int("cell_max_cells", geo.DefaultCoveringConfig.MaxCells, "desired maximum number of S2 cells indexing an area")
+	cellLevelMod = flag.Int("cell_level_mod", geo.DefaultCoveringConfig.LevelMod, "only use S2 cells whose level exceeds cell_min_level by a multiple of this value, in {1, 2, 3}")
+
+	dispatchNotifications       = flag.Bool("dispatch_notifications", false, "whether the DSS delivers ISA change notifications to subscriber callbacks itself")
+	dispatchMaxAttempts         = flag.Int("dispatch_max_attempts", dispatcher.DefaultConfig.MaxAttempts, "number of failed deliveries after which a notification is dead-lettered")
+	dispatchPerHostConcurrency  = flag.Int("dispatch_per_host_concurrency", dispatcher.DefaultConfig.PerHostConcurrency, "maximum number of concurrent deliveries to a single host")
+	dispatchTimeout             = flag.Duration("dispatch_timeout", dispatcher.DefaultConfig.Timeout, "timeout of a single delivery")
+	dispatchDeadLetterRetention = flag.Duration("dispatch_dead_letter_retention", dispatcher.DefaultConfig.DeadLetterRetention, "duration for which dead-lettered notifications are kept, forever if 0")
+
+	jwksSource             = flag.String("jwks", "", "path or http(s) URL of a JWKS document providing the keys for JWT decoding. Takes precedence over public_key_file")
+	jwksRefreshInterval    = flag.Duration("jwks_refresh_interval", auth.DefaultJWKSRefreshInterval, "interval between two scheduled refreshes of the JWKS document")
+	jwksMinRefreshInterval = flag.Duration("jwks_min_refresh_interval", auth.DefaultJWKSMinRefreshInterval, "minimum interval between two refreshes of the JWKS document triggered by tokens naming unknown keys")
+	jwksGracePeriod        = flag.Duration("jwks_grace_period", auth.DefaultJWKSGracePeriod, "duration for which keys removed from the JWKS document are still accepted")
+
+	jwtIssuers    = flag.String("jwt_issuers", "", "comma-separated list of accepted JWT issuers, empty to accept all issuers")
+	jwtAudiences  = flag.String("jwt_audiences", "", "comma-separated list of accepted JWT audiences, empty to accept all audiences")
+	jwtAlgorithms = flag.String("jwt_algorithms", strings.Join(auth.DefaultAlgorithms, ","), "comma-separated list of accepted JWT signing algorithms")
+	jwtClockSkew  = flag.Duration("jwt_clock_skew", auth.DefaultClockSkew, "tolerated clock skew when checking the exp, nbf and iat claims of JWTs")
+
+	traceExporter    = flag.String("trace_exporter", tracing.DefaultExporter, "exporter of trace spans in {none, stdout, file}")
+	traceFile        = flag.String("trace_file", "", "path of the file spans are appended to by the file exporter")
+	traceSampleRatio = flag.Float64("trace_sample_ratio", tracing.DefaultSampleRatio, "fraction of traces started by the backend that are sampled")
+
+	rateLimits = flag.String("rate_limits", "", "path to a JSON file configuring the rate limits per owner and RPC, empty to disable rate limiting")
+
+	gcInterval  = flag.Duration("gc_interval", reaper.DefaultInterval, "interval between runs of the garbage collection of expired records, 0 disables it")
+	gcRetention = flag.Duration("gc_retention", reaper.DefaultRetention, "duration for which expired records are kept before they are garbage collected")
+	gcBatchSize = flag.Int("gc_batch_size", reaper.DefaultBatchSize, "maximum number of records deleted per garbage collection batch")
 
 	cockroachHost    = flag.String("cockroach_host", "", "cockroach host to connect to")
 	cockroachPort    = flag.Int("cockroach_port", 26257, "cockroach port to connect to")
 	cockroachSSLMode = flag.String("cockroach_ssl_mode", "disable", "cockroach sslmode")
 	cockroachUser    = flag.String("cockroach_user", "root", "cockroach user to authenticate as")
 	cockroachSSLDir  = flag.String("cockroach_ssl_dir", "", "directory to ssl certificates. Must contain files: ca.crt, client.<user>.crt, client.<user>.key")
+	cockroachMigrate = flag.Bool("cockroach_migrate", true, "whether to apply pending schema migrations at startup. If false, the schema must have been migrated with the migrate command")
 )
 
+// newStore returns the dss.Store implementation selected by the store flag.
+func newStore(ctx context.Context, logger *zap.Logger) (dss.Store, error) {
+	switch *storeType {
+	case "memory":
+		logger.Warn("Using in-memory store, all data will be lost on shutdown")
+		return memstore.New(), nil
+	case "cockroach":
+		break
+	default:
+		return nil, fmt.Errorf("unknown store type: %s", *storeType)
+	}
+
+	uriParams := map[string]string{
+		"host":     *cockroachHost,
+		"port":     strconv.Itoa(*cockroachPort),
+		"user":     *cockroachUser,
+		"ssl_mode": *cockroachSSLMode,
+		"ssl_dir":  *cockroachSSLDir,
+	}
+	uri, err := cockroach.BuildURI(uriParams)
+	if err != nil {
+		logger.Panic("Failed to build URI", zap.Error(err))
+	}
+
+	store, err := cockroach.Dial(uri)
+	if err != nil {
+		logger.Panic("Failed to open connection to CRDB", zap.String("uri", uri), zap.Error(err))
+	}
+
+	if !*cockroachMigrate {
+		if err := store.CheckSchemaVersion(ctx); err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+
+	applied, err := store.Migrate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Migrated CRDB schema", zap.Int("applied", applied), zap.Int("version", cockroach.SchemaVersion))
+	return store, nil
+}
+
+// schemaChecker is implemented by stores with a versioned schema.
+type schemaChecker interface {
+	// CheckSchemaVersion returns an error if the store is unreachable or its
+	// schema is not at the version expected by the backend.
+	CheckSchemaVersion(ctx context.Context) error
+}
+
+// newKeyProvider returns the auth.KeyProvider serving the keys from the JWKS
+// document configured by the jwks flags or, if no JWKS document is
+// configured, the key in public_key_file.
+func newKeyProvider(ctx context.Context, logger *zap.Logger) (auth.KeyProvider, error) {
+	if *jwksSource == "" {
+		return auth.NewRSAKeyProvider(*pkFile)
+	}
+
+	keys, err := auth.NewJWKSProvider(auth.JWKSConfig{
+		Source:             *jwksSource,
+		RefreshInterval:    *jwksRefreshInterval,
+		MinRefreshInterval: *jwksMinRefreshInterval,
+		GracePeriod:        *jwksGracePeriod,
+	}, &http.Client{Timeout: jwksTimeout}, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	go keys.Run(ctx)
+	return keys, nil
+}
+
+// splitList returns the elements of the comma-separated list "s".
+func splitList(s string) []string {
+	var result []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// runAdminServer serves "handler" on "address" until ctx is done.
+func runAdminServer(ctx context.Context, address string, handler http.Handler, logger *zap.Logger) {
+	server := &http.Server{Addr: address, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			logger.Error("Failed to close admin server", zap.Error(err))
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("Admin server failed", zap.String("address", address), zap.Error(err))
+	}
+}
+
 // RunGRPCServer starts the example gRPC service.
 // "network" and "address" are passed to net.Listen.
 func RunGRPCServer(ctx context.Context, address string) error {
@@ -48,50 +233,193 @@ func RunGRPCServer(ctx context.Context, address string) error {
 		}
 	}()
 
-	uriParams := map[string]string{
-		"host":     *cockroachHost,
-		"port":     strconv.Itoa(*cockroachPort),
-		"user":     *cockroachUser,
-		"ssl_mode": *cockroachSSLMode,
-		"ssl_dir":  *cockroachSSLDir,
+	registry := metrics.NewRegistry()
+	m, err := metrics.New(registry)
+	if err != nil {
+		return err
 	}
-	uri, err := cockroach.BuildURI(uriParams)
+
+	rawStore, err := newStore(ctx, logger)
 	if err != nil {
-		logger.Panic("Failed to build URI", zap.Error(err))
+		return err
 	}
+	store := m.Store(rawStore)
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("Failed to close store", zap.Error(err))
+		}
+	}()
 
-	store, err := cockroach.Dial(uri)
+	checks := map[string]health.Check{}
+	if checker, ok := rawStore.(schemaChecker); ok {
+		checks["store"] = checker.CheckSchemaVersion
+	}
+	monitor, err := health.New(checks, []string{dssService}, health.Config{
+		Interval: *healthCheckInterval,
+		Timeout:  *healthCheckTimeout,
+	}, logger)
 	if err != nil {
-		logger.Panic("Failed to open connection to CRDB", zap.String("uri", uri), zap.Error(err))
+		return err
 	}
+	go monitor.Run(ctx)
 
-	if err := store.Bootstrap(ctx); err != nil {
-		logger.Panic("Failed to bootstrap CRDB instance", zap.Error(err))
+	if *adminAddr != "" {
+		admin := http.NewServeMux()
+		admin.Handle("/metrics", metrics.Handler(registry))
+		monitor.Handle(admin)
+		// The admin server outlives ctx to answer probes while draining.
+		adminCtx, stopAdmin := context.WithCancel(context.Background())
+		defer stopAdmin()
+		go runAdminServer(adminCtx, *adminAddr, admin, logger)
 	}
 
-	dssServer := &dss.Server{
-		Store: store,
+	if *gcInterval > 0 {
+		r, err := reaper.New(store, reaper.Config{
+			Retention: *gcRetention,
+			BatchSize: *gcBatchSize,
+			Interval:  *gcInterval,
+		}, logger)
+		if err != nil {
+			return err
+		}
+		if err := r.Register(registry); err != nil {
+			return err
+		}
+		go r.Run(ctx)
 	}
 
-	ac, err := auth.NewRSAAuthClient(*pkFile)
+	coverer, err := geo.NewCoverer(geo.CoveringConfig{
+		MinLevel:    *cellMinLevel,
+		MaxLevel:    *cellMaxLevel,
+		MaxCells:    *cellMaxCells,
+		LevelMod:    *cellLevelMod,
+		MaxVertices: *maxPolygonVertices,
+	})
 	if err != nil {
 		return err
 	}
-	ac.RequireScopes(dssServer.AuthScopes())
 
-	s := grpc.NewServer(grpc_middleware.WithUnaryServerChain(errors.Interceptor(logger), logging.Interceptor(logger), ac.AuthInterceptor, validations.ValidationInterceptor))
+	dssServer := &dss.Server{
+		Store:       store,
+		Coverer:     coverer,
+		Events:      events.NewBus(*eventBufferSize),
+		MaxPageSize: *maxPageSize,
+
+		MaxSearchAreaKm2:       *maxSearchAreaKm2,
+		MaxISAAreaKm2:          *maxISAAreaKm2,
+		MaxSubscriptionAreaKm2: *maxSubscriptionAreaKm2,
+
+		MaxSubscriptionsPerArea: *maxSubscriptionsPerArea,
+
+		ISATimePolicy: dss.TimePolicy{
+			MaxDuration:     *maxISADuration,
+			MaxLeadTime:     *maxISALeadTime,
+			DefaultDuration: *defaultISADuration,
+		},
+		SubscriptionTimePolicy: dss.TimePolicy{
+			MaxDuration:     *maxSubscriptionDuration,
+			MaxLeadTime:     *maxSubscriptionLeadTime,
+			DefaultDuration: *defaultSubscriptionDuration,
+		},
+	}
+
+	if *dispatchNotifications {
+		outbox, ok := rawStore.(dispatcher.Outbox)
+		if !ok {
+			return fmt.Errorf("store %s does not support dispatching notifications", *storeType)
+		}
+		config := dispatcher.DefaultConfig
+		config.MaxAttempts = *dispatchMaxAttempts
+		config.PerHostConcurrency = *dispatchPerHostConcurrency
+		config.Timeout = *dispatchTimeout
+		config.DeadLetterRetention = *dispatchDeadLetterRetention
+
+		d, err := dispatcher.New(m.Outbox(outbox), config, dispatcher.NewClient(), logger)
+		if err != nil {
+			return err
+		}
+		dssServer.Dispatcher = d
+		go d.Run(ctx)
+	}
+
+	keys, err := newKeyProvider(ctx, logger)
+	if err != nil {
+		return err
+	}
+	if *jwtIssuers == "" || *jwtAudiences == "" {
+		logger.Warn("Accepting JWTs of any issuer or audience, configure jwt_issuers and jwt_audiences to restrict them")
+	}
+	ac, err := auth.NewAuthClient(keys, auth.ValidationConfig{
+		Issuers:    splitList(*jwtIssuers),
+		Audiences:  splitList(*jwtAudiences),
+		Algorithms: splitList(*jwtAlgorithms),
+		ClockSkew:  *jwtClockSkew,
+	})
 	if err != nil {
 		return err
 	}
+	ac.RequireScopes(dssServer.AuthScopes())
+	// Probes and load balancers check the health of the backend without a
+	// token.
+	ac.AllowUnauthenticated(health.ServiceName)
+
+	var (
+		unaryInterceptors  = []grpc.UnaryServerInterceptor{tracing.Interceptor, m.Interceptor, errors.Interceptor(logger), logging.Interceptor(logger), ac.AuthInterceptor}
+		streamInterceptors = []grpc.StreamServerInterceptor{tracing.StreamInterceptor, m.StreamInterceptor, errors.StreamInterceptor(logger), logging.StreamInterceptor(logger), ac.AuthStreamInterceptor}
+	)
+	if *rateLimits != "" {
+		config, err := ratelimit.LoadConfig(*rateLimits)
+		if err != nil {
+			return err
+		}
+		limiter, err := ratelimit.New(config, logger)
+		if err != nil {
+			return err
+		}
+		// The limiter identifies owners by the context set up by the auth
+		// interceptors.
+		unaryInterceptors = append(unaryInterceptors, limiter.Interceptor)
+		streamInterceptors = append(streamInterceptors, limiter.StreamInterceptor)
+	}
+	unaryInterceptors = append(unaryInterceptors, validations.ValidationInterceptor)
+
+	opts := []grpc.ServerOption{
+		grpc_middleware.WithUnaryServerChain(unaryInterceptors...),
+		grpc_middleware.WithStreamServerChain(streamInterceptors...),
+	}
+	if *tlsCertFile != "" {
+		r, err := certs.New(certs.Config{
+			CertFile:          *tlsCertFile,
+			KeyFile:           *tlsKeyFile,
+			CAFile:            *tlsClientCAFile,
+			RequireClientCert: *tlsRequireClientCert,
+			ReloadInterval:    *tlsReloadInterval,
+		}, logger)
+		if err != nil {
+			return err
+		}
+		go r.Run(ctx)
+		opts = append(opts, grpc.Creds(r.ServerCredentials()))
+	} else {
+		logger.Warn("Serving without TLS, configure tls_cert_file and tls_key_file to enable it")
+	}
+
+	s := grpc.NewServer(opts...)
 	if *reflectAPI {
 		reflection.Register(s)
 	}
 
 	dssproto.RegisterDSServiceServer(s, dssServer)
+	monitor.Register(s)
 
 	go func() {
 		defer s.GracefulStop()
 		<-ctx.Done()
+		// Report not ready before draining, so that load balancers stop
+		// routing new calls to this backend first.
+		monitor.Drain()
+		logger.Info("Draining", zap.Duration("shutdown_delay", *shutdownDelay))
+		time.Sleep(*shutdownDelay)
 	}()
 	return s.Serve(l)
 }
@@ -103,10 +431,32 @@ func main() {
 		panic(err)
 	}
 
-	var (
-		ctx    = context.Background()
-		logger = logging.WithValuesFromContext(ctx, logging.Logger)
-	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := logging.WithValuesFromContext(ctx, logging.Logger)
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-signals
+		logger.Info("Shutting down", zap.Stringer("signal", sig))
+		cancel()
+	}()
+
+	shutdownTracing, err := tracing.Configure(tracing.Config{
+		ServiceName: "grpc-backend",
+		Exporter:    *traceExporter,
+		File:        *traceFile,
+		SampleRatio: *traceSampleRatio,
+	})
+	if err != nil {
+		logger.Panic("Failed to configure tracing", zap.Error(err))
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("Failed to flush trace spans", zap.Error(err))
+		}
+	}()
 
 	if err := RunGRPCServer(ctx, *address); err != nil {
 		logger.Panic("Failed to execute service", zap.Error(err))
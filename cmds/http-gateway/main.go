@@ -4,10 +4,19 @@ import (
 	"context"
 	"flag"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/steeling/InterUSS-Platform/pkg/apidocs"
+	"github.com/steeling/InterUSS-Platform/pkg/certs"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/metrics"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/ratelimit"
 	"github.com/steeling/InterUSS-Platform/pkg/dssproto"
+	"github.com/steeling/InterUSS-Platform/pkg/health"
 	"github.com/steeling/InterUSS-Platform/pkg/logging"
+	"github.com/steeling/InterUSS-Platform/pkg/tracing"
 
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"go.uber.org/zap"
@@ -15,43 +24,216 @@ import (
 	"google.golang.org/grpc"
 )
 
+// dssService is the name of the DSS gRPC service.
+const dssService = "dssproto.DSService"
+
 var (
 	address     = flag.String("addr", ":8080", "address")
+	adminAddr   = flag.String("admin_addr", ":9080", "address of the admin HTTP server exposing /metrics, empty to disable it")
 	grpcBackend = flag.String("grpc-backend", "", "Endpoint for grpc backend. Only to be set if run in proxy mode")
+	apiDocs     = flag.Bool("api_docs", true, "whether to serve the OpenAPI document of the API at /openapi.json and an API explorer at /docs/")
+
+	healthCheckInterval = flag.Duration("health_check_interval", health.DefaultConfig.Interval, "interval between two checks of the backend backing readiness")
+	healthCheckTimeout  = flag.Duration("health_check_timeout", health.DefaultConfig.Timeout, "timeout of a single check of the backend")
+	shutdownDelay       = flag.Duration("shutdown_delay", 5*time.Second, "duration for which the gateway reports not ready before it stops accepting requests on shutdown")
+
+	tlsCertFile          = flag.String("tls_cert_file", "", "path to the PEM-encoded certificate chain of the gateway, empty to serve HTTP without TLS")
+	tlsKeyFile           = flag.String("tls_key_file", "", "path to the PEM-encoded private key of tls_cert_file")
+	tlsClientCAFile      = flag.String("tls_client_ca_file", "", "path to the PEM-encoded certificates of the CAs verifying client certificates")
+	tlsRequireClientCert = flag.Bool("tls_require_client_cert", false, "whether to reject clients without a certificate issued by a CA in tls_client_ca_file")
+	tlsReloadInterval    = flag.Duration("tls_reload_interval", certs.DefaultReloadInterval, "interval between two checks of the TLS files for changes")
+
+	backendTLS        = flag.Bool("backend_tls", false, "whether to connect to the gRPC backend over TLS")
+	backendCAFile     = flag.String("backend_ca_file", "", "path to the PEM-encoded certificates of the CAs verifying the backend certificate, empty to use the system pool")
+	backendCertFile   = flag.String("backend_cert_file", "", "path to the PEM-encoded client certificate chain presented to the backend, empty to connect without a client certificate")
+	backendKeyFile    = flag.String("backend_key_file", "", "path to the PEM-encoded private key of backend_cert_file")
+	backendServerName = flag.String("backend_server_name", "", "name the backend certificate is verified for, empty to use the host of grpc-backend")
+
+	traceExporter    = flag.String("trace_exporter", tracing.DefaultExporter, "exporter of trace spans in {none, stdout, file}")
+	traceFile        = flag.String("trace_file", "", "path of the file spans are appended to by the file exporter")
+	traceSampleRatio = flag.Float64("trace_sample_ratio", tracing.DefaultSampleRatio, "fraction of traces started by the gateway that are sampled")
 )
 
+// outgoingHeaderMatcher forwards the retry-after metadata of rate limited calls
+// as Retry-After header, and all other metadata like the default matcher does.
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if key == ratelimit.RetryAfterKey {
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// backendCredentials returns the dial option securing the connection to the
+// gRPC backend as configured by the backend flags. The client certificate and
+// CAs are reloaded until ctx is done.
+func backendCredentials(ctx context.Context, logger *zap.Logger) (grpc.DialOption, error) {
+	if !*backendTLS {
+		logger.Warn("Connecting to the backend without TLS, set backend_tls to enable it")
+		return grpc.WithInsecure(), nil
+	}
+	r, err := certs.New(certs.Config{
+		CertFile:       *backendCertFile,
+		KeyFile:        *backendKeyFile,
+		CAFile:         *backendCAFile,
+		ReloadInterval: *tlsReloadInterval,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	go r.Run(ctx)
+	return grpc.WithTransportCredentials(r.ClientCredentials(*backendServerName)), nil
+}
+
+// runAdminServer serves "handler" on "address" until ctx is done.
+func runAdminServer(ctx context.Context, address string, handler http.Handler, logger *zap.Logger) {
+	server := &http.Server{Addr: address, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			logger.Error("Failed to close admin server", zap.Error(err))
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("Admin server failed", zap.String("address", address), zap.Error(err))
+	}
+}
+
 // RunHTTPProxy starts the HTTP proxy for the DSS gRPC service on ctx, listening
-// on address, proxying to endpoint.
-func RunHTTPProxy(ctx context.Context, address, endpoint string) error {
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+// on address, proxying to endpoint. Metrics are exposed on adminAddress unless
+// it is empty. The proxy serves HTTPS if tls_cert_file is set. Once ctx is
+// done, the proxy reports not ready for shutdown_delay and then shuts down
+// gracefully.
+func RunHTTPProxy(ctx context.Context, address, adminAddress, endpoint string) error {
+	logger := logging.WithValuesFromContext(ctx, logging.Logger)
 
 	// Register gRPC server endpoint
 	// Note: Make sure the gRPC server is running properly and accessible
-	mux := runtime.NewServeMux()
+	mux := runtime.NewServeMux(runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher))
+	creds, err := backendCredentials(ctx, logger)
+	if err != nil {
+		return err
+	}
 	opts := []grpc.DialOption{
-		grpc.WithInsecure(),
+		creds,
 		grpc.WithBlock(),
 		grpc.WithTimeout(10 * time.Second),
+		grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor),
+		grpc.WithStreamInterceptor(tracing.StreamClientInterceptor),
+	}
+
+	conn, err := grpc.Dial(endpoint, opts...)
+	if err != nil {
+		return err
+	}
+	// The connection outlives ctx to serve requests while draining.
+	defer func() {
+		if err := conn.Close(); err != nil {
+			logger.Error("Failed to close connection to backend", zap.String("endpoint", endpoint), zap.Error(err))
+		}
+	}()
+	if err := dssproto.RegisterDSServiceHandler(ctx, mux, conn); err != nil {
+		return err
 	}
 
-	err := dssproto.RegisterDSServiceHandlerFromEndpoint(ctx, mux, endpoint, opts)
+	monitor, err := health.New(map[string]health.Check{
+		"backend": health.GRPCCheck(conn, dssService),
+	}, nil, health.Config{
+		Interval: *healthCheckInterval,
+		Timeout:  *healthCheckTimeout,
+	}, logger)
 	if err != nil {
 		return err
 	}
+	go monitor.Run(ctx)
+
+	registry := metrics.NewRegistry()
+	handler, err := metrics.InstrumentHTTP(registry, tracing.Handler(mux))
+	if err != nil {
+		return err
+	}
+	root := http.NewServeMux()
+	monitor.Handle(root)
+	if *apiDocs {
+		apidocs.Handle(root, dssproto.SwaggerJSON)
+	}
+	root.Handle("/", handler)
+
+	if adminAddress != "" {
+		admin := http.NewServeMux()
+		admin.Handle("/metrics", metrics.Handler(registry))
+		adminCtx, stopAdmin := context.WithCancel(context.Background())
+		defer stopAdmin()
+		go runAdminServer(adminCtx, adminAddress, admin, logger)
+	}
 
 	// Start HTTP server (and proxy calls to gRPC server endpoint)
-	return http.ListenAndServe(address, mux)
+	server := &http.Server{Addr: address, Handler: root}
+	if *tlsCertFile != "" {
+		r, err := certs.New(certs.Config{
+			CertFile:          *tlsCertFile,
+			KeyFile:           *tlsKeyFile,
+			CAFile:            *tlsClientCAFile,
+			RequireClientCert: *tlsRequireClientCert,
+			ReloadInterval:    *tlsReloadInterval,
+		}, logger)
+		if err != nil {
+			return err
+		}
+		go r.Run(ctx)
+		server.TLSConfig = r.ServerConfig()
+	}
+	drained := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		// Report not ready before draining, so that load balancers stop
+		// routing new requests to this gateway first.
+		monitor.Drain()
+		logger.Info("Draining", zap.Duration("shutdown_delay", *shutdownDelay))
+		time.Sleep(*shutdownDelay)
+		drained <- server.Shutdown(context.Background())
+	}()
+	if server.TLSConfig != nil {
+		// The certificates are provided by server.TLSConfig.
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+	return <-drained
 }
 
 func main() {
 	flag.Parse()
-	var (
-		ctx    = context.Background()
-		logger = logging.WithValuesFromContext(ctx, logging.Logger)
-	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := logging.WithValuesFromContext(ctx, logging.Logger)
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-signals
+		logger.Info("Shutting down", zap.Stringer("signal", sig))
+		cancel()
+	}()
+
+	shutdownTracing, err := tracing.Configure(tracing.Config{
+		ServiceName: "http-gateway",
+		Exporter:    *traceExporter,
+		File:        *traceFile,
+		SampleRatio: *traceSampleRatio,
+	})
+	if err != nil {
+		logger.Panic("Failed to configure tracing", zap.Error(err))
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("Failed to flush trace spans", zap.Error(err))
+		}
+	}()
 
-	if err := RunHTTPProxy(ctx, *address, *grpcBackend); err != nil {
+	if err := RunHTTPProxy(ctx, *address, *adminAddr, *grpcBackend); err != nil {
 		logger.Panic("Failed to execute service", zap.Error(err))
 	}
 	logger.Info("Shutting down gracefully")
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/cockroach"
+	"github.com/steeling/InterUSS-Platform/pkg/logging"
+	"go.uber.org/zap"
+)
+
+var (
+	logFormat = flag.String("log_format", logging.DefaultFormat, "The log format in {json, console}")
+	logLevel  = flag.String("log_level", logging.DefaultLevel.String(), "The log level")
+	check     = flag.Bool("check", false, "Only check whether the schema is up to date, without applying migrations")
+
+	cockroachHost    = flag.String("cockroach_host", "", "cockroach host to connect to")
+	cockroachPort    = flag.Int("cockroach_port", 26257, "cockroach port to connect to")
+	cockroachSSLMode = flag.String("cockroach_ssl_mode", "disable", "cockroach sslmode")
+	cockroachUser    = flag.String("cockroach_user", "root", "cockroach user to authenticate as")
+	cockroachSSLDir  = flag.String("cockroach_ssl_dir", "", "directory to ssl certificates. Must contain files: ca.crt, client.<user>.crt, client.<user>.key")
+)
+
+// Migrate applies all pending schema migrations to the CockroachDB cluster
+// configured by the cockroach flags.
+func Migrate(ctx context.Context, logger *zap.Logger) error {
+	uri, err := cockroach.BuildURI(map[string]string{
+		"host":     *cockroachHost,
+		"port":     strconv.Itoa(*cockroachPort),
+		"user":     *cockroachUser,
+		"ssl_mode": *cockroachSSLMode,
+		"ssl_dir":  *cockroachSSLDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	store, err := cockroach.Dial(uri)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("Failed to close store", zap.Error(err))
+		}
+	}()
+
+	if *check {
+		return store.CheckSchemaVersion(ctx)
+	}
+
+	applied, err := store.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("Migrated CRDB schema", zap.Int("applied", applied), zap.Int("version", cockroach.SchemaVersion))
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	if err := logging.Configure(*logLevel, *logFormat); err != nil {
+		panic(err)
+	}
+
+	var (
+		ctx    = context.Background()
+		logger = logging.WithValuesFromContext(ctx, logging.Logger)
+	)
+
+	if err := Migrate(ctx, logger); err != nil {
+		logger.Panic("Failed to migrate schema", zap.Error(err))
+	}
+}
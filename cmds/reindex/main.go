@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/cockroach"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/reindex"
+	"github.com/steeling/InterUSS-Platform/pkg/logging"
+	"go.uber.org/zap"
+)
+
+var (
+	logFormat = flag.String("log_format", logging.DefaultFormat, "The log format in {json, console}")
+	logLevel  = flag.String("log_level", logging.DefaultLevel.String(), "The log level")
+	batchSize = flag.Int("batch_size", reindex.DefaultBatchSize, "number of records listed per batch")
+
+	cellMinLevel = flag.Int("cell_min_level", geo.DefaultCoveringConfig.MinLevel, "level of the largest S2 cells indexing an area")
+	cellMaxLevel = flag.Int("cell_max_level", geo.DefaultCoveringConfig.MaxLevel, "level of the smallest S2 cells indexing an area")
+	cellMaxCells = flag.Int("cell_max_cells", geo.DefaultCoveringConfig.MaxCells, "desired maximum number of S2 cells indexing an area")
+	cellLevelMod = flag.Int("cell_level_mod", geo.DefaultCoveringConfig.LevelMod, "only use S2 cells whose level exceeds cell_min_level by a multiple of this value, in {1, 2, 3}")
+
+	cockroachHost    = flag.String("cockroach_host", "", "cockroach host to connect to")
+	cockroachPort    = flag.Int("cockroach_port", 26257, "cockroach port to connect to")
+	cockroachSSLMode = flag.String("cockroach_ssl_mode", "disable", "cockroach sslmode")
+	cockroachUser    = flag.String("cockroach_user", "root", "cockroach user to authenticate as")
+	cockroachSSLDir  = flag.String("cockroach_ssl_dir", "", "directory to ssl certificates. Must contain files: ca.crt, client.<user>.crt, client.<user>.key")
+)
+
+// Reindex re-indexes all ISAs and subscriptions in the CockroachDB cluster
+// configured by the cockroach flags with the covering configured by the cell
+// flags.
+func Reindex(ctx context.Context, logger *zap.Logger) error {
+	coverer, err := geo.NewCoverer(geo.CoveringConfig{
+		MinLevel: *cellMinLevel,
+		MaxLevel: *cellMaxLevel,
+		MaxCells: *cellMaxCells,
+		LevelMod: *cellLevelMod,
+	})
+	if err != nil {
+		return err
+	}
+
+	uri, err := cockroach.BuildURI(map[string]string{
+		"host":     *cockroachHost,
+		"port":     strconv.Itoa(*cockroachPort),
+		"user":     *cockroachUser,
+		"ssl_mode": *cockroachSSLMode,
+		"ssl_dir":  *cockroachSSLDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	store, err := cockroach.Dial(uri)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Error("Failed to close store", zap.Error(err))
+		}
+	}()
+
+	if err := store.CheckSchemaVersion(ctx); err != nil {
+		return err
+	}
+
+	reindexer, err := reindex.New(store, coverer, *batchSize, logger)
+	if err != nil {
+		return err
+	}
+	stats, err := reindexer.Run(ctx)
+	logger.Info("Re-indexed cells",
+		zap.Int64("isas", stats.ISAs),
+		zap.Int64("subscriptions", stats.Subscriptions),
+		zap.Int64("skipped", stats.Skipped))
+	return err
+}
+
+func main() {
+	flag.Parse()
+
+	if err := logging.Configure(*logLevel, *logFormat); err != nil {
+		panic(err)
+	}
+
+	var (
+		ctx    = context.Background()
+		logger = logging.WithValuesFromContext(ctx, logging.Logger)
+	)
+
+	if err := Reindex(ctx, logger); err != nil {
+		logger.Panic("Failed to re-index cells", zap.Error(err))
+	}
+}
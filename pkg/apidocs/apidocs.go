@@ -0,0 +1,73 @@
+// Package apidocs serves the OpenAPI document of an HTTP API and an
+// interactive explorer of it.
+//
+// The explorer is Swagger UI, vendored in the swagger-ui directory and
+// embedded in the binary so that it works without internet access. It loads the document from SpecPath relative to
+// ExplorerPath, so both keep working behind a proxy serving the API under a
+// path prefix.
+package apidocs
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// SpecPath is the path of the OpenAPI document.
+	SpecPath = "/openapi.json"
+	// ExplorerPath is the path of the explorer.
+	ExplorerPath = "/docs/"
+
+	// initializerPath is the path of the script configuring the explorer,
+	// relative to ExplorerPath.
+	initializerPath = "swagger-initializer.js"
+)
+
+// swaggerUI holds the Swagger UI distribution.
+//
+//go:embed swagger-ui/*.html swagger-ui/*.css swagger-ui/*.js swagger-ui/*.png
+var swaggerUI embed.FS
+
+// initializer replaces the script of the Swagger UI distribution, which
+// loads an example document.
+var initializer = fmt.Sprintf(`window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "..%s",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`, SpecPath)
+
+// Handle registers handlers serving "spec" at SpecPath and the explorer at
+// ExplorerPath with "mux".
+func Handle(mux *http.ServeMux, spec string) {
+	mux.Handle(SpecPath, content("openapi.json", spec))
+	mux.Handle(ExplorerPath+initializerPath, content(initializerPath, initializer))
+	mux.Handle(strings.TrimSuffix(ExplorerPath, "/"), http.RedirectHandler(ExplorerPath, http.StatusMovedPermanently))
+	mux.Handle(ExplorerPath, http.StripPrefix(ExplorerPath, http.FileServer(http.FS(explorer()))))
+}
+
+// explorer returns the files of the explorer.
+func explorer() fs.FS {
+	dist, err := fs.Sub(swaggerUI, "swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+	return dist
+}
+
+// content returns a handler serving "s" with the content type of "name".
+func content(name, s string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader([]byte(s)))
+	})
+}
@@ -0,0 +1,53 @@
+package apidocs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `{"swagger": "2.0"}`
+
+func get(mux *http.ServeMux, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	Handle(mux, spec)
+	return mux
+}
+
+func TestServeSpec(t *testing.T) {
+	w := get(newMux(), SpecPath)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.Equal(t, spec, w.Body.String())
+}
+
+func TestServeExplorer(t *testing.T) {
+	mux := newMux()
+
+	w := get(mux, ExplorerPath)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "swagger-ui-bundle.js")
+
+	// The explorer is bundled rather than loaded from the internet.
+	for _, file := range []string{"swagger-ui-bundle.js", "swagger-ui-standalone-preset.js", "swagger-ui.css"} {
+		w = get(mux, ExplorerPath+file)
+		require.Equal(t, http.StatusOK, w.Code, file)
+		require.NotEmpty(t, w.Body.Bytes(), file)
+	}
+
+	w = get(mux, ExplorerPath+initializerPath)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `url: "../openapi.json"`)
+
+	w = get(mux, "/docs")
+	require.Equal(t, http.StatusMovedPermanently, w.Code)
+	require.Equal(t, ExplorerPath, w.Header().Get("Location"))
+}
@@ -0,0 +1,241 @@
+// Package certs provides TLS configurations for servers and clients whose
+// certificates and certificate authorities are loaded from PEM files and
+// reloaded whenever the files change.
+//
+// Reloading lets certificates be rotated, e.g. by updating a Kubernetes
+// secret, without restarting the process. Connections established before a
+// reload keep the certificates they were established with; every new
+// handshake uses the certificates loaded last.
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultReloadInterval is the default interval between two checks of the
+// certificate files for changes.
+const DefaultReloadInterval = 30 * time.Second
+
+var (
+	errMissingKeyPair        = errors.New("certificate and key files must be given together")
+	errMissingCA             = errors.New("requiring client certificates needs a CA file")
+	errInvalidReloadInterval = errors.New("reload interval must be positive")
+	errNoCertificates        = errors.New("no certificates found")
+)
+
+// Config configures a Reloader.
+type Config struct {
+	// CertFile and KeyFile contain the PEM-encoded certificate chain and
+	// private key presented to peers. Servers need them, clients only to
+	// authenticate with a client certificate.
+	CertFile string
+	KeyFile  string
+	// CAFile contains the PEM-encoded certificates of the CAs verifying
+	// peers. Servers verify client certificates against them, and clients
+	// verify server certificates against them, or against the system pool if
+	// CAFile is empty.
+	CAFile string
+	// RequireClientCert makes servers reject clients without a certificate
+	// issued by a CA in CAFile. Without it, servers only verify client
+	// certificates that are presented.
+	RequireClientCert bool
+	// ReloadInterval is the interval between two checks of the files for
+	// changes.
+	ReloadInterval time.Duration
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c Config) Validate() error {
+	switch {
+	case (c.CertFile == "") != (c.KeyFile == ""):
+		return errMissingKeyPair
+	case c.RequireClientCert && c.CAFile == "":
+		return errMissingCA
+	case c.ReloadInterval <= 0:
+		return errInvalidReloadInterval
+	}
+	return nil
+}
+
+// files are the contents of the files configured by a Config.
+type files struct {
+	cert, key, ca []byte
+}
+
+func (f files) equal(other files) bool {
+	return bytes.Equal(f.cert, other.cert) && bytes.Equal(f.key, other.key) && bytes.Equal(f.ca, other.ca)
+}
+
+// Reloader provides the certificates and CAs configured by a Config,
+// reloading them when their files change. A Reloader is safe for concurrent
+// use.
+type Reloader struct {
+	config Config
+	logger *zap.Logger
+
+	// reloadMu serializes reloads.
+	reloadMu sync.Mutex
+	files    files
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// New returns a new Reloader providing the certificates and CAs configured
+// by "config". It fails if the files cannot be loaded.
+func New(config Config, logger *zap.Logger) (*Reloader, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	r := &Reloader{config: config, logger: logger}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Run reloads the files every r.config.ReloadInterval until ctx is done.
+// Failed reloads are logged, and the previously loaded certificates stay in
+// use.
+func (r *Reloader) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := r.Reload(); err != nil {
+			r.logger.Error("Failed to reload certificates", zap.Error(err))
+		}
+	}
+}
+
+// Reload reads the files and replaces the current certificates and CAs if
+// any of the files changed. The current certificates stay in use if the files
+// are invalid.
+func (r *Reloader) Reload() error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	f, err := r.read()
+	if err != nil {
+		return err
+	}
+	if r.files.equal(f) {
+		return nil
+	}
+
+	var (
+		cert *tls.Certificate
+		pool *x509.CertPool
+	)
+	if r.config.CertFile != "" {
+		c, err := tls.X509KeyPair(f.cert, f.key)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s and %s: %v", r.config.CertFile, r.config.KeyFile, err)
+		}
+		cert = &c
+	}
+	if r.config.CAFile != "" {
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(f.ca) {
+			return fmt.Errorf("failed to parse %s: %v", r.config.CAFile, errNoCertificates)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool = cert, pool
+	r.mu.Unlock()
+	r.files = f
+	r.logger.Info("Loaded certificates", zap.String("cert_file", r.config.CertFile), zap.String("ca_file", r.config.CAFile))
+	return nil
+}
+
+// read returns the contents of the configured files.
+func (r *Reloader) read() (files, error) {
+	var (
+		f   files
+		err error
+	)
+	for _, file := range []struct {
+		path     string
+		contents *[]byte
+	}{
+		{r.config.CertFile, &f.cert},
+		{r.config.KeyFile, &f.key},
+		{r.config.CAFile, &f.ca},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if *file.contents, err = ioutil.ReadFile(file.path); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+// ServerConfig returns the TLS configuration of servers. Every handshake uses
+// the certificates and CAs loaded last.
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.serverConfig(), nil
+		},
+	}
+}
+
+// serverConfig returns the TLS configuration of a single server handshake.
+func (r *Reloader) serverConfig() *tls.Config {
+	cert, pool := r.current()
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Offer HTTP/2 for gRPC and HTTP servers alike.
+		NextProtos: []string{"h2", "http/1.1"},
+		ClientCAs:  pool,
+	}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	switch {
+	case r.config.RequireClientCert:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	case pool != nil:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config
+}
+
+// clientConfig returns the TLS configuration of a single client handshake.
+func (r *Reloader) clientConfig() *tls.Config {
+	cert, pool := r.current()
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	return config
+}
@@ -0,0 +1,262 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// authority is a CA issuing certificates for tests.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+var serial int64
+
+func newTemplate(cn string) *x509.Certificate {
+	serial++
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
+
+func encode(t *testing.T, der []byte, key *ecdsa.PrivateKey) ([]byte, []byte) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newAuthority(t *testing.T, cn string) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := newTemplate(cn)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	certPEM, _ := encode(t, der, key)
+	return &authority{cert: cert, key: key, pem: certPEM}
+}
+
+// issue returns the PEM-encoded certificate and key for "cn", valid for
+// localhost.
+func (a *authority) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := newTemplate(cn)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	template.DNSNames = []string{"localhost"}
+	template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+	return encode(t, der, key)
+}
+
+// fixture holds the files of a CA, a server and a client certificate.
+type fixture struct {
+	dir                   string
+	ca                    *authority
+	caFile                string
+	serverCert, serverKey string
+	clientCert, clientKey string
+}
+
+func newFixture(t *testing.T) *fixture {
+	dir, err := ioutil.TempDir("", "certs")
+	require.NoError(t, err)
+	f := &fixture{dir: dir, ca: newAuthority(t, "ca")}
+	f.caFile = f.write(t, "ca.crt", f.ca.pem)
+	cert, key := f.ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	f.serverCert, f.serverKey = f.write(t, "server.crt", cert), f.write(t, "server.key", key)
+	cert, key = f.ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	f.clientCert, f.clientKey = f.write(t, "client.crt", cert), f.write(t, "client.key", key)
+	return f
+}
+
+func (f *fixture) write(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(f.dir, name)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	return path
+}
+
+func (f *fixture) close() {
+	os.RemoveAll(f.dir)
+}
+
+func newReloader(t *testing.T, config Config) *Reloader {
+	config.ReloadInterval = time.Hour
+	r, err := New(config, zap.NewNop())
+	require.NoError(t, err)
+	return r
+}
+
+// serveHealth serves the gRPC health service with the server credentials of
+// "r" and returns its address.
+func serveHealth(t *testing.T, r *Reloader) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.Creds(r.ServerCredentials()))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(l)
+	return l.Addr().String(), s.Stop
+}
+
+// checkHealth calls the health service at "address" over TLS with the client
+// credentials of "r".
+func checkHealth(t *testing.T, address string, r *Reloader) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(r.ClientCredentials("localhost")))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(false))
+	return err
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"server", Config{CertFile: "a.crt", KeyFile: "a.key", ReloadInterval: time.Second}, true},
+		{"mutual", Config{CertFile: "a.crt", KeyFile: "a.key", CAFile: "ca.crt", RequireClientCert: true, ReloadInterval: time.Second}, true},
+		{"client with system roots", Config{ReloadInterval: time.Second}, true},
+		{"cert without key", Config{CertFile: "a.crt", ReloadInterval: time.Second}, false},
+		{"key without cert", Config{KeyFile: "a.key", ReloadInterval: time.Second}, false},
+		{"client certs without CA", Config{CertFile: "a.crt", KeyFile: "a.key", RequireClientCert: true, ReloadInterval: time.Second}, false},
+		{"no reload interval", Config{CertFile: "a.crt", KeyFile: "a.key"}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestNewFailsOnInvalidFiles(t *testing.T) {
+	f := newFixture(t)
+	defer f.close()
+
+	_, err := New(Config{CertFile: f.serverCert, KeyFile: f.clientKey, ReloadInterval: time.Hour}, zap.NewNop())
+	require.Error(t, err)
+	_, err = New(Config{CertFile: f.serverCert, KeyFile: f.serverKey, CAFile: f.serverKey, ReloadInterval: time.Hour}, zap.NewNop())
+	require.Error(t, err)
+	_, err = New(Config{CertFile: filepath.Join(f.dir, "missing.crt"), KeyFile: f.serverKey, ReloadInterval: time.Hour}, zap.NewNop())
+	require.Error(t, err)
+}
+
+func TestGRPCOverTLS(t *testing.T) {
+	f := newFixture(t)
+	defer f.close()
+
+	address, stop := serveHealth(t, newReloader(t, Config{CertFile: f.serverCert, KeyFile: f.serverKey}))
+	defer stop()
+
+	require.NoError(t, checkHealth(t, address, newReloader(t, Config{CAFile: f.caFile})))
+	// The server certificate is not trusted by the system pool.
+	require.Error(t, checkHealth(t, address, newReloader(t, Config{})))
+}
+
+func TestGRPCOverMutualTLS(t *testing.T) {
+	f := newFixture(t)
+	defer f.close()
+
+	address, stop := serveHealth(t, newReloader(t, Config{
+		CertFile:          f.serverCert,
+		KeyFile:           f.serverKey,
+		CAFile:            f.caFile,
+		RequireClientCert: true,
+	}))
+	defer stop()
+
+	require.NoError(t, checkHealth(t, address, newReloader(t, Config{CertFile: f.clientCert, KeyFile: f.clientKey, CAFile: f.caFile})))
+	require.Error(t, checkHealth(t, address, newReloader(t, Config{CAFile: f.caFile})))
+
+	// Client certificates issued by other CAs are rejected.
+	cert, key := newAuthority(t, "other").issue(t, "client", x509.ExtKeyUsageClientAuth)
+	other := newReloader(t, Config{
+		CertFile: f.write(t, "other.crt", cert),
+		KeyFile:  f.write(t, "other.key", key),
+		CAFile:   f.caFile,
+	})
+	require.Error(t, checkHealth(t, address, other))
+}
+
+func TestReload(t *testing.T) {
+	f := newFixture(t)
+	defer f.close()
+
+	server := newReloader(t, Config{CertFile: f.serverCert, KeyFile: f.serverKey})
+	l, err := tls.Listen("tcp", "127.0.0.1:0", server.ServerConfig())
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	client := newReloader(t, Config{CAFile: f.caFile})
+	peer := func() string {
+		config := client.clientConfig()
+		config.ServerName = "localhost"
+		conn, err := tls.Dial("tcp", l.Addr().String(), config)
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	require.Equal(t, "server", peer())
+
+	// Unchanged files are not reloaded.
+	require.NoError(t, server.Reload())
+	require.Equal(t, "server", peer())
+
+	cert, key := f.ca.issue(t, "rotated", x509.ExtKeyUsageServerAuth)
+	f.write(t, "server.crt", cert)
+	f.write(t, "server.key", key)
+	require.NoError(t, server.Reload())
+	require.Equal(t, "rotated", peer())
+
+	// Invalid files keep the current certificate in use.
+	f.write(t, "server.key", []byte("garbage"))
+	require.Error(t, server.Reload())
+	require.Equal(t, "rotated", peer())
+}
@@ -0,0 +1,56 @@
+package certs
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// transportCredentials are gRPC transport credentials using the certificates
+// of a Reloader that are current at the time of each handshake.
+type transportCredentials struct {
+	reloader   *Reloader
+	serverName string
+}
+
+// ServerCredentials returns the gRPC transport credentials of servers.
+func (r *Reloader) ServerCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(r.ServerConfig())
+}
+
+// ClientCredentials returns the gRPC transport credentials of clients. Server
+// certificates are verified for "serverName", or for the host of the dialed
+// address if "serverName" is empty.
+func (r *Reloader) ClientCredentials(serverName string) credentials.TransportCredentials {
+	return &transportCredentials{reloader: r, serverName: serverName}
+}
+
+// tls returns the TLS credentials of a single handshake.
+func (c *transportCredentials) tls() credentials.TransportCredentials {
+	config := c.reloader.clientConfig()
+	config.ServerName = c.serverName
+	return credentials.NewTLS(config)
+}
+
+func (c *transportCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.tls().ClientHandshake(ctx, authority, conn)
+}
+
+func (c *transportCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.tls().ServerHandshake(conn)
+}
+
+func (c *transportCredentials) Info() credentials.ProtocolInfo {
+	return c.tls().Info()
+}
+
+func (c *transportCredentials) Clone() credentials.TransportCredentials {
+	clone := *c
+	return &clone
+}
+
+func (c *transportCredentials) OverrideServerName(serverName string) error {
+	c.serverName = serverName
+	return nil
+}
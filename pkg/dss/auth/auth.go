@@ -2,23 +2,49 @@ package auth
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
 	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+	"github.com/steeling/InterUSS-Platform/pkg/logging"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
+// DefaultClockSkew is the default tolerated difference between the clocks of
+// token issuers and the backend.
+const DefaultClockSkew = 30 * time.Second
+
 var (
 	// ContextKeyOwner is the key to an owner value.
 	ContextKeyOwner ContextKey = "owner"
+
+	// DefaultAlgorithms are the asymmetric signing algorithms supported by
+	// the keys of a KeyProvider.
+	DefaultAlgorithms = []string{
+		"RS256", "RS384", "RS512",
+		"PS256", "PS384", "PS512",
+		"ES256", "ES384", "ES512",
+	}
+
+	errMalformedToken       = errors.New("token is malformed")
+	errInvalidSignature     = errors.New("token signature is invalid")
+	errUnexpectedAlgorithm  = errors.New("token signing algorithm is not accepted")
+	errKeyAlgorithmMismatch = errors.New("token signing algorithm does not match the key")
+	errUnexpectedIssuer     = errors.New("token issuer is not accepted")
+	errUnexpectedAudience   = errors.New("token audience is not accepted")
+	errNegativeClockSkew    = errors.New("clock skew must not be negative")
 )
 
 // ContextKey models auth-specific keys in a context.
@@ -44,9 +70,52 @@ func OwnerFromContext(ctx context.Context) (models.Owner, bool) {
 	return owner, ok
 }
 
+// ValidationConfig configures the checks applied to tokens in addition to
+// verifying their signatures.
+type ValidationConfig struct {
+	// Issuers lists the accepted "iss" claims. All issuers are accepted if
+	// empty.
+	Issuers []string
+	// Audiences lists the accepted "aud" claims, tokens have to name at least
+	// one of them. All audiences are accepted if empty.
+	Audiences []string
+	// Algorithms lists the accepted signing algorithms. If empty, all
+	// algorithms matching the type of the verification key are accepted.
+	Algorithms []string
+	// ClockSkew is the tolerated difference between the clocks of the issuer
+	// and the backend when checking the "exp", "nbf" and "iat" claims.
+	ClockSkew time.Duration
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c ValidationConfig) Validate() error {
+	if c.ClockSkew < 0 {
+		return errNegativeClockSkew
+	}
+	for _, alg := range c.Algorithms {
+		if alg == "none" || jwt.GetSigningMethod(alg) == nil {
+			return fmt.Errorf("unsupported signing algorithm %q", alg)
+		}
+	}
+	return nil
+}
+
 type authClient struct {
-	key            interface{}
+	keys           KeyProvider
+	config         ValidationConfig
 	requiredScopes map[string][]string
+	// unauthenticated holds the names of the services whose RPCs do not
+	// require a token.
+	unauthenticated map[string]bool
+}
+
+// NewAuthClient returns a new authClient instance verifying tokens with the
+// keys provided by "keys" and validating them according to "config".
+func NewAuthClient(keys KeyProvider, config ValidationConfig) (*authClient, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &authClient{keys: keys, config: config}, nil
 }
 
 // NewSymmetricAuthClient returns a new authClient instance using symmetric keys.
@@ -55,11 +124,21 @@ func NewSymmetricAuthClient(keyFile string) (*authClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &authClient{key: bytes}, nil
+	return &authClient{keys: staticKey{bytes}}, nil
 }
 
 // NewRSAAuthClient returns a new authClient instance which uses RSA.
 func NewRSAAuthClient(keyFile string) (*authClient, error) {
+	keys, err := NewRSAKeyProvider(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &authClient{keys: keys}, nil
+}
+
+// NewRSAKeyProvider returns a KeyProvider returning the PEM-encoded RSA public
+// key in "keyFile" for all tokens.
+func NewRSAKeyProvider(keyFile string) (KeyProvider, error) {
 	bytes, err := ioutil.ReadFile(keyFile)
 	if err != nil {
 		return nil, err
@@ -73,41 +152,158 @@ func NewRSAAuthClient(keyFile string) (*authClient, error) {
 	if !ok {
 		return nil, fmt.Errorf("could not create rsa public key from %s", keyFile)
 	}
-	return &authClient{key: key}, nil
+	return staticKey{key}, nil
 }
 
 func (a *authClient) RequireScopes(scopes map[string][]string) {
 	a.requiredScopes = scopes
 }
 
+// AllowUnauthenticated exempts all RPCs of "services", e.g.
+// "grpc.health.v1.Health", from authentication. Their handlers find no owner
+// in their context.
+func (a *authClient) AllowUnauthenticated(services ...string) {
+	if a.unauthenticated == nil {
+		a.unauthenticated = make(map[string]bool)
+	}
+	for _, service := range services {
+		a.unauthenticated[service] = true
+	}
+}
+
 func (a *authClient) AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// AuthStreamInterceptor authenticates streaming calls like AuthInterceptor
+// does for unary calls.
+func (a *authClient) AuthStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	wrapped := grpc_middleware.WrapServerStream(ss)
+	wrapped.WrappedContext = ctx
+	return handler(srv, wrapped)
+}
+
+// authenticate validates the token in "ctx" and checks its scopes against the
+// ones required for "fullMethod". Returns "ctx" augmented with the owner
+// named by the token.
+func (a *authClient) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if parts := strings.Split(fullMethod, "/"); len(parts) == 3 && a.unauthenticated[parts[1]] {
+		return ctx, nil
+	}
 
 	tknStr, ok := getToken(ctx)
 	if !ok {
 		return nil, dsserr.Unauthenticated("missing token")
 	}
 
-	claims := claims{}
-
-	_, err := jwt.ParseWithClaims(tknStr, &claims, func(token *jwt.Token) (interface{}, error) {
-		return a.key, nil
-	})
+	claims, err := a.parse(ctx, tknStr)
 	if err != nil {
-		fmt.Println(err)
+		logging.WithValuesFromContext(ctx, logging.Logger).Info("Rejected token",
+			zap.String("method", fullMethod), zap.String("reason", err.Error()))
 		return nil, dsserr.Unauthenticated("invalid token")
 	}
 
-	if err := a.missingScopes(info, strings.Split(claims.ScopeString, " ")); err != nil {
+	if err := a.missingScopesForMethod(fullMethod, strings.Split(claims.ScopeString, " ")); err != nil {
 		return nil, dsserr.PermissionDenied(fmt.Sprintf("missing scopes: %v", err))
 	}
 
-	return handler(ContextWithOwner(ctx, models.Owner(claims.ClientID)), req)
+	return ContextWithOwner(ctx, models.Owner(claims.ClientID)), nil
+}
+
+// parse verifies the signature of the token in "tknStr", validates its
+// claims and returns them. The returned errors name the reason for rejecting
+// the token.
+func (a *authClient) parse(ctx context.Context, tknStr string) (*claims, error) {
+	var (
+		claims = &claims{}
+		// Claims are validated below to account for clock skew.
+		parser = &jwt.Parser{SkipClaimsValidation: true}
+	)
+	_, err := parser.ParseWithClaims(tknStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.key(ctx, token)
+	})
+	if ve, ok := err.(*jwt.ValidationError); ok {
+		switch {
+		case ve.Errors&jwt.ValidationErrorMalformed != 0:
+			return nil, errMalformedToken
+		case ve.Errors&jwt.ValidationErrorUnverifiable != 0 && ve.Inner != nil:
+			return nil, ve.Inner
+		case ve.Errors&jwt.ValidationErrorUnverifiable != 0:
+			// The parser does not know the signing algorithm.
+			return nil, errUnexpectedAlgorithm
+		default:
+			return nil, errInvalidSignature
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := claims.validate(jwt.TimeFunc(), a.config.ClockSkew); err != nil {
+		return nil, err
+	}
+	if len(a.config.Issuers) > 0 && !contains(a.config.Issuers, claims.Issuer) {
+		return nil, errUnexpectedIssuer
+	}
+	if len(a.config.Audiences) > 0 && !claims.Audience.contains(a.config.Audiences) {
+		return nil, errUnexpectedAudience
+	}
+	return claims, nil
+}
+
+// key returns the key verifying the signature of "token", making sure that
+// the signing algorithm is accepted and matches the type of the key. The
+// latter prevents verifying e.g. an HS256 signature with a public RSA key.
+func (a *authClient) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	if len(a.config.Algorithms) > 0 && !contains(a.config.Algorithms, token.Method.Alg()) {
+		return nil, errUnexpectedAlgorithm
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, err := a.keys.Key(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok = key.([]byte)
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok = key.(*rsa.PublicKey)
+	case *jwt.SigningMethodECDSA:
+		_, ok = key.(*ecdsa.PublicKey)
+	}
+	if !ok {
+		return nil, errKeyAlgorithmMismatch
+	}
+	return key, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 // Returns all of the required scopes that are missing.
 func (a *authClient) missingScopes(info *grpc.UnaryServerInfo, scopes []string) error {
+	return a.missingScopesForMethod(info.FullMethod, scopes)
+}
+
+func (a *authClient) missingScopesForMethod(fullMethod string, scopes []string) error {
 	var (
-		parts      = strings.Split(info.FullMethod, "/")
+		parts      = strings.Split(fullMethod, "/")
 		method     = parts[len(parts)-1]
 		claimedMap = make(map[string]bool)
 		err        = &missingScopesError{}
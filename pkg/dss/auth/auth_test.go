@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -78,7 +79,7 @@ func TestSymmetricAuthInterceptor(t *testing.T) {
 		{symmetricTokenCtx(ctx, hmacSampleSecret), codes.OK},
 	}
 
-	a := &authClient{key: hmacSampleSecret}
+	a := &authClient{keys: staticKey{hmacSampleSecret}}
 
 	for _, test := range authTests {
 		_, err := a.AuthInterceptor(test.ctx, nil, &grpc.UnaryServerInfo{},
@@ -116,7 +117,7 @@ func TestRSAAuthInterceptor(t *testing.T) {
 		{rsaTokenCtx(ctx, key, 100, 50), codes.Unauthenticated}, // Not valid yet
 	}
 
-	a := &authClient{key: &key.PublicKey}
+	a := &authClient{keys: staticKey{&key.PublicKey}}
 
 	for _, test := range authTests {
 		_, err := a.AuthInterceptor(test.ctx, nil, &grpc.UnaryServerInfo{},
@@ -127,6 +128,66 @@ func TestRSAAuthInterceptor(t *testing.T) {
 	}
 }
 
+// fakeServerStream is a grpc.ServerStream carrying a fixed context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthStreamInterceptor(t *testing.T) {
+	jwt.TimeFunc = func() time.Time {
+		return time.Unix(42, 0)
+	}
+	defer func() { jwt.TimeFunc = time.Now }()
+
+	ctx := context.Background()
+	var authTests = []struct {
+		ctx  context.Context
+		code codes.Code
+	}{
+		{ctx, codes.Unauthenticated},
+		{symmetricTokenCtx(ctx, []byte("bad_signing_key")), codes.Unauthenticated},
+		{symmetricTokenCtx(ctx, hmacSampleSecret), codes.OK},
+	}
+
+	a := &authClient{keys: staticKey{hmacSampleSecret}}
+
+	for _, test := range authTests {
+		err := a.AuthStreamInterceptor(nil, &fakeServerStream{ctx: test.ctx}, &grpc.StreamServerInfo{},
+			func(srv interface{}, ss grpc.ServerStream) error {
+				owner, ok := OwnerFromContext(ss.Context())
+				require.True(t, ok)
+				require.Equal(t, models.Owner("me"), owner)
+				return nil
+			})
+		if status.Code(err) != test.code {
+			t.Errorf("expected: %v, got: %v", test.code, status.Code(err))
+		}
+	}
+}
+
+func TestAllowUnauthenticated(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		a       = &authClient{keys: staticKey{hmacSampleSecret}}
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, ok := OwnerFromContext(ctx)
+			require.False(t, ok)
+			return nil, nil
+		}
+	)
+	a.AllowUnauthenticated("grpc.health.v1.Health")
+
+	_, err := a.AuthInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	require.NoError(t, err)
+	_, err = a.AuthInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/dssproto.DSService/GetSubscription"}, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
 func TestMissingScopes(t *testing.T) {
 	ac := &authClient{requiredScopes: map[string][]string{
 		"PutFoo": []string{"required1", "required2"},
@@ -193,3 +254,170 @@ func TestContextWithOwner(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, expected, owner)
 }
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestTokenValidation(t *testing.T) {
+	jwt.TimeFunc = func() time.Time {
+		return time.Unix(1000, 0)
+	}
+	defer func() { jwt.TimeFunc = time.Now }()
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	badKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	ac, err := NewAuthClient(staticKey{&key.PublicKey}, ValidationConfig{
+		Issuers:    []string{"https://auth.example.com"},
+		Audiences:  []string{"dss.example.com"},
+		Algorithms: []string{"RS256"},
+		ClockSkew:  10 * time.Second,
+	})
+	require.NoError(t, err)
+
+	// claimsWith returns valid claims, overridden by "overrides". A nil
+	// override removes the claim.
+	claimsWith := func(overrides jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"client_id": "me",
+			"iss":       "https://auth.example.com",
+			"aud":       "dss.example.com",
+			"exp":       1100,
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = v
+		}
+		return claims
+	}
+
+	for _, r := range []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{
+			name:  "valid",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(nil)),
+		},
+		{
+			name:  "audience array",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"aud": []string{"other", "dss.example.com"}})),
+		},
+		{
+			name:  "malformed",
+			token: "not-a-token",
+			err:   errMalformedToken,
+		},
+		{
+			name:  "wrong key",
+			token: signToken(t, jwt.SigningMethodRS256, badKey, claimsWith(nil)),
+			err:   errInvalidSignature,
+		},
+		{
+			name:  "algorithm not accepted",
+			token: signToken(t, jwt.SigningMethodRS512, key, claimsWith(nil)),
+			err:   errUnexpectedAlgorithm,
+		},
+		{
+			name:  "public key as HMAC secret",
+			token: signToken(t, jwt.SigningMethodHS256, publicKeyBytes, claimsWith(nil)),
+			err:   errUnexpectedAlgorithm,
+		},
+		{
+			name:  "unsigned",
+			token: signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claimsWith(nil)),
+			err:   errUnexpectedAlgorithm,
+		},
+		{
+			name:  "wrong issuer",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"iss": "https://other.example.com"})),
+			err:   errUnexpectedIssuer,
+		},
+		{
+			name:  "missing issuer",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"iss": nil})),
+			err:   errUnexpectedIssuer,
+		},
+		{
+			name:  "wrong audience",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"aud": []string{"other"}})),
+			err:   errUnexpectedAudience,
+		},
+		{
+			name:  "missing audience",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"aud": nil})),
+			err:   errUnexpectedAudience,
+		},
+		{
+			name:  "missing client_id",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"client_id": nil})),
+			err:   errMissingOrEmptyClientID,
+		},
+		{
+			name:  "expired within clock skew",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"exp": 995})),
+		},
+		{
+			name:  "expired",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"exp": 985})),
+			err:   errTokenExpired,
+		},
+		{
+			name:  "not valid yet within clock skew",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"nbf": 1005})),
+		},
+		{
+			name:  "not valid yet",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"nbf": 1015})),
+			err:   errTokenNotValidYet,
+		},
+		{
+			name:  "issued in the future",
+			token: signToken(t, jwt.SigningMethodRS256, key, claimsWith(jwt.MapClaims{"iat": 1015})),
+			err:   errTokenIssuedInFuture,
+		},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			_, err := ac.parse(context.Background(), r.token)
+			require.Equal(t, r.err, err)
+		})
+	}
+}
+
+func TestAlgorithmMustMatchKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	claims := jwt.MapClaims{"client_id": "me"}
+
+	// Without an allow-list, the type of the key restricts the algorithms.
+	ac := &authClient{keys: staticKey{&key.PublicKey}}
+	_, err = ac.parse(context.Background(), signToken(t, jwt.SigningMethodHS256, publicKeyBytes, claims))
+	require.Equal(t, errKeyAlgorithmMismatch, err)
+	_, err = ac.parse(context.Background(), signToken(t, jwt.SigningMethodPS256, key, claims))
+	require.NoError(t, err)
+
+	ac = &authClient{keys: staticKey{hmacSampleSecret}}
+	_, err = ac.parse(context.Background(), signToken(t, jwt.SigningMethodRS256, key, claims))
+	require.Equal(t, errKeyAlgorithmMismatch, err)
+}
+
+func TestValidationConfig(t *testing.T) {
+	require.NoError(t, ValidationConfig{}.Validate())
+	require.NoError(t, ValidationConfig{Algorithms: DefaultAlgorithms}.Validate())
+	require.Error(t, ValidationConfig{Algorithms: []string{"none"}}.Validate())
+	require.Error(t, ValidationConfig{Algorithms: []string{"XS256"}}.Validate())
+	require.Error(t, ValidationConfig{ClockSkew: -time.Second}.Validate())
+}
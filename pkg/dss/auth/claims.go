@@ -1,25 +1,76 @@
 package auth
 
 import (
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 var (
 	errMissingOrEmptyClientID = errors.New("Missing or empty client_id")
+	errTokenExpired           = errors.New("token is expired")
+	errTokenNotValidYet       = errors.New("token is not valid yet")
+	errTokenIssuedInFuture    = errors.New("token is issued in the future")
 )
 
+// audience is the "aud" claim, which is either a single string or an array of
+// strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = audience(multiple)
+	return nil
+}
+
+// contains returns true if a names any of "audiences".
+func (a audience) contains(audiences []string) bool {
+	for _, aud := range a {
+		for _, accepted := range audiences {
+			if aud == accepted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type claims struct {
 	jwt.StandardClaims
-	ClientID    string `json:"client_id"`
-	ScopeString string `json:"scope"`
+	// Audience shadows StandardClaims.Audience, which does not support
+	// arrays.
+	Audience    audience `json:"aud,omitempty"`
+	ClientID    string   `json:"client_id"`
+	ScopeString string   `json:"scope"`
 }
 
 func (c *claims) Valid() error {
+	return c.validate(jwt.TimeFunc(), 0)
+}
+
+// validate checks c at "now", tolerating clocks that are off by up to "skew".
+func (c *claims) validate(now time.Time, skew time.Duration) error {
 	if c.ClientID == "" {
 		return errMissingOrEmptyClientID
 	}
-
-	return c.StandardClaims.Valid()
+	if !c.VerifyExpiresAt(now.Add(-skew).Unix(), false) {
+		return errTokenExpired
+	}
+	if !c.VerifyNotBefore(now.Add(skew).Unix(), false) {
+		return errTokenNotValidYet
+	}
+	if !c.VerifyIssuedAt(now.Add(skew).Unix(), false) {
+		return errTokenIssuedInFuture
+	}
+	return nil
 }
@@ -0,0 +1,347 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultJWKSRefreshInterval is the default duration between two
+	// scheduled refreshes of a JWKS document.
+	DefaultJWKSRefreshInterval = 15 * time.Minute
+	// DefaultJWKSMinRefreshInterval is the default minimum duration between
+	// two refreshes triggered by tokens naming unknown keys.
+	DefaultJWKSMinRefreshInterval = 30 * time.Second
+	// DefaultJWKSGracePeriod is the default duration for which keys removed
+	// from a JWKS document are still accepted.
+	DefaultJWKSGracePeriod = time.Hour
+	// maxJWKSSize bounds the size of JWKS documents.
+	maxJWKSSize = 1 << 20
+)
+
+var (
+	errMissingJWKSSource         = errors.New("missing JWKS source")
+	errInvalidRefreshInterval    = errors.New("refresh interval must be positive")
+	errInvalidMinRefreshInterval = errors.New("minimum refresh interval must not be negative")
+	errInvalidGracePeriod        = errors.New("grace period must not be negative")
+	errNoKeysInJWKS              = errors.New("JWKS document contains no usable keys")
+	errMissingKeyID              = errors.New("token does not name its key")
+)
+
+// KeyProvider provides the keys for verifying the signatures of tokens.
+type KeyProvider interface {
+	// Key returns the key identified by "kid", the key ID found in the header
+	// of a token. "kid" is empty for tokens that do not name their key.
+	Key(ctx context.Context, kid string) (interface{}, error)
+}
+
+// staticKey is a KeyProvider returning the same key for all tokens.
+type staticKey struct {
+	key interface{}
+}
+
+func (s staticKey) Key(ctx context.Context, kid string) (interface{}, error) {
+	return s.key, nil
+}
+
+// JWKSConfig configures a JWKSProvider.
+type JWKSConfig struct {
+	// Source is the path of a file or the http(s) URL of the JWKS document.
+	Source string
+	// RefreshInterval is the duration between two scheduled refreshes.
+	RefreshInterval time.Duration
+	// MinRefreshInterval is the minimum duration between two refreshes
+	// triggered by tokens naming unknown keys.
+	MinRefreshInterval time.Duration
+	// GracePeriod is the duration for which keys are still accepted after
+	// they were removed from the JWKS document.
+	GracePeriod time.Duration
+}
+
+// retiredKey is a key that was removed from the JWKS document.
+type retiredKey struct {
+	key     interface{}
+	expires time.Time
+}
+
+// JWKSProvider is a KeyProvider serving the keys of a JSON Web Key Set (RFC
+// 7517), selecting keys by their ID. The set is refreshed periodically by Run
+// and whenever a token names an unknown key, so that signing keys can be
+// rotated without restarting the backend. Keys that disappear from the set
+// are still accepted for a grace period, so that tokens signed right before a
+// rotation stay valid.
+type JWKSProvider struct {
+	config JWKSConfig
+	client *http.Client
+	logger *zap.Logger
+	clock  func() time.Time
+
+	// refreshMu serializes refreshes.
+	refreshMu   sync.Mutex
+	lastRefresh time.Time
+
+	mu      sync.RWMutex
+	keys    map[string]interface{}
+	retired map[string]retiredKey
+}
+
+// NewJWKSProvider returns a new JWKSProvider loading the JWKS document
+// configured by "config", using "client" for documents served over HTTP. The
+// document is loaded by the first call to Refresh.
+func NewJWKSProvider(config JWKSConfig, client *http.Client, logger *zap.Logger) (*JWKSProvider, error) {
+	switch {
+	case config.Source == "":
+		return nil, errMissingJWKSSource
+	case config.RefreshInterval <= 0:
+		return nil, errInvalidRefreshInterval
+	case config.MinRefreshInterval < 0:
+		return nil, errInvalidMinRefreshInterval
+	case config.GracePeriod < 0:
+		return nil, errInvalidGracePeriod
+	}
+	return &JWKSProvider{
+		config:  config,
+		client:  client,
+		logger:  logger,
+		clock:   time.Now,
+		keys:    map[string]interface{}{},
+		retired: map[string]retiredKey{},
+	}, nil
+}
+
+// Run refreshes the keys every p.config.RefreshInterval until "ctx" is done.
+// Failed refreshes are logged, and the previously loaded keys stay in use.
+func (p *JWKSProvider) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := p.Refresh(ctx); err != nil {
+			p.logger.Error("Failed to refresh JWKS", zap.String("source", p.config.Source), zap.Error(err))
+		}
+	}
+}
+
+// Refresh loads the JWKS document and replaces the current keys with the ones
+// it contains. Keys that are not part of the document anymore are retired
+// and accepted until the grace period has passed.
+func (p *JWKSProvider) Refresh(ctx context.Context) error {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	return p.refresh(ctx)
+}
+
+// refresh implements Refresh. p.refreshMu must be held.
+func (p *JWKSProvider) refresh(ctx context.Context) error {
+	p.lastRefresh = p.clock()
+
+	document, err := p.load(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(document, p.logger)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock()
+	for kid, key := range p.keys {
+		if _, ok := keys[kid]; !ok {
+			p.retired[kid] = retiredKey{key: key, expires: now.Add(p.config.GracePeriod)}
+		}
+	}
+	for kid, key := range p.retired {
+		if _, ok := keys[kid]; ok || !now.Before(key.expires) {
+			delete(p.retired, kid)
+		}
+	}
+	p.keys = keys
+	return nil
+}
+
+// load returns the raw JWKS document.
+func (p *JWKSProvider) load(ctx context.Context) ([]byte, error) {
+	source := p.config.Source
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return ioutil.ReadFile(source)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS from %s failed with status %d", source, resp.StatusCode)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// Key returns the key identified by "kid". Unknown key IDs trigger a refresh
+// of the keys, unless the last refresh happened less than
+// p.config.MinRefreshInterval ago. If "kid" is empty, the only current key is
+// returned, if there is exactly one.
+func (p *JWKSProvider) Key(ctx context.Context, kid string) (interface{}, error) {
+	if key, err := p.lookup(kid); err == nil {
+		return key, nil
+	}
+
+	p.refreshMu.Lock()
+	if p.clock().Sub(p.lastRefresh) >= p.config.MinRefreshInterval {
+		if err := p.refresh(ctx); err != nil {
+			p.logger.Warn("Failed to refresh JWKS", zap.String("source", p.config.Source), zap.Error(err))
+		}
+	}
+	p.refreshMu.Unlock()
+
+	return p.lookup(kid)
+}
+
+// lookup returns the current or retired key identified by "kid".
+func (p *JWKSProvider) lookup(kid string) (interface{}, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if kid == "" {
+		if len(p.keys) != 1 {
+			return nil, errMissingKeyID
+		}
+		for _, key := range p.keys {
+			return key, nil
+		}
+	}
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if key, ok := p.retired[kid]; ok && p.clock().Before(key.expires) {
+		return key.key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// jsonWebKey holds the members of a JSON Web Key relevant for verifying
+// signatures, see RFC 7517 and RFC 7518.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// Elliptic curve keys.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns the signature verification keys in "document" by their
+// key IDs. Keys that are not meant for signatures or cannot be parsed are
+// skipped.
+func parseJWKS(document []byte, logger *zap.Logger) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(document, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.Warn("Skipping JWK", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errNoKeysInJWKS
+	}
+	return keys, nil
+}
+
+// publicKey returns the *rsa.PublicKey or *ecdsa.PublicKey described by k.
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// decodeBigInt decodes the base64url-encoded big-endian integer "s".
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	bytes, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// jwksServer serves a JWKS document that can be changed by tests.
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int64
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+func newJWKSServer(keys map[string]*rsa.PublicKey) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Inc()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, err := w.Write(jwksDocument(s.keys)); err != nil {
+			panic(err)
+		}
+	}))
+	return s
+}
+
+func (s *jwksServer) setKeys(keys map[string]*rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func jwksDocument(keys map[string]*rsa.PublicKey) []byte {
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	document, err := json.Marshal(set)
+	if err != nil {
+		panic(err)
+	}
+	return document
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	return key
+}
+
+func kidTokenCtx(t *testing.T, key *rsa.PrivateKey, kid string) context.Context {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"client_id": "me",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	tokenString, err := token.SignedString(key)
+	require.NoError(t, err)
+	return metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{
+		"Authorization": "Bearer " + tokenString,
+	}))
+}
+
+func authCode(ac *authClient, ctx context.Context) codes.Code {
+	_, err := ac.AuthInterceptor(ctx, nil, &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	return status.Code(err)
+}
+
+func newTestJWKSProvider(t *testing.T, source string) *JWKSProvider {
+	p, err := NewJWKSProvider(JWKSConfig{
+		Source:             source,
+		RefreshInterval:    time.Hour,
+		MinRefreshInterval: time.Minute,
+		GracePeriod:        time.Hour,
+	}, http.DefaultClient, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, p.Refresh(context.Background()))
+	return p
+}
+
+func TestJWKSSelectsKeyByID(t *testing.T) {
+	var (
+		first  = generateKey(t)
+		second = generateKey(t)
+		server = newJWKSServer(map[string]*rsa.PublicKey{"first": &first.PublicKey, "second": &second.PublicKey})
+	)
+	defer server.Close()
+	ac := &authClient{keys: newTestJWKSProvider(t, server.URL)}
+
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, first, "first")))
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, second, "second")))
+	require.Equal(t, codes.Unauthenticated, authCode(ac, kidTokenCtx(t, first, "second")))
+	require.Equal(t, codes.Unauthenticated, authCode(ac, kidTokenCtx(t, first, "unknown")))
+	// Tokens have to name their key if there is more than one.
+	require.Equal(t, codes.Unauthenticated, authCode(ac, kidTokenCtx(t, first, "")))
+}
+
+func TestJWKSWithoutKeyID(t *testing.T) {
+	var (
+		key    = generateKey(t)
+		server = newJWKSServer(map[string]*rsa.PublicKey{"only": &key.PublicKey})
+	)
+	defer server.Close()
+	ac := &authClient{keys: newTestJWKSProvider(t, server.URL)}
+
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, key, "")))
+}
+
+func TestJWKSRefreshesOnUnknownKeyID(t *testing.T) {
+	var (
+		old    = generateKey(t)
+		new    = generateKey(t)
+		server = newJWKSServer(map[string]*rsa.PublicKey{"old": &old.PublicKey})
+	)
+	defer server.Close()
+	p := newTestJWKSProvider(t, server.URL)
+	now := p.lastRefresh
+	p.clock = func() time.Time { return now }
+	ac := &authClient{keys: p}
+	require.Equal(t, int64(1), server.requests.Load())
+
+	// Unknown keys trigger a refresh, but not more often than allowed.
+	server.setKeys(map[string]*rsa.PublicKey{"new": &new.PublicKey})
+	require.Equal(t, codes.Unauthenticated, authCode(ac, kidTokenCtx(t, new, "new")))
+	require.Equal(t, int64(1), server.requests.Load())
+
+	now = now.Add(time.Minute)
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, new, "new")))
+	require.Equal(t, int64(2), server.requests.Load())
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, new, "new")))
+	require.Equal(t, int64(2), server.requests.Load())
+
+	// The old key is still accepted during the grace period.
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, old, "old")))
+	require.Equal(t, int64(2), server.requests.Load())
+
+	now = now.Add(time.Hour)
+	require.Equal(t, codes.Unauthenticated, authCode(ac, kidTokenCtx(t, old, "old")))
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, new, "new")))
+}
+
+func TestJWKSKeepsKeysIfRefreshFails(t *testing.T) {
+	var (
+		key    = generateKey(t)
+		server = newJWKSServer(map[string]*rsa.PublicKey{"key": &key.PublicKey})
+	)
+	p := newTestJWKSProvider(t, server.URL)
+	server.Close()
+
+	require.Error(t, p.Refresh(context.Background()))
+	require.Equal(t, codes.OK, authCode(&authClient{keys: p}, kidTokenCtx(t, key, "key")))
+}
+
+func TestJWKSRunRefreshesPeriodically(t *testing.T) {
+	var (
+		key    = generateKey(t)
+		server = newJWKSServer(map[string]*rsa.PublicKey{"key": &key.PublicKey})
+	)
+	defer server.Close()
+	p, err := NewJWKSProvider(JWKSConfig{
+		Source:          server.URL,
+		RefreshInterval: 10 * time.Millisecond,
+	}, http.DefaultClient, zap.NewNop())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- p.Run(ctx)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); server.requests.Load() < 3; {
+		require.True(t, time.Now().Before(deadline), "JWKS was not refreshed")
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+}
+
+func TestJWKSFromFile(t *testing.T) {
+	key := generateKey(t)
+	tmpfile, err := ioutil.TempFile("", "jwks.json")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Remove(tmpfile.Name()))
+	}()
+	_, err = tmpfile.Write(jwksDocument(map[string]*rsa.PublicKey{"key": &key.PublicKey}))
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+
+	ac := &authClient{keys: newTestJWKSProvider(t, tmpfile.Name())}
+	require.Equal(t, codes.OK, authCode(ac, kidTokenCtx(t, key, "key")))
+}
+
+func TestParseJWKS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	document := `{"keys":[
+		{"kty":"EC","kid":"ec","crv":"P-256","x":"` + base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()) + `","y":"` + base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()) + `"},
+		{"kty":"RSA","kid":"encryption","use":"enc","n":"AQAB","e":"AQAB"},
+		{"kty":"oct","kid":"symmetric","k":"c2VjcmV0"},
+		{"kty":"RSA","kid":"broken","n":"","e":"AQAB"}
+	]}`
+	keys, err := parseJWKS([]byte(document), zap.NewNop())
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, &ecKey.PublicKey, keys["ec"])
+
+	_, err = parseJWKS([]byte(`{"keys":[{"kty":"oct","kid":"symmetric","k":"c2VjcmV0"}]}`), zap.NewNop())
+	require.Equal(t, errNoKeysInJWKS, err)
+	_, err = parseJWKS([]byte(`not json`), zap.NewNop())
+	require.Error(t, err)
+}
+
+func TestNewJWKSProviderValidatesConfig(t *testing.T) {
+	for _, config := range []JWKSConfig{
+		{RefreshInterval: time.Minute},
+		{Source: "jwks.json"},
+		{Source: "jwks.json", RefreshInterval: time.Minute, MinRefreshInterval: -1},
+		{Source: "jwks.json", RefreshInterval: time.Minute, GracePeriod: -1},
+	} {
+		_, err := NewJWKSProvider(config, http.DefaultClient, zap.NewNop())
+		require.Error(t, err, "%+v", config)
+	}
+}
@@ -0,0 +1,138 @@
+package cockroach
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/geo/s2"
+	"github.com/lib/pq"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+)
+
+// cellIDs returns the representation of "cells" stored in the database.
+func cellIDs(cells s2.CellUnion) []int64 {
+	cids := make([]int64, len(cells))
+	for i, cell := range cells {
+		cids[i] = int64(cell)
+	}
+	return cids
+}
+
+// cellMatchArgs returns the query arguments for finding all entities that
+// intersect "cells", independent of the levels of the cells involved. Rows of
+// the cells tables match if
+//
+//	(cell_id = ANY(<first argument>) AND NOT ancestor)
+//	OR
+//	(cell_id = ANY(<second argument>) AND ancestor)
+//
+// See geo.Ancestors for details.
+func cellMatchArgs(cells s2.CellUnion) (interface{}, interface{}) {
+	cellsAndAncestors := append(append(s2.CellUnion{}, cells...), geo.Ancestors(cells)...)
+	return pq.Array(cellIDs(cellsAndAncestors)), pq.Array(cellIDs(cells))
+}
+
+// perCellMatchArgs returns the query arguments for finding the entities that
+// intersect each of "cells" separately. The arguments are parallel arrays of
+// rows (cell, cell_id, ancestor): rows of the cells tables that match a row's
+// cell_id and ancestor intersect the row's cell. This is the matching done by
+// cellMatchArgs, for one cell at a time, so that results can be grouped by
+// cell in a single query.
+func perCellMatchArgs(cells s2.CellUnion) (interface{}, interface{}, interface{}) {
+	var (
+		areas     []int64
+		matches   []int64
+		ancestors []bool
+	)
+	add := func(cell, match s2.CellID, ancestor bool) {
+		areas = append(areas, int64(cell))
+		matches = append(matches, int64(match))
+		ancestors = append(ancestors, ancestor)
+	}
+	for _, cell := range cells {
+		add(cell, cell, false)
+		add(cell, cell, true)
+		for level := cell.Level() - 1; level >= 0; level-- {
+			add(cell, cell.Parent(level), false)
+		}
+	}
+	return pq.Array(areas), pq.Array(matches), pq.Array(ancestors)
+}
+
+// pushCells replaces the cells indexing the entity identified by "id" with
+// "cells" and their ancestors. "table" names the cells table, "column" the
+// column referencing the entity.
+func pushCells(ctx context.Context, q queryable, table string, column string, id models.ID, cells s2.CellUnion) error {
+	var (
+		upsertQuery = fmt.Sprintf(`
+			UPSERT INTO
+				%s
+				(cell_id, cell_level, ancestor, %s)
+			VALUES
+				($1, $2, $3, $4)`, table, column)
+		deleteLeftOverCellsQuery = fmt.Sprintf(`
+			DELETE FROM
+				%s
+			WHERE
+				cell_id != ALL($1)
+			AND
+				%s = $2`, table, column)
+		ancestors = geo.Ancestors(cells)
+		cids      = make([]int64, 0, len(cells)+len(ancestors))
+	)
+
+	for _, cu := range []struct {
+		cells    s2.CellUnion
+		ancestor bool
+	}{
+		{cells, false},
+		{ancestors, true},
+	} {
+		for _, cell := range cu.cells {
+			if _, err := q.ExecContext(ctx, upsertQuery, int64(cell), cell.Level(), cu.ancestor, id); err != nil {
+				return err
+			}
+			cids = append(cids, int64(cell))
+		}
+	}
+
+	_, err := q.ExecContext(ctx, deleteLeftOverCellsQuery, pq.Array(cids), id)
+	return err
+}
+
+// fetchCells returns the cells indexing the entity identified by "id",
+// excluding their ancestors. "table" names the cells table, "column" the
+// column referencing the entity.
+func fetchCells(ctx context.Context, q queryable, table string, column string, id models.ID) (s2.CellUnion, error) {
+	var query = fmt.Sprintf(`
+		SELECT
+			cell_id
+		FROM
+			%s
+		WHERE
+			%s = $1
+		AND
+			NOT ancestor`, table, column)
+
+	rows, err := q.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		cell  int64
+		cells = s2.CellUnion{}
+	)
+	for rows.Next() {
+		if err := rows.Scan(&cell); err != nil {
+			return nil, err
+		}
+		cells = append(cells, s2.CellID(uint64(cell)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return cells, nil
+}
@@ -0,0 +1,48 @@
+package cockroach
+
+import (
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// matchRow is a row of the cells tables matched by a query.
+type matchRow struct {
+	cellID   int64
+	ancestor bool
+}
+
+func TestPerCellMatchArgs(t *testing.T) {
+	var (
+		leaf  = s2.CellIDFromLatLng(s2.LatLngFromDegrees(37.427636, -122.170502))
+		cells = s2.CellUnion{leaf.Parent(13), leaf.Parent(10).Next()}
+	)
+
+	areas, matches, ancestors := perCellMatchArgs(cells)
+	var (
+		areaIDs    = *areas.(*pq.Int64Array)
+		matchIDs   = *matches.(*pq.Int64Array)
+		isAncestor = *ancestors.(*pq.BoolArray)
+		got        = map[int64][]matchRow{}
+	)
+	require.Len(t, matchIDs, len(areaIDs))
+	require.Len(t, isAncestor, len(areaIDs))
+	for i, area := range areaIDs {
+		got[area] = append(got[area], matchRow{matchIDs[i], isAncestor[i]})
+	}
+
+	require.Len(t, got, len(cells))
+	for _, cell := range cells {
+		cellsAndAncestors, ownCells := cellMatchArgs(s2.CellUnion{cell})
+		var want []matchRow
+		for _, id := range *cellsAndAncestors.(*pq.Int64Array) {
+			want = append(want, matchRow{id, false})
+		}
+		for _, id := range *ownCells.(*pq.Int64Array) {
+			want = append(want, matchRow{id, true})
+		}
+		require.ElementsMatch(t, want, got[int64(cell)])
+	}
+}
@@ -7,14 +7,13 @@ import (
 	"time"
 
 	"github.com/golang/geo/s2"
-	"github.com/lib/pq"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
 	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
 	"go.uber.org/multierr"
 )
 
-var isaFields = "identification_service_areas.id, identification_service_areas.owner, identification_service_areas.url, identification_service_areas.starts_at, identification_service_areas.ends_at, identification_service_areas.updated_at"
-var isaFieldsWithoutPrefix = "id, owner, url, starts_at, ends_at, updated_at"
+var isaFields = "identification_service_areas.id, identification_service_areas.owner, identification_service_areas.url, identification_service_areas.starts_at, identification_service_areas.ends_at, identification_service_areas.altitude_lo, identification_service_areas.altitude_hi, identification_service_areas.updated_at"
+var isaFieldsWithoutPrefix = "id, owner, url, starts_at, ends_at, altitude_lo, altitude_hi, updated_at"
 
 func (c *Store) fetchISAs(ctx context.Context, q queryable, query string, args ...interface{}) ([]*models.IdentificationServiceArea, error) {
 	rows, err := q.QueryContext(ctx, query, args...)
@@ -33,6 +32,8 @@ func (c *Store) fetchISAs(ctx context.Context, q queryable, query string, args .
 			&i.Url,
 			&i.StartTime,
 			&i.EndTime,
+			&i.AltitudeLo,
+			&i.AltitudeHi,
 			&i.Version,
 		)
 		if err != nil {
@@ -82,90 +83,50 @@ func (c *Store) fetchISAByIDAndOwner(ctx context.Context, q queryable, id models
 }
 
 func (c *Store) populateISACells(ctx context.Context, q queryable, i *models.IdentificationServiceArea) error {
-	const query = `
-	SELECT
-		cell_id
-	FROM
-		cells_identification_service_areas
-	WHERE identification_service_area_id = $1`
-
-	rows, err := q.QueryContext(ctx, query, i.ID)
+	cells, err := fetchCells(ctx, q, "cells_identification_service_areas", "identification_service_area_id", i.ID)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-	var cell int64
-	i.Cells = s2.CellUnion{}
-
-	for rows.Next() {
-		if err := rows.Scan(&cell); err != nil {
-			return err
-		}
-		i.Cells = append(i.Cells, s2.CellID(uint64(cell)))
-	}
-	if err := rows.Err(); err != nil {
-		return err
-	}
+	i.Cells = cells
 	return nil
 }
 
 // pushISA creates/updates the IdentificationServiceArea
 // identified by "id" and owned by "owner", affecting "cells" in the time
-// interval ["starts", "ends"].
+// interval ["starts", "ends"]. "old" is the stored version of the ISA
+// including its cells, or nil if the ISA is created.
 //
 // Returns the created/updated IdentificationServiceArea and all Subscriptions
-// affected by the operation.
-func (c *Store) pushISA(ctx context.Context, q queryable, isa *models.IdentificationServiceArea) (*models.IdentificationServiceArea, []*models.Subscription, error) {
+// affected by the operation, i.e. those affected by the new or the old
+// extents of the ISA.
+func (c *Store) pushISA(ctx context.Context, q queryable, isa *models.IdentificationServiceArea, old *models.IdentificationServiceArea) (*models.IdentificationServiceArea, []*models.Subscription, error) {
 	var (
 		upsertAreasQuery = fmt.Sprintf(`
 			UPSERT INTO
 				identification_service_areas
 				(%s)
 			VALUES
-				($1, $2, $3, $4, $5, transaction_timestamp())
+				($1, $2, $3, $4, $5, $6, $7, transaction_timestamp())
 			RETURNING
 				%s`, isaFieldsWithoutPrefix, isaFields)
-		upsertCellsForAreaQuery = `
-			UPSERT INTO
-				cells_identification_service_areas
-				(cell_id, cell_level, identification_service_area_id)
-			VALUES
-				($1, $2, $3)`
-		deleteLeftOverCellsForAreaQuery = `
-			DELETE FROM
-				cells_identification_service_areas
-			WHERE
-				cell_id != ALL($1)
-			AND
-				identification_service_area_id = $2`
 	)
 
-	cids := make([]int64, len(isa.Cells))
-	clevels := make([]int, len(isa.Cells))
-
-	for i, cell := range isa.Cells {
-		cids[i] = int64(cell)
-		clevels[i] = cell.Level()
-	}
-
 	cells := isa.Cells
-	isa, err := c.fetchISA(ctx, q, upsertAreasQuery, isa.ID, isa.Owner, isa.Url, isa.StartTime, isa.EndTime)
+	isa, err := c.fetchISA(ctx, q, upsertAreasQuery, isa.ID, isa.Owner, isa.Url, isa.StartTime, isa.EndTime, isa.AltitudeLo, isa.AltitudeHi)
 	if err != nil {
 		return nil, nil, err
 	}
 	isa.Cells = cells
 
-	for i := range cids {
-		if _, err := q.ExecContext(ctx, upsertCellsForAreaQuery, cids[i], clevels[i], isa.ID); err != nil {
-			return nil, nil, err
-		}
-	}
-
-	if _, err := q.ExecContext(ctx, deleteLeftOverCellsForAreaQuery, pq.Array(cids), isa.ID); err != nil {
+	if err := pushCells(ctx, q, "cells_identification_service_areas", "identification_service_area_id", isa.ID, cells); err != nil {
 		return nil, nil, err
 	}
 
-	subscriptions, err := c.fetchSubscriptionsByCellsWithoutOwner(ctx, q, cids, isa.Owner)
+	extents := []*models.IdentificationServiceArea{isa}
+	if old != nil {
+		extents = append(extents, old)
+	}
+	subscriptions, err := c.incrementNotificationIndices(ctx, q, extents...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -182,29 +143,37 @@ func (c *Store) GetISA(ctx context.Context, id models.ID) (*models.Identificatio
 // by "owner", affecting "cells" in the time interval ["starts", "ends"].
 //
 // Returns the created IdentificationServiceArea and all Subscriptions affected
-// by it.
-func (c *Store) InsertISA(ctx context.Context, isa *models.IdentificationServiceArea) (*models.IdentificationServiceArea, []*models.Subscription, error) {
-	tx, err := c.Begin()
-	if err != nil {
-		return nil, nil, err
-	}
+// by it. The notifications returned by "notify", if not nil, are inserted in
+// the same transaction.
+func (c *Store) InsertISA(ctx context.Context, isa *models.IdentificationServiceArea, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error) {
+	var (
+		area        *models.IdentificationServiceArea
+		subscribers []*models.Subscription
+	)
 
-	old, err := c.fetchISAByIDAndOwner(ctx, tx, isa.ID, isa.Owner)
-	switch {
-	case err == sql.ErrNoRows:
-		break
-	case err != nil:
-		return nil, nil, multierr.Combine(err, tx.Rollback())
-	case !isa.Version.Empty() && !isa.Version.Matches(old.Version):
-		return nil, nil, multierr.Combine(dsserr.VersionMismatch("old version"), tx.Rollback())
-	}
+	err := c.inTx(ctx, func(q queryable) error {
+		old, err := c.fetchISAByIDAndOwner(ctx, q, isa.ID, isa.Owner)
+		switch {
+		case err == sql.ErrNoRows:
+			break
+		case err != nil:
+			return err
+		case !isa.Version.Empty() && !isa.Version.Matches(old.Version):
+			return dsserr.VersionMismatch("old version")
+		default:
+			// Subscriptions the ISA is moved away from are affected, too.
+			if err := c.populateISACells(ctx, q, old); err != nil {
+				return err
+			}
+		}
 
-	area, subscribers, err := c.pushISA(ctx, tx, isa)
+		area, subscribers, err = c.pushISA(ctx, q, isa, old)
+		if err != nil {
+			return err
+		}
+		return c.insertNotifications(ctx, q, notify, area, subscribers)
+	})
 	if err != nil {
-		return nil, nil, multierr.Combine(err, tx.Rollback())
-	}
-
-	if err := tx.Commit(); err != nil {
 		return nil, nil, err
 	}
 
@@ -213,7 +182,9 @@ func (c *Store) InsertISA(ctx context.Context, isa *models.IdentificationService
 
 // DeleteISA deletes the IdentificationServiceArea identified by "id" and owned by "owner".
 // Returns the delete IdentificationServiceArea and all Subscriptions affected by the delete.
-func (c *Store) DeleteISA(ctx context.Context, id models.ID, owner models.Owner, version *models.Version) (*models.IdentificationServiceArea, []*models.Subscription, error) {
+// The notifications returned by "notify", if not nil, are inserted in the same
+// transaction.
+func (c *Store) DeleteISA(ctx context.Context, id models.ID, owner models.Owner, version *models.Version, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error) {
 	var (
 		deleteQuery = `
 			DELETE FROM
@@ -225,41 +196,37 @@ func (c *Store) DeleteISA(ctx context.Context, id models.ID, owner models.Owner,
 			RETURNING
 				*
 		`
+		old           *models.IdentificationServiceArea
+		subscriptions []*models.Subscription
 	)
 
-	tx, err := c.Begin()
-	if err != nil {
-		return nil, nil, err
-	}
+	err := c.inTx(ctx, func(q queryable) error {
+		var err error
+		// We fetch to know whether to return a concurrency error, or a not found error
+		old, err = c.fetchISAByIDAndOwner(ctx, q, id, owner)
+		switch {
+		case err == sql.ErrNoRows: // Return a 404 here.
+			return dsserr.NotFound(id.String())
+		case err != nil:
+			return err
+		case !version.Empty() && !version.Matches(old.Version):
+			return dsserr.VersionMismatch("old version")
+		}
+		if err := c.populateISACells(ctx, q, old); err != nil {
+			return err
+		}
 
-	// We fetch to know whether to return a concurrency error, or a not found error
-	old, err := c.fetchISAByIDAndOwner(ctx, tx, id, owner)
-	switch {
-	case err == sql.ErrNoRows: // Return a 404 here.
-		return nil, nil, multierr.Combine(dsserr.NotFound(id.String()), tx.Rollback())
-	case err != nil:
-		return nil, nil, multierr.Combine(err, tx.Rollback())
-	case !version.Empty() && !version.Matches(old.Version):
-		return nil, nil, multierr.Combine(dsserr.VersionMismatch("old version"), tx.Rollback())
-	}
-	if err := c.populateISACells(ctx, tx, old); err != nil {
-		return nil, nil, multierr.Combine(err, tx.Rollback())
-	}
+		subscriptions, err = c.incrementNotificationIndices(ctx, q, old)
+		if err != nil {
+			return err
+		}
 
-	cids := make([]int64, len(old.Cells))
-	for i, cell := range old.Cells {
-		cids[i] = int64(cell)
-	}
-	subscriptions, err := c.fetchSubscriptionsByCellsWithoutOwner(ctx, tx, cids, owner)
+		if _, err := q.ExecContext(ctx, deleteQuery, id, owner); err != nil {
+			return err
+		}
+		return c.insertNotifications(ctx, q, notify, old, subscriptions)
+	})
 	if err != nil {
-		return nil, nil, multierr.Combine(err, tx.Rollback())
-	}
-
-	if _, err := tx.ExecContext(ctx, deleteQuery, id, owner); err != nil {
-		return nil, nil, multierr.Combine(err, tx.Rollback())
-	}
-
-	if err := tx.Commit(); err != nil {
 		return nil, nil, err
 	}
 
@@ -268,8 +235,9 @@ func (c *Store) DeleteISA(ctx context.Context, id models.ID, owner models.Owner,
 
 // SearchISAs searches IdentificationServiceArea
 // instances that intersect with "cells" and, if set, the temporal volume
-// defined by "earliest" and "latest".
-func (c *Store) SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *time.Time, latest *time.Time) ([]*models.IdentificationServiceArea, error) {
+// defined by "earliest" and "latest" and the vertical interval defined by
+// "minAltitude" and "maxAltitude", restricted to "page".
+func (c *Store) SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *time.Time, latest *time.Time, minAltitude *float32, maxAltitude *float32, page *models.Page) ([]*models.IdentificationServiceArea, error) {
 	var (
 		serviceAreasInCellsQuery = fmt.Sprintf(`
 			SELECT
@@ -282,40 +250,68 @@ func (c *Store) SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *ti
 				FROM
 					cells_identification_service_areas
 				WHERE
-					cells_identification_service_areas.cell_id = ANY($1)
+					(cells_identification_service_areas.cell_id = ANY($1) AND NOT cells_identification_service_areas.ancestor)
+				OR
+					(cells_identification_service_areas.cell_id = ANY($2) AND cells_identification_service_areas.ancestor)
 				)
 			AS
 				unique_identification_service_areas
 			ON
 				identification_service_areas.id = unique_identification_service_areas.identification_service_area_id
 			WHERE
-				COALESCE(identification_service_areas.starts_at >= $2, true)
+				COALESCE(identification_service_areas.starts_at >= $3, true)
 			AND
-				COALESCE(identification_service_areas.ends_at <= $3, true)`, isaFields)
+				COALESCE(identification_service_areas.ends_at <= $4, true)
+			AND
+				COALESCE(identification_service_areas.altitude_hi >= $5, true)
+			AND
+				COALESCE(identification_service_areas.altitude_lo <= $6, true)
+			AND
+				COALESCE((identification_service_areas.updated_at, identification_service_areas.id) > ($7::TIMESTAMPTZ, $8::UUID), true)
+			ORDER BY
+				identification_service_areas.updated_at, identification_service_areas.id
+			%s`, isaFields, limitClause(page, 9))
 	)
 
 	if len(cells) == 0 {
 		return nil, dsserr.BadRequest("missing cell IDs for query")
 	}
 
-	cids := make([]int64, len(cells))
-	for i, cid := range cells {
-		cids[i] = int64(cid)
-	}
+	cellsAndAncestors, ownCells := cellMatchArgs(cells)
 
-	tx, err := c.Begin()
+	var result []*models.IdentificationServiceArea
+	err := c.inTx(ctx, func(q queryable) error {
+		var err error
+		result, err = c.fetchISAs(ctx, q, serviceAreasInCellsQuery, append([]interface{}{cellsAndAncestors, ownCells, earliest, latest, minAltitude, maxAltitude}, pageArgs(page)...)...)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := c.fetchISAs(ctx, tx, serviceAreasInCellsQuery, pq.Array(cids), earliest, latest)
-	if err != nil {
-		return nil, multierr.Combine(err, tx.Rollback())
-	}
+	return result, nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
+// DeleteExpiredISAs deletes at most "limit" IdentificationServiceAreas that
+// ended before "threshold". Cells are removed by the ON DELETE CASCADE
+// constraint of cells_identification_service_areas.
+//
+// Concurrent calls are safe, rows that have already been deleted by a
+// different caller simply don't count towards the result.
+func (c *Store) DeleteExpiredISAs(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	const query = `
+		DELETE FROM
+			identification_service_areas
+		WHERE
+			ends_at < $1
+		ORDER BY
+			ends_at
+		LIMIT
+			$2`
 
-	return result, nil
+	result, err := c.ExecContext(ctx, query, threshold, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
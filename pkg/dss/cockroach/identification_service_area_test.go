@@ -54,7 +54,7 @@ func TestStoreSearchISAs(t *testing.T) {
 	for _, r := range serviceAreasPool {
 		copy := *r.input
 		copy.Cells = cells
-		saOut, _, err := store.InsertISA(ctx, &copy)
+		saOut, _, err := store.InsertISA(ctx, &copy, nil)
 		require.NoError(t, err)
 		require.NotNil(t, saOut)
 		require.Equal(t, copy.ID, saOut.ID)
@@ -140,7 +140,7 @@ func TestStoreSearchISAs(t *testing.T) {
 			for _, sa := range insertedServiceAreas {
 				earliest, latest := r.timestampMutator(*sa.StartTime, *sa.EndTime)
 
-				serviceAreas, err := store.SearchISAs(ctx, r.cells, earliest, latest)
+				serviceAreas, err := store.SearchISAs(ctx, r.cells, earliest, latest, nil, nil, nil)
 				require.NoError(t, err)
 				require.Len(t, serviceAreas, r.expectedLen)
 			}
@@ -165,7 +165,7 @@ func TestStoreDeleteISAs(t *testing.T) {
 	for _, r := range subscriptionsPool {
 		copy := *r.input
 		copy.Cells = s2.CellUnion{s2.CellID(42)}
-		s1, err := store.InsertSubscription(ctx, &copy)
+		s1, err := store.InsertSubscription(ctx, &copy, 0)
 		require.NoError(t, err)
 		require.NotNil(t, s1)
 		insertedSubscriptions = append(insertedSubscriptions, s1)
@@ -173,7 +173,7 @@ func TestStoreDeleteISAs(t *testing.T) {
 
 	for _, r := range serviceAreasPool {
 		tx, _ := store.Begin()
-		isa, _, err := store.pushISA(ctx, tx, r.input)
+		isa, _, err := store.pushISA(ctx, tx, r.input, nil)
 		tx.Commit()
 		require.NoError(t, err)
 		require.NotNil(t, isa)
@@ -182,7 +182,7 @@ func TestStoreDeleteISAs(t *testing.T) {
 	}
 
 	for _, sa := range insertedServiceAreas {
-		serviceAreaOut, subscriptionsOut, err := store.DeleteISA(ctx, sa.ID, sa.Owner, sa.Version)
+		serviceAreaOut, subscriptionsOut, err := store.DeleteISA(ctx, sa.ID, sa.Owner, sa.Version, nil)
 		require.NoError(t, err)
 		require.NotNil(t, serviceAreaOut)
 		require.NotNil(t, subscriptionsOut)
@@ -198,7 +198,7 @@ func TestInsertISA(t *testing.T) {
 		require.NoError(t, tearDownStore())
 	}()
 	for _, r := range serviceAreasPool {
-		area, _, err := store.InsertISA(ctx, r.input)
+		area, _, err := store.InsertISA(ctx, r.input, nil)
 		require.NoError(t, err)
 		require.NotNil(t, area)
 	}
@@ -0,0 +1,251 @@
+package cockroach
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// migration describes a single, forward-only change to the database schema.
+type migration struct {
+	version     int
+	description string
+	statements  string
+}
+
+// migrations lists all schema migrations in the order in which they have to
+// be applied. Versions start at 1 and must be contiguous. The effect of
+// applied migrations must never be changed, add a new migration instead.
+//
+// Statements must be idempotent, e.g. by using IF NOT EXISTS: CockroachDB
+// runs schema changes after their transaction committed, so a migration may
+// have taken effect without being recorded in schema_versions.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create subscriptions and identification service areas",
+		statements: `
+		CREATE TABLE IF NOT EXISTS subscriptions (
+			id UUID PRIMARY KEY,
+			owner STRING NOT NULL,
+			url STRING NOT NULL,
+			notification_index INT4 DEFAULT 0,
+			starts_at TIMESTAMPTZ,
+			ends_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ NOT NULL,
+			INDEX owner_idx (owner),
+			INDEX starts_at_idx (starts_at),
+			INDEX ends_at_idx (ends_at),
+			CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+		);
+		CREATE TABLE IF NOT EXISTS cells_subscriptions (
+			cell_id INT64 NOT NULL,
+			cell_level INT CHECK (cell_level BETWEEN 0 and 30),
+			subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+			PRIMARY KEY (cell_id, subscription_id),
+			INDEX cell_id_idx (cell_id),
+			INDEX subscription_id_idx (subscription_id)
+		);
+		CREATE TABLE IF NOT EXISTS identification_service_areas (
+			id UUID PRIMARY KEY,
+			owner STRING NOT NULL,
+			url STRING NOT NULL,
+			starts_at TIMESTAMPTZ,
+			ends_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ NOT NULL,
+			INDEX owner_idx (owner),
+			INDEX starts_at_idx (starts_at),
+			INDEX ends_at_idx (ends_at),
+			INDEX updated_at_idx (updated_at),
+			CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+		);
+		CREATE TABLE IF NOT EXISTS cells_identification_service_areas (
+			cell_id INT64 NOT NULL,
+			cell_level INT CHECK (cell_level BETWEEN 0 and 30),
+			identification_service_area_id UUID NOT NULL REFERENCES identification_service_areas (id) ON DELETE CASCADE,
+			PRIMARY KEY (cell_id, identification_service_area_id),
+			INDEX cell_id_idx (cell_id),
+			INDEX identification_service_area_id_idx (identification_service_area_id)
+		);`,
+	},
+	{
+		version:     2,
+		description: "add altitudes to subscriptions and identification service areas",
+		statements: `
+		ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS altitude_lo REAL;
+		ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS altitude_hi REAL;
+		ALTER TABLE identification_service_areas ADD COLUMN IF NOT EXISTS altitude_lo REAL;
+		ALTER TABLE identification_service_areas ADD COLUMN IF NOT EXISTS altitude_hi REAL;`,
+	},
+	{
+		// Constraints are added separately as CockroachDB does not allow
+		// referencing columns added in the same transaction.
+		version:     3,
+		description: "constrain altitudes of subscriptions and identification service areas",
+		statements: `
+		ALTER TABLE subscriptions ADD CONSTRAINT IF NOT EXISTS altitude_lo_below_altitude_hi
+			CHECK (altitude_lo IS NULL OR altitude_hi IS NULL OR altitude_lo <= altitude_hi);
+		ALTER TABLE identification_service_areas ADD CONSTRAINT IF NOT EXISTS altitude_lo_below_altitude_hi
+			CHECK (altitude_lo IS NULL OR altitude_hi IS NULL OR altitude_lo <= altitude_hi);`,
+	},
+	{
+		version:     4,
+		description: "create notifications outbox",
+		statements: `
+		CREATE TABLE IF NOT EXISTS notifications (
+			id UUID PRIMARY KEY,
+			url STRING NOT NULL,
+			payload BYTES NOT NULL,
+			attempts INT4 NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMPTZ NOT NULL,
+			last_error STRING NOT NULL DEFAULT '',
+			dead_lettered_at TIMESTAMPTZ,
+			INDEX next_attempt_at_idx (next_attempt_at)
+		);`,
+	},
+	{
+		// Existing rows are the cells of their entities. Ancestors are added
+		// by the next write to an entity, or by re-indexing.
+		version:     5,
+		description: "index ancestors of cells",
+		statements: `
+		ALTER TABLE cells_subscriptions ADD COLUMN IF NOT EXISTS ancestor BOOL NOT NULL DEFAULT false;
+		ALTER TABLE cells_identification_service_areas ADD COLUMN IF NOT EXISTS ancestor BOOL NOT NULL DEFAULT false;`,
+	},
+	{
+		version:     6,
+		description: "index dead-lettered notifications",
+		statements: `
+		CREATE INDEX IF NOT EXISTS dead_lettered_at_idx ON notifications (dead_lettered_at);`,
+	},
+}
+
+// uniqueViolation is the SQLSTATE code reported for duplicate keys.
+const uniqueViolation = "23505"
+
+// SchemaVersion is the version of the database schema this version of the
+// code understands.
+var SchemaVersion = migrations[len(migrations)-1].version
+
+// ErrSchemaTooNew is returned if the database schema was migrated by a newer
+// version of the code.
+type ErrSchemaTooNew struct {
+	Version int
+}
+
+func (e *ErrSchemaTooNew) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than supported version %d", e.Version, SchemaVersion)
+}
+
+// ErrSchemaTooOld is returned if the database schema lacks migrations
+// required by this version of the code.
+type ErrSchemaTooOld struct {
+	Version int
+}
+
+func (e *ErrSchemaTooOld) Error() string {
+	return fmt.Sprintf("database schema version %d is older than required version %d, run migrations first", e.Version, SchemaVersion)
+}
+
+// currentSchemaVersion returns the latest schema version recorded in the
+// schema_versions table, or 0 if no migration has been applied yet.
+func (s *Store) currentSchemaVersion(ctx context.Context, q queryable) (int, error) {
+	const query = `SELECT COALESCE(MAX(version), 0) FROM schema_versions`
+
+	var version int
+	err := q.QueryRowContext(ctx, query).Scan(&version)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "42P01" { // undefined_table
+		return 0, nil
+	}
+	return version, err
+}
+
+// CheckSchemaVersion returns an error if the schema of the underlying
+// database is not exactly at SchemaVersion.
+func (s *Store) CheckSchemaVersion(ctx context.Context) error {
+	version, err := s.currentSchemaVersion(ctx, s.DB)
+	switch {
+	case err != nil:
+		return err
+	case version > SchemaVersion:
+		return &ErrSchemaTooNew{Version: version}
+	case version < SchemaVersion:
+		return &ErrSchemaTooOld{Version: version}
+	}
+	return nil
+}
+
+// Migrate applies all pending migrations to the underlying database and
+// returns the number of applied migrations. It refuses to touch a database
+// whose schema is newer than SchemaVersion.
+//
+// Every migration is applied in its own transaction together with its record
+// in schema_versions. Several instances can safely migrate the same database
+// concurrently: all but one of them fail to record each migration, roll back
+// their transaction and continue with the migration recorded by the winner.
+func (s *Store) Migrate(ctx context.Context) (int, error) {
+	const (
+		createQuery = `
+		CREATE TABLE IF NOT EXISTS schema_versions (
+			version INT PRIMARY KEY,
+			description STRING NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL
+		)`
+		insertQuery = `
+		INSERT INTO
+			schema_versions
+			(version, description, applied_at)
+		VALUES
+			($1, $2, transaction_timestamp())`
+	)
+
+	if _, err := s.ExecContext(ctx, createQuery); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		m := m
+		migrated := false
+		err := s.inTx(ctx, func(q queryable) error {
+			migrated = false
+			version, err := s.currentSchemaVersion(ctx, q)
+			switch {
+			case err != nil:
+				return err
+			case version > SchemaVersion:
+				return &ErrSchemaTooNew{Version: version}
+			case version >= m.version:
+				return nil
+			}
+
+			if _, err := q.ExecContext(ctx, m.statements); err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %v", m.version, m.description, err)
+			}
+			if _, err := q.ExecContext(ctx, insertQuery, m.version, m.description); err != nil {
+				return err
+			}
+			migrated = true
+			return nil
+		})
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+			// Another instance recorded the migration first.
+			version, verr := s.currentSchemaVersion(ctx, s.DB)
+			switch {
+			case verr != nil:
+				return applied, verr
+			case version < m.version:
+				return applied, err
+			}
+			continue
+		}
+		if err != nil {
+			return applied, err
+		}
+		if migrated {
+			applied++
+		}
+	}
+	return applied, nil
+}
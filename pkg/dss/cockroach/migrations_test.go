@@ -0,0 +1,114 @@
+package cockroach
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsAreContiguous(t *testing.T) {
+	for i, m := range migrations {
+		require.Equal(t, i+1, m.version)
+		require.NotEmpty(t, m.description)
+		require.NotEmpty(t, m.statements)
+	}
+	require.Equal(t, len(migrations), SchemaVersion)
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	var (
+		ctx                  = context.Background()
+		store, tearDownStore = setUpStore(ctx, t)
+	)
+	defer func() {
+		require.NoError(t, tearDownStore())
+	}()
+
+	applied, err := store.Migrate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, applied)
+	require.NoError(t, store.CheckSchemaVersion(ctx))
+}
+
+func TestMigrateAppliesPendingMigrations(t *testing.T) {
+	var (
+		ctx                  = context.Background()
+		store, tearDownStore = setUpStore(ctx, t)
+	)
+	defer func() {
+		require.NoError(t, tearDownStore())
+	}()
+
+	require.NoError(t, store.cleanUp(ctx))
+	require.Equal(t, &ErrSchemaTooOld{Version: 0}, store.CheckSchemaVersion(ctx))
+
+	applied, err := store.Migrate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, SchemaVersion, applied)
+	require.NoError(t, store.CheckSchemaVersion(ctx))
+}
+
+func TestConcurrentMigrationsApplyEachMigrationOnce(t *testing.T) {
+	var (
+		ctx                  = context.Background()
+		store, tearDownStore = setUpStore(ctx, t)
+		results              = make(chan int, 4)
+		errs                 = make(chan error, cap(results))
+	)
+	defer func() {
+		require.NoError(t, tearDownStore())
+	}()
+
+	require.NoError(t, store.cleanUp(ctx))
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			applied, err := store.Migrate(ctx)
+			results <- applied
+			errs <- err
+		}()
+	}
+
+	total := 0
+	for i := 0; i < cap(results); i++ {
+		require.NoError(t, <-errs)
+		total += <-results
+	}
+	require.Equal(t, SchemaVersion, total)
+	require.NoError(t, store.CheckSchemaVersion(ctx))
+}
+
+func TestMigrationsAreRepeatable(t *testing.T) {
+	var (
+		ctx                  = context.Background()
+		store, tearDownStore = setUpStore(ctx, t)
+	)
+	defer func() {
+		require.NoError(t, tearDownStore())
+	}()
+
+	// Migrations may have taken effect without being recorded.
+	for _, m := range migrations {
+		_, err := store.ExecContext(ctx, m.statements)
+		require.NoError(t, err, "migration %d", m.version)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	var (
+		ctx                  = context.Background()
+		store, tearDownStore = setUpStore(ctx, t)
+	)
+	defer func() {
+		require.NoError(t, tearDownStore())
+	}()
+
+	_, err := store.ExecContext(ctx,
+		`INSERT INTO schema_versions (version, description, applied_at) VALUES ($1, 'from the future', now())`,
+		SchemaVersion+1)
+	require.NoError(t, err)
+
+	_, err = store.Migrate(ctx)
+	require.Equal(t, &ErrSchemaTooNew{Version: SchemaVersion + 1}, err)
+	require.Equal(t, &ErrSchemaTooNew{Version: SchemaVersion + 1}, store.CheckSchemaVersion(ctx))
+}
@@ -0,0 +1,177 @@
+package cockroach
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+var notificationFields = "id, url, payload, attempts, next_attempt_at, last_error, dead_lettered_at"
+
+func (c *Store) fetchNotifications(ctx context.Context, q queryable, query string, args ...interface{}) ([]*models.Notification, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payload []*models.Notification
+	for rows.Next() {
+		n := new(models.Notification)
+
+		err := rows.Scan(
+			&n.ID,
+			&n.Url,
+			&n.Payload,
+			&n.Attempts,
+			&n.NextAttemptAt,
+			&n.LastError,
+			&n.DeadLetteredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payload = append(payload, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// insertNotifications persists the notifications returned by "notify", if
+// not nil, for the change of "isa" affecting "subscriptions".
+func (c *Store) insertNotifications(ctx context.Context, q queryable, notify models.NotificationsFunc, isa *models.IdentificationServiceArea, subscriptions []*models.Subscription) error {
+	var insertQuery = fmt.Sprintf(`
+		INSERT INTO
+			notifications
+			(%s)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)`, notificationFields)
+
+	if notify == nil {
+		return nil
+	}
+	notifications, err := notify(isa, subscriptions)
+	if err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		if _, err := q.ExecContext(ctx, insertQuery,
+			n.ID,
+			n.Url,
+			n.Payload,
+			n.Attempts,
+			n.NextAttemptAt,
+			n.LastError,
+			n.DeadLetteredAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LeaseNotifications returns at most "limit" notifications that are due at
+// "now", ordered by their next attempt, and pushes their next attempt back to
+// now + "lease". The update is atomic, concurrent callers never lease the
+// same notification.
+func (c *Store) LeaseNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.Notification, error) {
+	var leaseQuery = fmt.Sprintf(`
+		UPDATE
+			notifications
+		SET
+			next_attempt_at = $2
+		WHERE
+			dead_lettered_at IS NULL
+		AND
+			next_attempt_at <= $1
+		ORDER BY
+			next_attempt_at
+		LIMIT
+			$3
+		RETURNING
+			%s`, notificationFields)
+
+	var notifications []*models.Notification
+	err := c.inTx(ctx, func(q queryable) error {
+		var err error
+		notifications, err = c.fetchNotifications(ctx, q, leaseQuery, now, now.Add(lease), limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// DeleteNotification removes the notification identified by "id".
+func (c *Store) DeleteNotification(ctx context.Context, id models.ID) error {
+	const deleteQuery = `
+		DELETE FROM
+			notifications
+		WHERE
+			id = $1`
+
+	result, err := c.ExecContext(ctx, deleteQuery, id)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result.RowsAffected, id)
+}
+
+// UpdateNotification persists the delivery state of "n".
+func (c *Store) UpdateNotification(ctx context.Context, n *models.Notification) error {
+	const updateQuery = `
+		UPDATE
+			notifications
+		SET
+			attempts = $2,
+			next_attempt_at = $3,
+			last_error = $4,
+			dead_lettered_at = $5
+		WHERE
+			id = $1`
+
+	result, err := c.ExecContext(ctx, updateQuery, n.ID, n.Attempts, n.NextAttemptAt, n.LastError, n.DeadLetteredAt)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result.RowsAffected, n.ID)
+}
+
+// DeleteDeadLetteredNotifications deletes at most "limit" notifications that
+// were dead-lettered before "threshold" and returns the number of deleted
+// notifications.
+func (c *Store) DeleteDeadLetteredNotifications(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	const query = `
+		DELETE FROM
+			notifications
+		WHERE
+			dead_lettered_at < $1
+		ORDER BY
+			dead_lettered_at
+		LIMIT
+			$2`
+
+	result, err := c.ExecContext(ctx, query, threshold, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// requireRowsAffected returns a NotFound error for "id" if "rowsAffected"
+// reports that no row was touched.
+func requireRowsAffected(rowsAffected func() (int64, error), id models.ID) error {
+	n, err := rowsAffected()
+	switch {
+	case err != nil:
+		return err
+	case n == 0:
+		return dsserr.NotFound(id.String())
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cockroach
+
+import (
+	"fmt"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+)
+
+// pageArgs returns the query arguments for the cursor of "page", i.e. the
+// updated_at timestamp and the id of the last entity already returned. Both
+// are NULL if the search starts at the beginning, or if it isn't paginated
+// at all, and the accompanying (updated_at, id) comparison must be wrapped in
+// COALESCE(..., true).
+//
+// The limit, if any, is appended to the arguments, too, and must be referenced
+// by limitClause.
+func pageArgs(page *models.Page) []interface{} {
+	args := []interface{}{nil, nil}
+	if page == nil {
+		return args
+	}
+	if page.After != nil {
+		args[0], args[1] = page.After.UpdatedAt, page.After.ID
+	}
+	if page.Limit > 0 {
+		args = append(args, page.Limit)
+	}
+	return args
+}
+
+// limitClause returns the LIMIT clause for "page", referring to the limit
+// argument returned by pageArgs as placeholder "$<index>". An empty string is
+// returned if the search is unlimited.
+func limitClause(page *models.Page, index int) string {
+	if page == nil || page.Limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("LIMIT $%d", index)
+}
@@ -0,0 +1,126 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+// idAfter returns the query argument for listing entities with IDs greater
+// than "after". An empty "after" yields NULL, the accompanying comparison must
+// be wrapped in COALESCE(..., true).
+func idAfter(after models.ID) interface{} {
+	if after == "" {
+		return nil
+	}
+	return after
+}
+
+// ListISAs returns at most "limit" IdentificationServiceAreas with IDs greater
+// than "after", ordered by ID.
+func (c *Store) ListISAs(ctx context.Context, after models.ID, limit int) ([]*models.IdentificationServiceArea, error) {
+	var query = fmt.Sprintf(`
+		SELECT
+			%s
+		FROM
+			identification_service_areas
+		WHERE
+			COALESCE(id > $1::UUID, true)
+		ORDER BY
+			id
+		LIMIT
+			$2`, isaFields)
+
+	var result []*models.IdentificationServiceArea
+	err := c.inTx(ctx, func(q queryable) error {
+		var err error
+		result, err = c.fetchISAs(ctx, q, query, idAfter(after), limit)
+		if err != nil {
+			return err
+		}
+		for _, isa := range result {
+			if err := c.populateISACells(ctx, q, isa); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateISACells replaces the cells of the IdentificationServiceArea
+// identified by "id" if it is still at "version".
+func (c *Store) UpdateISACells(ctx context.Context, id models.ID, version *models.Version, cells s2.CellUnion) error {
+	return c.inTx(ctx, func(q queryable) error {
+		old, err := c.fetchISAByID(ctx, q, id)
+		switch {
+		case err == sql.ErrNoRows:
+			return dsserr.NotFound(id.String())
+		case err != nil:
+			return err
+		case !version.Matches(old.Version):
+			return dsserr.VersionMismatch("old version")
+		}
+		return pushCells(ctx, q, "cells_identification_service_areas", "identification_service_area_id", id, cells)
+	})
+}
+
+// ListSubscriptions returns at most "limit" subscriptions with IDs greater
+// than "after", ordered by ID.
+func (c *Store) ListSubscriptions(ctx context.Context, after models.ID, limit int) ([]*models.Subscription, error) {
+	var query = fmt.Sprintf(`
+		SELECT
+			%s
+		FROM
+			subscriptions
+		WHERE
+			COALESCE(id > $1::UUID, true)
+		ORDER BY
+			id
+		LIMIT
+			$2`, subscriptionFields)
+
+	var result []*models.Subscription
+	err := c.inTx(ctx, func(q queryable) error {
+		var err error
+		result, err = c.fetchSubscriptions(ctx, q, query, idAfter(after), limit)
+		if err != nil {
+			return err
+		}
+		for _, sub := range result {
+			sub.Cells, err = fetchCells(ctx, q, "cells_subscriptions", "subscription_id", sub.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdateSubscriptionCells replaces the cells of the subscription identified
+// by "id" if it is still at "version".
+func (c *Store) UpdateSubscriptionCells(ctx context.Context, id models.ID, version *models.Version, cells s2.CellUnion) error {
+	return c.inTx(ctx, func(q queryable) error {
+		old, err := c.fetchSubscriptionByID(ctx, q, id)
+		switch {
+		case err == sql.ErrNoRows:
+			return dsserr.NotFound(id.String())
+		case err != nil:
+			return err
+		case !version.Matches(old.Version):
+			return dsserr.VersionMismatch("old version")
+		}
+		return pushCells(ctx, q, "cells_subscriptions", "subscription_id", id, cells)
+	})
+}
@@ -10,7 +10,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/dispatcher"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/reindex"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/storetest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -18,17 +21,19 @@ import (
 var (
 	// Make sure that Store implements dss.Store.
 	_ dss.Store = &Store{}
+	// Make sure that Store implements dispatcher.Outbox.
+	_ dispatcher.Outbox = &Store{}
+	// Make sure that Store implements reindex.Store.
+	_ reindex.Store = &Store{}
 
+	// storeURI is parsed by the testing package. Calling flag.Parse from an
+	// init function fails since go 1.13, which registers the test flags later.
 	storeURI  = flag.String("store-uri", "", "URI pointing to a Cockroach node")
 	tempTime  = time.Now()
 	startTime = tempTime.AddDate(0, 0, -1)
 	endTime   = tempTime.AddDate(0, 0, 1)
 )
 
-func init() {
-	flag.Parse()
-}
-
 func setUpStore(ctx context.Context, t *testing.T) (*Store, func() error) {
 	store, err := newStore()
 	if err != nil {
@@ -40,6 +45,24 @@ func setUpStore(ctx context.Context, t *testing.T) (*Store, func() error) {
 	}
 }
 
+func TestStoreConformance(t *testing.T) {
+	storetest.Run(t, func(ctx context.Context, t *testing.T) (dss.Store, func() error) {
+		return setUpStore(ctx, t)
+	})
+}
+
+func TestOutboxConformance(t *testing.T) {
+	storetest.RunOutbox(t, func(ctx context.Context, t *testing.T) (storetest.OutboxStore, func() error) {
+		return setUpStore(ctx, t)
+	})
+}
+
+func TestReindexConformance(t *testing.T) {
+	storetest.RunReindex(t, func(ctx context.Context, t *testing.T) (storetest.ReindexStore, func() error) {
+		return setUpStore(ctx, t)
+	})
+}
+
 func newStore() (*Store, error) {
 	if len(*storeURI) == 0 {
 		return nil, errors.New("Missing command-line parameter store-uri")
@@ -85,7 +108,7 @@ func TestDatabaseEnsuresBeginsBeforeExpires(t *testing.T) {
 		NotificationIndex: 42,
 		StartTime:         &begins,
 		EndTime:           &expires,
-	})
+	}, 0)
 	require.Error(t, err)
 }
 
@@ -4,16 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/golang/geo/s2"
-	"github.com/lib/pq"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
 	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
 	"go.uber.org/multierr"
 )
 
-var subscriptionFields = "subscriptions.id, subscriptions.owner, subscriptions.url, subscriptions.notification_index, subscriptions.starts_at, subscriptions.ends_at, subscriptions.updated_at"
-var subscriptionFieldsWithoutPrefix = "id, owner, url, notification_index, starts_at, ends_at, updated_at"
+var subscriptionFields = "subscriptions.id, subscriptions.owner, subscriptions.url, subscriptions.notification_index, subscriptions.starts_at, subscriptions.ends_at, subscriptions.altitude_lo, subscriptions.altitude_hi, subscriptions.updated_at"
+var subscriptionFieldsWithoutPrefix = "id, owner, url, notification_index, starts_at, ends_at, altitude_lo, altitude_hi, updated_at"
 
 func (c *Store) fetchSubscriptions(ctx context.Context, q queryable, query string, args ...interface{}) ([]*models.Subscription, error) {
 	rows, err := q.QueryContext(ctx, query, args...)
@@ -33,6 +34,8 @@ func (c *Store) fetchSubscriptions(ctx context.Context, q queryable, query strin
 			&s.NotificationIndex,
 			&s.StartTime,
 			&s.EndTime,
+			&s.AltitudeLo,
+			&s.AltitudeHi,
 			&s.Version,
 		)
 		if err != nil {
@@ -46,22 +49,59 @@ func (c *Store) fetchSubscriptions(ctx context.Context, q queryable, query strin
 	return payload, nil
 }
 
-func (c *Store) fetchSubscriptionsByCellsWithoutOwner(ctx context.Context, q queryable, cells []int64, owner models.Owner) ([]*models.Subscription, error) {
-	var subscriptionsQuery = fmt.Sprintf(`
-		 SELECT
-				%s
-			FROM
-				subscriptions
-			LEFT JOIN 
-				(SELECT DISTINCT subscription_id FROM cells_subscriptions WHERE cell_id = ANY($1))
-			AS
-				unique_subscription_ids
-			ON
-				subscriptions.id = unique_subscription_ids.subscription_id
-			WHERE
-				subscriptions.owner != $2`, subscriptionFields)
+// incrementNotificationIndices increments the notification index of all
+// subscriptions affected by any of "isas", the extents of the same ISA before
+// and after a change. A subscription is affected by an extent if it is not
+// owned by the owner of the ISA and intersects the cells, the vertical extent
+// and the time window of that extent. Missing bounds are treated as
+// unbounded, and intervals that merely touch are considered to intersect.
+// Subscriptions affected by several extents are incremented once.
+//
+// Returns the affected subscriptions including their new notification index.
+func (c *Store) incrementNotificationIndices(ctx context.Context, q queryable, isas ...*models.IdentificationServiceArea) ([]*models.Subscription, error) {
+	var (
+		extents []string
+		args    = []interface{}{isas[0].Owner}
+	)
+	for _, isa := range isas {
+		n := len(args)
+		extents = append(extents, fmt.Sprintf(`(
+				id IN (
+					SELECT DISTINCT
+						subscription_id
+					FROM
+						cells_subscriptions
+					WHERE
+						(cell_id = ANY($%d) AND NOT ancestor)
+					OR
+						(cell_id = ANY($%d) AND ancestor)
+				)
+			AND
+				COALESCE(altitude_hi >= $%d, true)
+			AND
+				COALESCE(altitude_lo <= $%d, true)
+			AND
+				COALESCE(ends_at >= $%d, true)
+			AND
+				COALESCE(starts_at <= $%d, true)
+			)`, n+1, n+2, n+3, n+4, n+5, n+6))
+		cellsAndAncestors, ownCells := cellMatchArgs(isa.Cells)
+		args = append(args, cellsAndAncestors, ownCells, isa.AltitudeLo, isa.AltitudeHi, isa.StartTime, isa.EndTime)
+	}
+
+	var updateQuery = fmt.Sprintf(`
+		UPDATE
+			subscriptions
+		SET
+			notification_index = notification_index + 1
+		WHERE
+			owner != $1
+		AND
+			(%s)
+		RETURNING
+			%s`, strings.Join(extents, " OR "), subscriptionFields)
 
-	return c.fetchSubscriptions(ctx, q, subscriptionsQuery, pq.Array(cells), owner)
+	return c.fetchSubscriptions(ctx, q, updateQuery, args...)
 }
 
 func (c *Store) fetchSubscription(ctx context.Context, q queryable, query string, args ...interface{}) (*models.Subscription, error) {
@@ -100,33 +140,11 @@ func (c *Store) pushSubscription(ctx context.Context, q queryable, s *models.Sub
 		  subscriptions
 		  (%s)
 		VALUES
-			($1, $2, $3, $4, $5, $6, transaction_timestamp())
+			($1, $2, $3, $4, $5, $6, $7, $8, transaction_timestamp())
 		RETURNING
 			%s`, subscriptionFieldsWithoutPrefix, subscriptionFields)
-		subscriptionCellQuery = `
-		UPSERT INTO
-			cells_subscriptions
-			(cell_id, cell_level, subscription_id)
-		VALUES
-			($1, $2, $3)
-		`
-		deleteLeftOverCellsForSubscriptionQuery = `
-			DELETE FROM
-				cells_subscriptions
-			WHERE
-				cell_id != ALL($1)
-			AND
-				subscription_id = $2`
 	)
 
-	cids := make([]int64, len(s.Cells))
-	clevels := make([]int, len(s.Cells))
-
-	for i, cell := range s.Cells {
-		cids[i] = int64(cell)
-		clevels[i] = cell.Level()
-	}
-
 	cells := s.Cells
 	s, err := c.fetchSubscription(ctx, q, upsertQuery,
 		s.ID,
@@ -134,25 +152,66 @@ func (c *Store) pushSubscription(ctx context.Context, q queryable, s *models.Sub
 		s.Url,
 		s.NotificationIndex,
 		s.StartTime,
-		s.EndTime)
+		s.EndTime,
+		s.AltitudeLo,
+		s.AltitudeHi)
 	if err != nil {
 		return nil, err
 	}
 	s.Cells = cells
 
-	for i := range cids {
-		if _, err := q.ExecContext(ctx, subscriptionCellQuery, cids[i], clevels[i], s.ID); err != nil {
-			return nil, err
-		}
-	}
-
-	if _, err := q.ExecContext(ctx, deleteLeftOverCellsForSubscriptionQuery, pq.Array(cids), s.ID); err != nil {
+	if err := pushCells(ctx, q, "cells_subscriptions", "subscription_id", s.ID, cells); err != nil {
 		return nil, err
 	}
 
 	return s, nil
 }
 
+// countSubscriptionsPerArea returns an error if the owner of "s" holds
+// "maxPerArea" or more other active subscriptions intersecting one of the
+// cells of "s". All cells are counted by a single query. The cells of the
+// counted subscriptions are read in the transaction of the write, so that
+// serializable isolation prevents concurrent writes from exceeding the limit.
+func (c *Store) countSubscriptionsPerArea(ctx context.Context, q queryable, s *models.Subscription, maxPerArea int) error {
+	const query = `
+		SELECT
+			area.cell
+		FROM
+			unnest($1::INT8[], $2::INT8[], $3::BOOL[]) AS area(cell, cell_id, ancestor)
+		JOIN
+			cells_subscriptions
+		ON
+			cells_subscriptions.cell_id = area.cell_id
+		AND
+			cells_subscriptions.ancestor = area.ancestor
+		JOIN
+			subscriptions
+		ON
+			subscriptions.id = cells_subscriptions.subscription_id
+		WHERE
+			subscriptions.owner = $4
+		AND
+			subscriptions.id != $5
+		AND
+			COALESCE(subscriptions.ends_at >= transaction_timestamp(), true)
+		GROUP BY
+			area.cell
+		HAVING
+			COUNT(DISTINCT subscriptions.id) >= $6
+		LIMIT 1`
+
+	areas, matches, ancestors := perCellMatchArgs(s.Cells)
+	rows, err := q.QueryContext(ctx, query, areas, matches, ancestors, s.Owner, s.ID, maxPerArea)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		return dsserr.Exhausted(fmt.Sprintf("too many subscriptions in area, at most %d are allowed per owner", maxPerArea))
+	}
+	return rows.Err()
+}
+
 // Get returns the subscription identified by "id".
 func (c *Store) GetSubscription(ctx context.Context, id models.ID) (*models.Subscription, error) {
 	return c.fetchSubscriptionByID(ctx, c.DB, id)
@@ -160,30 +219,42 @@ func (c *Store) GetSubscription(ctx context.Context, id models.ID) (*models.Subs
 
 // Insert inserts subscription into the store and returns
 // the resulting subscription including its ID.
-func (c *Store) InsertSubscription(ctx context.Context, s *models.Subscription) (*models.Subscription, error) {
+func (c *Store) InsertSubscription(ctx context.Context, s *models.Subscription, maxPerArea int) (*models.Subscription, error) {
+	var result *models.Subscription
+
+	err := c.inTx(ctx, func(q queryable) error {
+		old, err := c.fetchSubscriptionByID(ctx, q, s.ID)
+		switch {
+		case err == sql.ErrNoRows:
+			break
+		case err != nil:
+			return err
+		case !s.Version.Empty() && !s.Version.Matches(old.Version):
+			return dsserr.VersionMismatch("old version")
+		}
 
-	tx, err := c.Begin()
-	if err != nil {
-		return nil, err
-	}
-	old, err := c.fetchSubscriptionByID(ctx, tx, s.ID)
-	switch {
-	case err == sql.ErrNoRows:
-		break
-	case err != nil:
-		return nil, multierr.Combine(err, tx.Rollback())
-	case !s.Version.Empty() && !s.Version.Matches(old.Version):
-		return nil, multierr.Combine(dsserr.VersionMismatch("old version"), tx.Rollback())
-	}
+		if maxPerArea > 0 {
+			if err := c.countSubscriptionsPerArea(ctx, q, s, maxPerArea); err != nil {
+				return err
+			}
+		}
 
-	s, err = c.pushSubscription(ctx, tx, s)
+		updated := s
+		if old != nil {
+			// The notification index is owned by the DSS, it must not be reset by
+			// updates to the subscription.
+			copy := *s
+			copy.NotificationIndex = old.NotificationIndex
+			updated = &copy
+		}
+
+		result, err = c.pushSubscription(ctx, q, updated)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
-	return s, nil
+	return result, nil
 }
 
 // DeleteSubscription deletes the subscription identified by "id" and
@@ -198,73 +269,101 @@ func (c *Store) DeleteSubscription(ctx context.Context, id models.ID, owner mode
 			AND owner = $2`
 	)
 
-	tx, err := c.Begin()
-	if err != nil {
-		return nil, err
-	}
-
-	// We fetch to know whether to return a concurrency error, or a not found error
-	old, err := c.fetchSubscriptionByIDAndOwner(ctx, tx, id, owner)
-	switch {
-	case err == sql.ErrNoRows: // Return a 404 here.
-		return nil, multierr.Combine(dsserr.NotFound(id.String()), tx.Rollback())
-	case err != nil:
-		return nil, multierr.Combine(err, tx.Rollback())
-	case !version.Empty() && !version.Matches(old.Version):
-		return nil, multierr.Combine(dsserr.VersionMismatch("old version"), tx.Rollback())
-	}
-
-	if _, err := tx.ExecContext(ctx, query, id, owner); err != nil {
-		return nil, multierr.Combine(err, tx.Rollback())
-	}
+	var old *models.Subscription
+	err := c.inTx(ctx, func(q queryable) error {
+		var err error
+		// We fetch to know whether to return a concurrency error, or a not found error
+		old, err = c.fetchSubscriptionByIDAndOwner(ctx, q, id, owner)
+		switch {
+		case err == sql.ErrNoRows: // Return a 404 here.
+			return dsserr.NotFound(id.String())
+		case err != nil:
+			return err
+		case !version.Empty() && !version.Matches(old.Version):
+			return dsserr.VersionMismatch("old version")
+		}
 
-	if err := tx.Commit(); err != nil {
+		_, err = q.ExecContext(ctx, query, id, owner)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return old, nil
 }
 
-// SearchSubscriptions returns all subscriptions in "cells".
-func (c *Store) SearchSubscriptions(ctx context.Context, cells s2.CellUnion, owner models.Owner) ([]*models.Subscription, error) {
+// SearchSubscriptions returns all subscriptions owned by "owner" in "cells",
+// restricted to "page".
+func (c *Store) SearchSubscriptions(ctx context.Context, cells s2.CellUnion, owner models.Owner, page *models.Page) ([]*models.Subscription, error) {
 	var (
 		query = fmt.Sprintf(`
 			SELECT
 				%s
 			FROM
 				subscriptions
-			LEFT JOIN 
-				(SELECT DISTINCT cells_subscriptions.subscription_id FROM cells_subscriptions WHERE cells_subscriptions.cell_id = ANY($1))
+			JOIN
+				(SELECT DISTINCT
+					cells_subscriptions.subscription_id
+				FROM
+					cells_subscriptions
+				WHERE
+					(cells_subscriptions.cell_id = ANY($1) AND NOT cells_subscriptions.ancestor)
+				OR
+					(cells_subscriptions.cell_id = ANY($2) AND cells_subscriptions.ancestor)
+				)
 			AS
 				unique_subscription_ids
 			ON
 				subscriptions.id = unique_subscription_ids.subscription_id
 			WHERE
-				subscriptions.owner = $2`, subscriptionFields)
+				subscriptions.owner = $3
+			AND
+				COALESCE((subscriptions.updated_at, subscriptions.id) > ($4::TIMESTAMPTZ, $5::UUID), true)
+			ORDER BY
+				subscriptions.updated_at, subscriptions.id
+			%s`, subscriptionFields, limitClause(page, 6))
 	)
 
 	if len(cells) == 0 {
 		return nil, dsserr.BadRequest("no location provided")
 	}
 
-	tx, err := c.Begin()
+	cellsAndAncestors, ownCells := cellMatchArgs(cells)
+
+	var subscriptions []*models.Subscription
+	err := c.inTx(ctx, func(q queryable) error {
+		var err error
+		subscriptions, err = c.fetchSubscriptions(ctx, q, query, append([]interface{}{cellsAndAncestors, ownCells, owner}, pageArgs(page)...)...)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	cids := make([]int64, len(cells))
-	for i, cell := range cells {
-		cids[i] = int64(cell)
-	}
+	return subscriptions, nil
+}
 
-	subscriptions, err := c.fetchSubscriptions(ctx, tx, query, pq.Array(cids), owner)
-	if err != nil {
-		return nil, multierr.Combine(err, tx.Rollback())
-	}
+// DeleteExpiredSubscriptions deletes at most "limit" subscriptions that ended
+// before "threshold". Cells are removed by the ON DELETE CASCADE constraint of
+// cells_subscriptions.
+//
+// Concurrent calls are safe, rows that have already been deleted by a
+// different caller simply don't count towards the result.
+func (c *Store) DeleteExpiredSubscriptions(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	const query = `
+		DELETE FROM
+			subscriptions
+		WHERE
+			ends_at < $1
+		ORDER BY
+			ends_at
+		LIMIT
+			$2`
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
+	result, err := c.ExecContext(ctx, query, threshold, limit)
+	if err != nil {
+		return 0, err
 	}
-
-	return subscriptions, nil
+	return result.RowsAffected()
 }
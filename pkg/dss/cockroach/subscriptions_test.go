@@ -101,7 +101,7 @@ func TestDatabaseEnsuresStartTimeBeforeEndTime(t *testing.T) {
 		NotificationIndex: 42,
 		StartTime:         &startTime,
 		EndTime:           &endTime,
-	})
+	}, 0)
 	require.Error(t, err)
 }
 
@@ -116,7 +116,7 @@ func TestStoreGetSubscription(t *testing.T) {
 
 	for _, r := range subscriptionsPool {
 		t.Run(r.name, func(t *testing.T) {
-			sub1, err := store.InsertSubscription(ctx, r.input)
+			sub1, err := store.InsertSubscription(ctx, r.input, 0)
 			require.NoError(t, err)
 			require.NotNil(t, sub1)
 
@@ -140,14 +140,14 @@ func TestStoreInsertSubscription(t *testing.T) {
 
 	for _, r := range subscriptionsPool {
 		t.Run(r.name, func(t *testing.T) {
-			sub1, err := store.InsertSubscription(ctx, r.input)
+			sub1, err := store.InsertSubscription(ctx, r.input, 0)
 			require.NoError(t, err)
 			require.NotNil(t, sub1)
 
 			// Test changes without the version differing.
 			r2 := *sub1
 			r2.Url = "new url"
-			sub2, err := store.InsertSubscription(ctx, &r2)
+			sub2, err := store.InsertSubscription(ctx, &r2, 0)
 			require.NoError(t, err)
 			require.NotNil(t, sub2)
 			require.Equal(t, "new url", sub2.Url)
@@ -156,7 +156,7 @@ func TestStoreInsertSubscription(t *testing.T) {
 			r3 := *sub2
 			r3.Url = "new url 2"
 			r3.Version = nil
-			sub3, err := store.InsertSubscription(ctx, &r3)
+			sub3, err := store.InsertSubscription(ctx, &r3, 0)
 			require.NoError(t, err)
 			require.NotNil(t, sub3)
 			require.Equal(t, "new url 2", sub3.Url)
@@ -165,7 +165,7 @@ func TestStoreInsertSubscription(t *testing.T) {
 			r4 := *sub2
 			r4.Url = "new url 3"
 			r4.Version = models.VersionFromTime(time.Now())
-			sub4, err := store.InsertSubscription(ctx, &r4)
+			sub4, err := store.InsertSubscription(ctx, &r4, 0)
 			require.Error(t, err)
 			require.Nil(t, sub4)
 
@@ -189,7 +189,7 @@ func TestStoreDeleteSubscription(t *testing.T) {
 
 	for _, r := range subscriptionsPool {
 		t.Run(r.name, func(t *testing.T) {
-			sub1, err := store.InsertSubscription(ctx, r.input)
+			sub1, err := store.InsertSubscription(ctx, r.input, 0)
 			require.NoError(t, err)
 			require.NotNil(t, sub1)
 
@@ -247,8 +247,10 @@ func TestStoreSearchSubscription(t *testing.T) {
 	for i, r := range subscriptionsPool {
 		subscription := *r.input
 		subscription.Owner = owners[i]
-		subscription.Cells = cells[:i]
-		sub1, err := store.InsertSubscription(ctx, &subscription)
+		// Searches only return subscriptions intersecting the searched cells,
+		// so every subscription needs at least one cell to be found below.
+		subscription.Cells = cells[:i+1]
+		sub1, err := store.InsertSubscription(ctx, &subscription, 0)
 		require.NoError(t, err)
 		require.NotNil(t, sub1)
 
@@ -256,7 +258,7 @@ func TestStoreSearchSubscription(t *testing.T) {
 	}
 
 	for _, owner := range owners {
-		found, err := store.SearchSubscriptions(ctx, cells, owner)
+		found, err := store.SearchSubscriptions(ctx, cells, owner, nil)
 		require.NoError(t, err)
 		require.NotNil(t, found)
 		// We insert one subscription per owner. Hence, no matter how many cells are touched by the subscription,
@@ -0,0 +1,102 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/steeling/InterUSS-Platform/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of this package.
+const instrumentationName = "github.com/steeling/InterUSS-Platform/pkg/dss/cockroach"
+
+// operation returns the first keyword of "query", e.g. "SELECT".
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// startQuery starts the span of "query" in ctx.
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	op := operation(query)
+	return otel.Tracer(instrumentationName).Start(ctx, "cockroach "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "cockroachdb"),
+			attribute.String("db.operation", op),
+			attribute.String("db.statement", strings.TrimSpace(query)),
+		))
+}
+
+// endQuery records the outcome "err" of a query on "span" and ends it.
+// sql.ErrNoRows is an expected outcome and not recorded as error.
+func endQuery(span trace.Span, err error) {
+	if err != sql.ErrNoRows {
+		tracing.RecordError(span, err)
+	}
+	span.End()
+}
+
+// The following functions run a query on "q" in a span covering the
+// execution of the query. The spans of QueryContext do not cover reading the
+// rows, and the spans of QueryRowContext do not cover scanning the row.
+
+func queryContext(ctx context.Context, q queryable, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	rows, err := q.QueryContext(ctx, query, args...)
+	endQuery(span, err)
+	return rows, err
+}
+
+func queryRowContext(ctx context.Context, q queryable, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	row := q.QueryRowContext(ctx, query, args...)
+	endQuery(span, row.Err())
+	return row
+}
+
+func execContext(ctx context.Context, q queryable, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	result, err := q.ExecContext(ctx, query, args...)
+	endQuery(span, err)
+	return result, err
+}
+
+// QueryContext runs "query" in a traced span.
+func (s *Store) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return queryContext(ctx, s.DB, query, args...)
+}
+
+// QueryRowContext runs "query" in a traced span.
+func (s *Store) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return queryRowContext(ctx, s.DB, query, args...)
+}
+
+// ExecContext runs "query" in a traced span.
+func (s *Store) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return execContext(ctx, s.DB, query, args...)
+}
+
+// tracedTx is a *sql.Tx running its queries in traced spans.
+type tracedTx struct {
+	*sql.Tx
+}
+
+func (t tracedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return queryContext(ctx, t.Tx, query, args...)
+}
+
+func (t tracedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return queryRowContext(ctx, t.Tx, query, args...)
+}
+
+func (t tracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return execContext(ctx, t.Tx, query, args...)
+}
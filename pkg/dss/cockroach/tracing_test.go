@@ -0,0 +1,14 @@
+package cockroach
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperation(t *testing.T) {
+	require.Equal(t, "SELECT", operation(`
+		SELECT id FROM subscriptions`))
+	require.Equal(t, "UPSERT", operation("upsert INTO cells_subscriptions VALUES ($1)"))
+	require.Equal(t, "", operation(" \n"))
+}
@@ -0,0 +1,111 @@
+package cockroach
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/multierr"
+)
+
+const (
+	// savepointName is the special savepoint name that enables CockroachDB's
+	// client-side transaction retry protocol.
+	// https://www.cockroachlabs.com/docs/stable/transactions.html#client-side-intervention
+	savepointName = "cockroach_restart"
+	// serializationFailure is the SQLSTATE code CockroachDB uses to signal that
+	// a transaction must be retried.
+	serializationFailure = "40001"
+)
+
+// transaction abstracts *sql.Tx, enabling tests to inject failures.
+type transaction interface {
+	queryable
+	Commit() error
+	Rollback() error
+}
+
+// txRetryPolicy bounds the retries of a transaction.
+type txRetryPolicy struct {
+	// maxAttempts is the maximum number of times a transaction is attempted.
+	maxAttempts int
+	// initialBackoff is the delay before the first retry. It doubles with
+	// every subsequent retry, up to maxBackoff.
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+var defaultTxRetryPolicy = txRetryPolicy{
+	maxAttempts:    10,
+	initialBackoff: 5 * time.Millisecond,
+	maxBackoff:     500 * time.Millisecond,
+}
+
+// isRetryable returns true if "err" signals a transaction conflict that is
+// resolved by retrying the transaction.
+func isRetryable(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == serializationFailure
+}
+
+// inTx runs "f" in a new, traced transaction and commits it, retrying "f" if
+// CockroachDB reports a retryable error. "f" must not have side effects
+// outside of the transaction as it might be invoked several times.
+func (s *Store) inTx(ctx context.Context, f func(q queryable) error) (err error) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, "cockroach transaction")
+	defer func() { endQuery(span, err) }()
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return runTx(ctx, tracedTx{tx}, defaultTxRetryPolicy, f)
+}
+
+// runTx implements the CockroachDB client-side retry protocol for "tx":
+// "f" is run after establishing the restart savepoint. Retryable errors roll
+// back to the savepoint and run "f" again, backing off according to
+// "policy". All other errors roll back "tx" and are returned unchanged.
+func runTx(ctx context.Context, tx transaction, policy txRetryPolicy, f func(q queryable) error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepointName); err != nil {
+		return multierr.Combine(err, tx.Rollback())
+	}
+
+	backoff := policy.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := f(tx)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepointName)
+			if err == nil {
+				return tx.Commit()
+			}
+		}
+
+		switch {
+		case !isRetryable(err):
+			return multierr.Combine(err, tx.Rollback())
+		case attempt >= policy.maxAttempts:
+			// Surface the conflict to clients instead of an opaque internal
+			// error, they are expected to retry.
+			return multierr.Combine(
+				dsserr.Conflict(fmt.Sprintf("transaction conflict persisted after %d attempts", attempt)),
+				tx.Rollback())
+		}
+
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepointName); err != nil {
+			return multierr.Combine(err, tx.Rollback())
+		}
+
+		select {
+		case <-ctx.Done():
+			return multierr.Combine(ctx.Err(), tx.Rollback())
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > policy.maxBackoff {
+			backoff = policy.maxBackoff
+		}
+	}
+}
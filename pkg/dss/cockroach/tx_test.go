@@ -0,0 +1,161 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errRetryable  = &pq.Error{Code: serializationFailure, Message: "restart transaction"}
+	testTxPolicy  = txRetryPolicy{maxAttempts: 3, initialBackoff: time.Microsecond, maxBackoff: time.Microsecond}
+	errNotRetried = errors.New("not retried")
+)
+
+// fakeTx records the statements executed against it and fails releasing the
+// savepoint with the errors in releaseErrs.
+type fakeTx struct {
+	statements  []string
+	releaseErrs []error
+	committed   bool
+	rolledBack  bool
+}
+
+func (tx *fakeTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (tx *fakeTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (tx *fakeTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	tx.statements = append(tx.statements, query)
+	if query == "RELEASE SAVEPOINT "+savepointName && len(tx.releaseErrs) > 0 {
+		err := tx.releaseErrs[0]
+		tx.releaseErrs = tx.releaseErrs[1:]
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+// failing returns a transaction body that fails with "errs" on its first
+// len(errs) invocations and counts its invocations in "calls".
+func failing(calls *int, errs ...error) func(queryable) error {
+	return func(queryable) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func TestRunTxCommitsOnSuccess(t *testing.T) {
+	var (
+		tx    = &fakeTx{}
+		calls int
+	)
+
+	require.NoError(t, runTx(context.Background(), tx, testTxPolicy, failing(&calls)))
+	require.Equal(t, 1, calls)
+	require.True(t, tx.committed)
+	require.False(t, tx.rolledBack)
+	require.Equal(t, []string{
+		"SAVEPOINT cockroach_restart",
+		"RELEASE SAVEPOINT cockroach_restart",
+	}, tx.statements)
+}
+
+func TestRunTxRetriesRetryableErrors(t *testing.T) {
+	var (
+		tx    = &fakeTx{}
+		calls int
+	)
+
+	require.NoError(t, runTx(context.Background(), tx, testTxPolicy, failing(&calls, errRetryable, errRetryable)))
+	require.Equal(t, 3, calls)
+	require.True(t, tx.committed)
+	require.Equal(t, []string{
+		"SAVEPOINT cockroach_restart",
+		"ROLLBACK TO SAVEPOINT cockroach_restart",
+		"ROLLBACK TO SAVEPOINT cockroach_restart",
+		"RELEASE SAVEPOINT cockroach_restart",
+	}, tx.statements)
+}
+
+func TestRunTxRetriesRetryableReleaseErrors(t *testing.T) {
+	var (
+		tx    = &fakeTx{releaseErrs: []error{errRetryable}}
+		calls int
+	)
+
+	require.NoError(t, runTx(context.Background(), tx, testTxPolicy, failing(&calls)))
+	require.Equal(t, 2, calls)
+	require.True(t, tx.committed)
+	require.Equal(t, []string{
+		"SAVEPOINT cockroach_restart",
+		"RELEASE SAVEPOINT cockroach_restart",
+		"ROLLBACK TO SAVEPOINT cockroach_restart",
+		"RELEASE SAVEPOINT cockroach_restart",
+	}, tx.statements)
+}
+
+func TestRunTxDoesNotRetryOtherErrors(t *testing.T) {
+	var (
+		tx    = &fakeTx{}
+		calls int
+	)
+
+	err := runTx(context.Background(), tx, testTxPolicy, failing(&calls, errNotRetried))
+	require.Equal(t, errNotRetried, err)
+	require.Equal(t, 1, calls)
+	require.False(t, tx.committed)
+	require.True(t, tx.rolledBack)
+}
+
+func TestRunTxGivesUpAfterMaxAttempts(t *testing.T) {
+	var (
+		tx    = &fakeTx{}
+		calls int
+	)
+
+	err := runTx(context.Background(), tx, testTxPolicy, failing(&calls, errRetryable, errRetryable, errRetryable, errRetryable))
+	require.Error(t, err)
+	require.Equal(t, codes.Aborted, status.Code(err))
+	require.Equal(t, testTxPolicy.maxAttempts, calls)
+	require.False(t, tx.committed)
+	require.True(t, tx.rolledBack)
+}
+
+func TestRunTxStopsBackingOffWhenContextIsDone(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		tx          = &fakeTx{}
+		calls       int
+		policy      = txRetryPolicy{maxAttempts: 3, initialBackoff: time.Hour, maxBackoff: time.Hour}
+	)
+	cancel()
+
+	err := runTx(ctx, tx, policy, failing(&calls, errRetryable))
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, 1, calls)
+	require.True(t, tx.rolledBack)
+}
@@ -0,0 +1,100 @@
+package dispatcher
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// nonPublicNetworks lists the networks that callbacks must not resolve to:
+// unspecified, loopback, private, shared, link-local, benchmarking, multicast
+// and reserved addresses. Link-local addresses include cloud metadata
+// services. IPv6 prefixes embedding IPv4 addresses, i.e. NAT64 and the
+// deprecated IPv4-compatible addresses, are refused as a whole since they may
+// be translated to any IPv4 address; ::/96 includes the unspecified and
+// loopback addresses.
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/96",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	result := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		result[i] = network
+	}
+	return result
+}
+
+// isPublic returns true if "ip" is not in any of nonPublicNetworks.
+func isPublic(ip net.IP) bool {
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAddress refuses connections to non-public addresses. It runs after
+// DNS resolution, right before connecting, so that host names resolving, or
+// re-resolving, to internal addresses are refused as well.
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// refuseRedirects makes http.Client return redirect responses instead of
+// following them, keeping deliveries at the callback URL.
+func refuseRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// NewClient returns the http.Client to deliver notifications with. It only
+// connects to public IP addresses, ignores proxy settings of the environment
+// and does not follow redirects, so that callbacks cannot be used to reach
+// internal services of the DSS deployment.
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkAddress,
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: refuseRedirects,
+	}
+}
@@ -0,0 +1,71 @@
+package dispatcher
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAddress(t *testing.T) {
+	for _, r := range []struct {
+		address string
+		public  bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:443", false},
+		{"[::1]:443", false},
+		{"10.1.2.3:443", false},
+		{"172.16.0.1:443", false},
+		{"192.168.1.1:443", false},
+		{"100.64.0.1:443", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:443", false},
+		{"[fd00::1]:443", false},
+		{"[::ffff:127.0.0.1]:443", false},
+		{"[::]:443", false},
+		{"[::7f00:1]:443", false},
+		{"[64:ff9b::7f00:1]:443", false},
+		{"[64:ff9b::5db8:d822]:443", false},
+		{"[64:ff9b:1::a00:1]:443", false},
+		{"0.0.0.0:443", false},
+	} {
+		err := checkAddress("tcp", r.address, nil)
+		if r.public {
+			require.NoError(t, err, r.address)
+		} else {
+			require.Error(t, err, r.address)
+		}
+	}
+}
+
+func TestNewClientRefusesNonPublicAddresses(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the server")
+	}))
+	defer s.Close()
+
+	_, err := NewClient().Get(s.URL)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "non-public address")
+}
+
+func TestRefuseRedirects(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			t.Error("redirect was followed")
+		}
+		http.Redirect(w, r, "https://"+net.JoinHostPort("169.254.169.254", "443")+"/latest", http.StatusFound)
+	}))
+	defer s.Close()
+
+	client := s.Client()
+	client.CheckRedirect = refuseRedirects
+	resp, err := client.Get(s.URL + "/callback")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+}
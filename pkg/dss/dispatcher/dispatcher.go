@@ -0,0 +1,418 @@
+// Package dispatcher implements the optional delivery of ISA change
+// notifications to subscriber callbacks by the DSS itself.
+//
+// Notifications are persisted in an Outbox by the store, atomically with the
+// change they describe, before delivery is attempted. Failed deliveries are
+// retried with exponential backoff and eventually dead-lettered. Delivery is
+// at-least-once: receivers have to use notification indices to detect
+// duplicates.
+//
+// Callbacks are chosen by clients, so deliveries are restricted to https URLs
+// and, when using NewClient, to public IP addresses without following
+// redirects.
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/google/uuid"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/events"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+var (
+	errInvalidInterval    = errors.New("interval must be positive")
+	errInvalidBatchSize   = errors.New("batch size must be positive")
+	errInvalidMaxAttempts = errors.New("max attempts must be positive")
+	errInvalidBackoff     = errors.New("backoff must be positive and initial backoff must not exceed max backoff")
+	errInvalidConcurrency = errors.New("per-host concurrency must be positive")
+	errInvalidTimeout     = errors.New("timeout must be positive")
+	errInvalidRetention   = errors.New("dead letter retention must not be negative")
+	errInsecureCallback   = errors.New("callback URL must use https")
+	marshaler             = jsonpb.Marshaler{OrigName: true}
+)
+
+const (
+	// instrumentationName names the tracer of this package.
+	instrumentationName = "github.com/steeling/InterUSS-Platform/pkg/dss/dispatcher"
+	// purgeInterval is the minimum duration between two purges of
+	// dead-lettered notifications.
+	purgeInterval = time.Hour
+)
+
+// Outbox persists notifications until they have been delivered.
+//
+// LeaseNotifications must hand out every due notification to at most one
+// caller per lease period, making it safe for several dispatchers, e.g. one
+// per backend replica, to share an Outbox.
+//
+// Notifications are added to an Outbox by passing the models.NotificationsFunc
+// returned by Dispatcher.Notifications to the ISA writes of the dss.Store
+// implementing it.
+type Outbox interface {
+	// LeaseNotifications returns at most "limit" notifications that are due
+	// at "now" and have not been dead-lettered. Their next attempt is pushed
+	// back to now + "lease".
+	LeaseNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.Notification, error)
+
+	// DeleteNotification removes the delivered notification identified by "id".
+	DeleteNotification(ctx context.Context, id models.ID) error
+
+	// UpdateNotification persists Attempts, NextAttemptAt, LastError and
+	// DeadLetteredAt of "n".
+	UpdateNotification(ctx context.Context, n *models.Notification) error
+
+	// DeleteDeadLetteredNotifications deletes at most "limit" notifications
+	// that were dead-lettered before "threshold" and returns the number of
+	// deleted notifications.
+	DeleteDeadLetteredNotifications(ctx context.Context, threshold time.Time, limit int) (int64, error)
+}
+
+// Config configures a Dispatcher.
+type Config struct {
+	// Interval is the duration between two polls of the outbox.
+	Interval time.Duration
+	// BatchSize is the maximum number of notifications leased per poll.
+	BatchSize int
+	// MaxAttempts is the number of failed deliveries after which a
+	// notification is dead-lettered.
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failed delivery. It
+	// doubles with every subsequent failure, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// PerHostConcurrency bounds the number of concurrent deliveries to a
+	// single host.
+	PerHostConcurrency int
+	// Timeout bounds a single delivery.
+	Timeout time.Duration
+	// DeadLetterRetention is the duration for which dead-lettered
+	// notifications are kept for inspection before they are deleted. Zero
+	// keeps them forever.
+	DeadLetterRetention time.Duration
+}
+
+// DefaultConfig is a reasonable default configuration.
+var DefaultConfig = Config{
+	Interval:            time.Second,
+	BatchSize:           100,
+	MaxAttempts:         10,
+	InitialBackoff:      time.Second,
+	MaxBackoff:          10 * time.Minute,
+	PerHostConcurrency:  4,
+	Timeout:             10 * time.Second,
+	DeadLetterRetention: 7 * 24 * time.Hour,
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.Interval <= 0:
+		return errInvalidInterval
+	case c.BatchSize <= 0:
+		return errInvalidBatchSize
+	case c.MaxAttempts <= 0:
+		return errInvalidMaxAttempts
+	case c.InitialBackoff <= 0 || c.MaxBackoff < c.InitialBackoff:
+		return errInvalidBackoff
+	case c.PerHostConcurrency <= 0:
+		return errInvalidConcurrency
+	case c.Timeout <= 0:
+		return errInvalidTimeout
+	case c.DeadLetterRetention < 0:
+		return errInvalidRetention
+	}
+	return nil
+}
+
+// Dispatcher delivers notifications from an Outbox to subscriber callbacks.
+type Dispatcher struct {
+	outbox Outbox
+	config Config
+	client *http.Client
+	logger *zap.Logger
+	clock  func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// New returns a new Dispatcher delivering notifications from "outbox" with
+// "client" according to "config".
+func New(outbox Outbox, config Config, client *http.Client, logger *zap.Logger) (*Dispatcher, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &Dispatcher{
+		outbox: outbox,
+		config: config,
+		client: client,
+		logger: logger,
+		clock:  time.Now,
+		hosts:  make(map[string]chan struct{}),
+	}, nil
+}
+
+// Notifications returns the models.NotificationsFunc building a
+// notification of type "t" for every affected subscription that has a
+// callback.
+func (d *Dispatcher) Notifications(t events.Type) models.NotificationsFunc {
+	return func(isa *models.IdentificationServiceArea, subscriptions []*models.Subscription) ([]*models.Notification, error) {
+		var (
+			now           = d.clock()
+			notifications []*models.Notification
+		)
+		for _, s := range subscriptions {
+			if s.Url == "" {
+				continue
+			}
+			p, err := events.Notification{Type: t, ISA: isa, Subscription: s}.ToProto()
+			if err != nil {
+				return nil, err
+			}
+			payload, err := marshaler.MarshalToString(p)
+			if err != nil {
+				return nil, err
+			}
+			notifications = append(notifications, &models.Notification{
+				ID:            models.ID(uuid.New().String()),
+				Url:           s.Url,
+				Payload:       []byte(payload),
+				NextAttemptAt: now,
+			})
+		}
+		return notifications, nil
+	}
+}
+
+// Run delivers due notifications every d.config.Interval until "ctx" is done.
+// Failed runs are logged and retried on the next tick.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("Failed to dispatch notifications", zap.Error(err))
+		}
+		if d.config.DeadLetterRetention > 0 && d.clock().Sub(lastPurge) >= purgeInterval {
+			lastPurge = d.clock()
+			purged, err := d.PurgeOnce(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				d.logger.Error("Failed to purge dead-lettered notifications", zap.Error(err))
+			case purged > 0:
+				d.logger.Info("Purged dead-lettered notifications", zap.Int64("notifications", purged))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce deletes all notifications that were dead-lettered more than
+// d.config.DeadLetterRetention ago, in batches of at most d.config.BatchSize
+// notifications. It returns the number of deleted notifications.
+func (d *Dispatcher) PurgeOnce(ctx context.Context) (int64, error) {
+	var (
+		threshold = d.clock().Add(-d.config.DeadLetterRetention)
+		purged    int64
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		n, err := d.outbox.DeleteDeadLetteredNotifications(ctx, threshold, d.config.BatchSize)
+		if err != nil {
+			return purged, err
+		}
+		purged += n
+		if n < int64(d.config.BatchSize) {
+			return purged, nil
+		}
+	}
+}
+
+// DispatchOnce leases a batch of due notifications and attempts to deliver
+// all of them. It returns the number of notifications that were delivered.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (delivered int, err error) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, "dispatcher.Dispatcher/DispatchOnce")
+	defer func() {
+		span.SetAttributes(attribute.Int("dss.delivered", delivered))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// Leasing for longer than a delivery can take ensures that no other
+	// dispatcher picks up the notifications while we are working on them.
+	lease := d.config.Timeout * time.Duration(1+(d.config.BatchSize-1)/d.config.PerHostConcurrency)
+	notifications, err := d.outbox.LeaseNotifications(ctx, d.clock(), lease, d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	span.SetAttributes(attribute.Int("dss.leased", len(notifications)))
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, n := range notifications {
+		wg.Add(1)
+		go func(n *models.Notification) {
+			defer wg.Done()
+
+			sem := d.semaphore(n.Url)
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			ok, err := d.dispatch(ctx, n)
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				delivered++
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return delivered, err
+	}
+	return delivered, multierr.Combine(errs...)
+}
+
+// semaphore returns the semaphore bounding concurrent deliveries to the host
+// of "rawurl".
+func (d *Dispatcher) semaphore(rawurl string) chan struct{} {
+	host := rawurl
+	if u, err := url.Parse(rawurl); err == nil {
+		host = u.Host
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sem, ok := d.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, d.config.PerHostConcurrency)
+		d.hosts[host] = sem
+	}
+	return sem
+}
+
+// dispatch attempts to deliver "n" and records the outcome in the outbox.
+// Returns true if "n" was delivered.
+func (d *Dispatcher) dispatch(ctx context.Context, n *models.Notification) (bool, error) {
+	deliveryErr := d.deliver(ctx, n)
+	if deliveryErr == nil {
+		return true, d.outbox.DeleteNotification(ctx, n.ID)
+	}
+
+	n.Attempts++
+	n.LastError = deliveryErr.Error()
+	now := d.clock()
+	// Retrying does not fix insecure callbacks.
+	if n.Attempts >= d.config.MaxAttempts || deliveryErr == errInsecureCallback {
+		n.DeadLetteredAt = &now
+		d.logger.Warn("Dead-lettering notification",
+			zap.String("id", n.ID.String()),
+			zap.String("url", n.Url),
+			zap.Int("attempts", n.Attempts),
+			zap.Error(deliveryErr))
+	} else {
+		n.NextAttemptAt = now.Add(d.backoff(n.Attempts))
+	}
+	return false, d.outbox.UpdateNotification(ctx, n)
+}
+
+// backoff returns the delay before the next attempt after "attempts" failed
+// deliveries.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.config.InitialBackoff
+	for i := 1; i < attempts && backoff < d.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.config.MaxBackoff {
+		backoff = d.config.MaxBackoff
+	}
+	return backoff
+}
+
+// deliver POSTs the payload of "n" to its callback.
+func (d *Dispatcher) deliver(ctx context.Context, n *models.Notification) (err error) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, "dispatcher.Dispatcher/deliver",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("dss.notification_id", n.ID.String()),
+			attribute.Int("dss.attempts", n.Attempts),
+		))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	u, err := url.Parse(n.Url)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return errInsecureCallback
+	}
+	span.SetAttributes(attribute.String("http.host", u.Host))
+
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(n.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback responded with %s", resp.Status)
+	}
+	return nil
+}
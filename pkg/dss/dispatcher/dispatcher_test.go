@@ -0,0 +1,263 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/google/uuid"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/events"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/memstore"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	now    = time.Now().UTC()
+	config = Config{
+		Interval:           time.Millisecond,
+		BatchSize:          10,
+		MaxAttempts:        3,
+		InitialBackoff:     time.Second,
+		MaxBackoff:         4 * time.Second,
+		PerHostConcurrency: 2,
+		Timeout:            time.Second,
+	}
+)
+
+// receiver records the requests it receives and responds with status.
+type receiver struct {
+	mu          sync.Mutex
+	status      int
+	bodies      []map[string]interface{}
+	inFlight    int
+	maxInFlight int
+	delay       time.Duration
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.inFlight++
+	if r.inFlight > r.maxInFlight {
+		r.maxInFlight = r.inFlight
+	}
+	r.mu.Unlock()
+
+	time.Sleep(r.delay)
+	body, _ := ioutil.ReadAll(req.Body)
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(body, &decoded)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight--
+	r.bodies = append(r.bodies, decoded)
+	w.WriteHeader(r.status)
+}
+
+func setUp(t *testing.T, r *receiver) (*Dispatcher, *memstore.Store, *httptest.Server) {
+	var (
+		server = httptest.NewTLSServer(r)
+		store  = memstore.New()
+	)
+	d, err := New(store, config, server.Client(), zap.NewNop())
+	require.NoError(t, err)
+	d.clock = func() time.Time { return now }
+	return d, store, server
+}
+
+// notify writes an ISA intersecting "subscriptions" new subscriptions with
+// callback "url", enqueueing the notifications of d for them.
+func notify(ctx context.Context, t *testing.T, d *Dispatcher, store *memstore.Store, url string, subscriptions int) *models.IdentificationServiceArea {
+	cells := s2.CellUnion{s2.CellID(42)}
+	for i := 0; i < subscriptions; i++ {
+		_, err := store.InsertSubscription(ctx, &models.Subscription{
+			ID:    models.ID(uuid.New().String()),
+			Owner: "you",
+			Url:   url,
+			Cells: cells,
+		}, 0)
+		require.NoError(t, err)
+	}
+	isa, _, err := store.InsertISA(ctx, &models.IdentificationServiceArea{
+		ID:    models.ID(uuid.New().String()),
+		Owner: "me-myself-and-i",
+		Url:   "https://no/place/like/home/for/flights",
+		Cells: cells,
+	}, d.Notifications(events.Created))
+	require.NoError(t, err)
+	return isa
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	_, err := New(memstore.New(), Config{}, http.DefaultClient, zap.NewNop())
+	require.Equal(t, errInvalidInterval, err)
+
+	c := config
+	c.MaxBackoff = c.InitialBackoff / 2
+	_, err = New(memstore.New(), c, http.DefaultClient, zap.NewNop())
+	require.Equal(t, errInvalidBackoff, err)
+
+	c = config
+	c.DeadLetterRetention = -time.Hour
+	_, err = New(memstore.New(), c, http.DefaultClient, zap.NewNop())
+	require.Equal(t, errInvalidRetention, err)
+}
+
+func TestNotificationsSkipSubscriptionsWithoutCallback(t *testing.T) {
+	var (
+		d, _, s = setUp(t, &receiver{status: http.StatusOK})
+		isa     = &models.IdentificationServiceArea{ID: models.ID(uuid.New().String())}
+	)
+	defer s.Close()
+
+	notifications, err := d.Notifications(events.Created)(isa, []*models.Subscription{
+		{ID: models.ID(uuid.New().String())},
+		{ID: models.ID(uuid.New().String()), Url: "https://no/place/like/home"},
+	})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, "https://no/place/like/home", notifications[0].Url)
+	require.Equal(t, now, notifications[0].NextAttemptAt)
+}
+
+func TestDispatchOnceDeliversNotifications(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		r           = &receiver{status: http.StatusNoContent}
+		d, store, s = setUp(t, r)
+		isa         = notify(ctx, t, d, store, s.URL+"/uss/identification_service_areas", 3)
+	)
+	defer s.Close()
+
+	delivered, err := d.DispatchOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, delivered)
+
+	require.Len(t, r.bodies, 3)
+	for _, body := range r.bodies {
+		require.Equal(t, "CREATED", body["type"])
+		require.Equal(t, isa.ID.String(), body["service_area"].(map[string]interface{})["id"])
+		require.Equal(t, float64(1), body["subscription"].(map[string]interface{})["notification_index"])
+	}
+
+	// Delivered notifications are removed from the outbox.
+	leased, err := store.LeaseNotifications(ctx, now.Add(time.Hour), time.Minute, 10)
+	require.NoError(t, err)
+	require.Empty(t, leased)
+}
+
+func TestDispatchOnceDeadLettersInsecureCallbacks(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		r           = &receiver{status: http.StatusOK}
+		d, store, s = setUp(t, r)
+	)
+	defer s.Close()
+	notify(ctx, t, d, store, strings.Replace(s.URL, "https://", "http://", 1), 1)
+
+	delivered, err := d.DispatchOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, delivered)
+	require.Empty(t, r.bodies)
+
+	leased, err := store.LeaseNotifications(ctx, now.Add(24*time.Hour), 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, leased)
+}
+
+func TestDispatchOnceRetriesWithBackoffAndDeadLetters(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		r           = &receiver{status: http.StatusInternalServerError}
+		d, store, s = setUp(t, r)
+		clock       = now
+	)
+	defer s.Close()
+	d.clock = func() time.Time { return clock }
+	notify(ctx, t, d, store, s.URL, 1)
+
+	for i, backoff := range []time.Duration{time.Second, 2 * time.Second} {
+		delivered, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, delivered)
+		require.Len(t, r.bodies, i+1)
+
+		// Not due before the backoff elapsed.
+		leased, err := store.LeaseNotifications(ctx, clock.Add(backoff-time.Millisecond), 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, leased)
+
+		clock = clock.Add(backoff)
+		leased, err = store.LeaseNotifications(ctx, clock, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, leased, 1)
+		require.Equal(t, i+1, leased[0].Attempts)
+		require.Contains(t, leased[0].LastError, "500")
+	}
+
+	delivered, err := d.DispatchOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, delivered)
+	require.Len(t, r.bodies, 3)
+
+	// Dead-lettered notifications are never leased again.
+	leased, err := store.LeaseNotifications(ctx, clock.Add(24*time.Hour), 0, 10)
+	require.NoError(t, err)
+	require.Empty(t, leased)
+}
+
+func TestDispatchOnceLimitsConcurrencyPerHost(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		r           = &receiver{status: http.StatusOK, delay: 20 * time.Millisecond}
+		d, store, s = setUp(t, r)
+	)
+	defer s.Close()
+	notify(ctx, t, d, store, s.URL, 8)
+
+	delivered, err := d.DispatchOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 8, delivered)
+	require.True(t, r.maxInFlight <= config.PerHostConcurrency, "%d concurrent deliveries", r.maxInFlight)
+}
+
+func TestPurgeOnceDeletesExpiredDeadLetters(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		r           = &receiver{status: http.StatusInternalServerError}
+		d, store, s = setUp(t, r)
+		clock       = now
+	)
+	defer s.Close()
+	d.clock = func() time.Time { return clock }
+	d.config.MaxAttempts = 1
+	d.config.DeadLetterRetention = time.Hour
+	d.config.BatchSize = 2
+
+	notify(ctx, t, d, store, s.URL, 3)
+	// Leasing batches of 2 takes two runs to dead-letter all notifications.
+	for i := 0; i < 2; i++ {
+		delivered, err := d.DispatchOnce(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, delivered)
+	}
+	require.Len(t, r.bodies, 3)
+
+	purged, err := d.PurgeOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), purged)
+
+	clock = clock.Add(time.Hour + time.Second)
+	purged, err = d.PurgeOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), purged)
+}
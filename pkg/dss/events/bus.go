@@ -0,0 +1,160 @@
+// Package events implements an in-process bus distributing changes to
+// IdentificationServiceAreas to watchers of the affected subscriptions.
+//
+// The bus is not shared across processes: watchers only receive changes
+// published by the process they are connected to.
+package events
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+)
+
+// DefaultBufferSize is the default number of notifications buffered per
+// watcher.
+const DefaultBufferSize = 64
+
+// ErrOverflow is reported by watchers that did not keep up with published
+// events and were disconnected.
+var ErrOverflow = errors.New("watcher did not keep up with events")
+
+// Type enumerates the kinds of changes to an IdentificationServiceArea.
+type Type int
+
+const (
+	// Created marks the creation of an IdentificationServiceArea.
+	Created Type = iota + 1
+	// Updated marks the update of an IdentificationServiceArea.
+	Updated
+	// Deleted marks the deletion of an IdentificationServiceArea.
+	Deleted
+)
+
+// Event describes a committed change to an IdentificationServiceArea and
+// all subscriptions affected by it.
+type Event struct {
+	Type          Type
+	ISA           *models.IdentificationServiceArea
+	Subscriptions []*models.Subscription
+}
+
+// Notification is the part of an Event delivered to the watchers of a single
+// subscription.
+type Notification struct {
+	Type         Type
+	ISA          *models.IdentificationServiceArea
+	Subscription *models.Subscription
+}
+
+// Bus distributes events to watchers. Publishing never blocks: watchers whose
+// buffer is full are disconnected with ErrOverflow.
+type Bus struct {
+	mu         sync.Mutex
+	bufferSize int
+	watchers   map[models.ID]map[*Watcher]bool
+}
+
+// NewBus returns a new Bus buffering up to "bufferSize" notifications per
+// watcher.
+func NewBus(bufferSize int) *Bus {
+	return &Bus{
+		bufferSize: bufferSize,
+		watchers:   make(map[models.ID]map[*Watcher]bool),
+	}
+}
+
+// Watch returns a new Watcher receiving notifications for the subscription
+// identified by "id". Callers must Close the returned Watcher.
+func (b *Bus) Watch(id models.ID) *Watcher {
+	w := &Watcher{
+		bus: b,
+		id:  id,
+		c:   make(chan Notification, b.bufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.watchers[id] == nil {
+		b.watchers[id] = make(map[*Watcher]bool)
+	}
+	b.watchers[id][w] = true
+	return w
+}
+
+// Publish delivers "e" to the watchers of all subscriptions in
+// e.Subscriptions.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range e.Subscriptions {
+		for w := range b.watchers[s.ID] {
+			select {
+			case w.c <- Notification{Type: e.Type, ISA: e.ISA, Subscription: s}:
+			default:
+				w.err = ErrOverflow
+				b.remove(w)
+			}
+		}
+	}
+}
+
+// CloseSubscription disconnects all watchers of the subscription identified
+// by "id", e.g. after it has been deleted.
+func (b *Bus) CloseSubscription(id models.ID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for w := range b.watchers[id] {
+		b.remove(w)
+	}
+}
+
+// remove unregisters "w" and closes its channel. b.mu must be held.
+func (b *Bus) remove(w *Watcher) {
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.c)
+
+	delete(b.watchers[w.id], w)
+	if len(b.watchers[w.id]) == 0 {
+		delete(b.watchers, w.id)
+	}
+}
+
+// Watcher receives notifications for a single subscription.
+type Watcher struct {
+	bus *Bus
+	id  models.ID
+	c   chan Notification
+
+	// Guarded by bus.mu.
+	closed bool
+	err    error
+}
+
+// Notifications returns the channel notifications are delivered on. The
+// channel is closed when the watcher is disconnected, Err reports why.
+func (w *Watcher) Notifications() <-chan Notification {
+	return w.c
+}
+
+// Err returns ErrOverflow if w was disconnected for falling behind, nil
+// otherwise.
+func (w *Watcher) Err() error {
+	w.bus.mu.Lock()
+	defer w.bus.mu.Unlock()
+	return w.err
+}
+
+// Close unregisters w from its bus.
+func (w *Watcher) Close() {
+	w.bus.mu.Lock()
+	defer w.bus.mu.Unlock()
+	w.bus.remove(w)
+}
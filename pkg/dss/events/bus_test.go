@@ -0,0 +1,70 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	isa      = &models.IdentificationServiceArea{ID: "isa", Owner: "me-myself-and-i"}
+	watched  = &models.Subscription{ID: "watched", NotificationIndex: 1}
+	observed = &models.Subscription{ID: "observed", NotificationIndex: 2}
+)
+
+func TestPublishDeliversToWatchersOfAffectedSubscriptions(t *testing.T) {
+	var (
+		bus   = NewBus(DefaultBufferSize)
+		w1    = bus.Watch(watched.ID)
+		w2    = bus.Watch(watched.ID)
+		other = bus.Watch("other")
+	)
+	defer w1.Close()
+	defer w2.Close()
+	defer other.Close()
+
+	bus.Publish(Event{Type: Created, ISA: isa, Subscriptions: []*models.Subscription{watched, observed}})
+
+	for _, w := range []*Watcher{w1, w2} {
+		require.Equal(t, Notification{Type: Created, ISA: isa, Subscription: watched}, <-w.Notifications())
+	}
+	require.Len(t, other.Notifications(), 0)
+}
+
+func TestPublishDisconnectsSlowWatchers(t *testing.T) {
+	var (
+		bus = NewBus(1)
+		w   = bus.Watch(watched.ID)
+		e   = Event{Type: Updated, ISA: isa, Subscriptions: []*models.Subscription{watched}}
+	)
+	defer w.Close()
+
+	bus.Publish(e)
+	bus.Publish(e)
+
+	_, ok := <-w.Notifications()
+	require.True(t, ok)
+	_, ok = <-w.Notifications()
+	require.False(t, ok)
+	require.Equal(t, ErrOverflow, w.Err())
+
+	// Further events must not panic on the closed channel.
+	bus.Publish(e)
+}
+
+func TestCloseSubscriptionDisconnectsWatchers(t *testing.T) {
+	var (
+		bus = NewBus(DefaultBufferSize)
+		w   = bus.Watch(watched.ID)
+	)
+
+	bus.CloseSubscription(watched.ID)
+	_, ok := <-w.Notifications()
+	require.False(t, ok)
+	require.NoError(t, w.Err())
+
+	// Closing an already disconnected watcher is a no-op.
+	w.Close()
+	require.Empty(t, bus.watchers)
+}
@@ -0,0 +1,32 @@
+package events
+
+import (
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+)
+
+// ToProto converts n to its proto representation.
+func (n Notification) ToProto() (*dspb.SubscriptionEvent, error) {
+	isa, err := n.ISA.ToProto()
+	if err != nil {
+		return nil, err
+	}
+
+	var t dspb.SubscriptionEvent_Type
+	switch n.Type {
+	case Created:
+		t = dspb.SubscriptionEvent_CREATED
+	case Updated:
+		t = dspb.SubscriptionEvent_UPDATED
+	case Deleted:
+		t = dspb.SubscriptionEvent_DELETED
+	}
+
+	return &dspb.SubscriptionEvent{
+		Type:        t,
+		ServiceArea: isa,
+		Subscription: &dspb.SubscriptionState{
+			Subscription:      n.Subscription.ID.String(),
+			NotificationIndex: int32(n.Subscription.NotificationIndex),
+		},
+	}, nil
+}
@@ -0,0 +1,119 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requireCoversRect asserts that "cells" cover the latitude/longitude
+// rectangle between "latLo" and "latHi" that extends east from "lngLo" to
+// "lngHi", and that no cell lies further than "margin" degrees outside of it.
+func requireCoversRect(t *testing.T, cells s2.CellUnion, latLo, lngLo, latHi, lngHi, margin float64) {
+	span := math.Mod(lngHi-lngLo+360, 360)
+	for i := 0; i <= 4; i++ {
+		for j := 0; j <= 4; j++ {
+			ll := s2.LatLngFromDegrees(latLo+(latHi-latLo)*float64(i)/4, lngLo+span*float64(j)/4)
+			require.True(t, cells.ContainsPoint(s2.PointFromLatLng(ll)), "%v not covered", ll)
+		}
+	}
+
+	bound := s2.RectFromLatLng(s2.LatLngFromDegrees(latLo-margin, lngLo-margin)).
+		AddPoint(s2.LatLngFromDegrees(latHi+margin, lngLo+span/2)).
+		AddPoint(s2.LatLngFromDegrees(latHi+margin, lngHi+margin))
+	for _, cell := range cells {
+		require.True(t, bound.IntersectsCell(s2.CellFromCellID(cell)), "%v is outside of %v", cell, bound)
+	}
+}
+
+func TestAntimeridianAroundFiji(t *testing.T) {
+	var expected s2.CellUnion
+	for _, area := range []string{
+		// Longitudes changing sign.
+		"-16.6,179.8,-16.6,-179.8,-17.0,-179.8,-17.0,179.8",
+		// Longitudes past 180.
+		"-16.6,179.8,-16.6,180.2,-17.0,180.2,-17.0,179.8",
+		// Longitudes past -180, in clockwise order.
+		"-16.6,-180.2,-17.0,-180.2,-17.0,-179.8,-16.6,-179.8",
+	} {
+		cells, err := DefaultCoverer().AreaToCellIDs(area, DefaultMaxAreaKm2)
+		require.NoError(t, err, area)
+		requireCoversRect(t, cells, -17.0, 179.8, -16.6, -179.8, 0.05)
+		if expected == nil {
+			expected = cells
+		}
+		require.Equal(t, expected, cells, area)
+	}
+
+	// The same polygon, spanning the globe the other way around, is rejected.
+	_, err := DefaultCoverer().AreaToCellIDs("-16.6,179.8,-16.6,0,-16.6,-179.8,-17.0,-179.8,-17.0,0,-17.0,179.8", DefaultMaxAreaKm2)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAntimeridianInBeringStrait(t *testing.T) {
+	geojson := `{"type":"Polygon","coordinates":[[[179.7,65.5],[180.3,65.5],[180.3,65.9],[179.7,65.9],[179.7,65.5]]]}`
+	cells, err := DefaultCoverer().GeoJSONToCellIDs(geojson, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	requireCoversRect(t, cells, 65.5, 179.7, 65.9, -179.7, 0.05)
+
+	cells, err = DefaultCoverer().AreaToCellIDs("65.5,179.7,65.5,-179.7,65.9,-179.7,65.9,179.7", DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	requireCoversRect(t, cells, 65.5, 179.7, 65.9, -179.7, 0.05)
+}
+
+func TestPolarRegions(t *testing.T) {
+	var (
+		northPole = s2.PointFromLatLng(s2.LatLngFromDegrees(90, 0))
+		southPole = s2.PointFromLatLng(s2.LatLngFromDegrees(-90, 0))
+	)
+
+	// A square around the north pole contains the pole, and nothing south of
+	// its vertices.
+	cells, err := DefaultCoverer().AreaToCellIDs("89.8,0,89.8,90,89.8,180,89.8,-90", DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.True(t, cells.ContainsPoint(northPole))
+	for _, cell := range cells {
+		require.True(t, s2.CellFromCellID(cell).RectBound().Lat.Hi >= s2.LatLngFromDegrees(89.7, 0).Lat.Radians())
+	}
+
+	// A closing vertex written with longitude 180 instead of -180 is dropped.
+	closed, err := DefaultCoverer().AreaToCellIDs("89.8,-180,89.8,-90,89.8,0,89.8,90,89.8,180", DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.Equal(t, cells, closed)
+
+	// A closing vertex at a pole repeating the first one is dropped.
+	cells, err = DefaultCoverer().AreaToCellIDs("-90,0,-89.8,10,-89.8,20,-90,0", DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.True(t, cells.ContainsPoint(southPole))
+	triangle, err := DefaultCoverer().AreaToCellIDs("-90,0,-89.8,10,-89.8,20", DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.Equal(t, triangle, cells)
+
+	// Edges away from the poles are geodesics even if they pass over a pole:
+	// this triangle lies on the side of the pole facing its third vertex.
+	cells, err = DefaultCoverer().AreaToCellIDs("89.8,0,89.8,180,89.5,90", DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.True(t, cells.ContainsPoint(s2.PointFromLatLng(s2.LatLngFromDegrees(89.7, 90))))
+	require.False(t, cells.ContainsPoint(s2.PointFromLatLng(s2.LatLngFromDegrees(89.7, -90))))
+
+	// Polar caps drawn as latitude/longitude rectangles are rejected, whether
+	// or not they span all longitudes.
+	for _, r := range []struct {
+		area string
+		edge int
+	}{
+		{"89.8,-180,89.8,-60,89.8,60,89.8,180,90,180,90,-180", 4},
+		{"80,-170,80,170,90,170,90,-170", 2},
+		{"80,-170,80,170,90,170,90,190", 2},
+		{"-90,0,-89.8,10,-89.8,20,-90,45", 3},
+	} {
+		_, err = DefaultCoverer().AreaToCellIDs(r.area, DefaultMaxAreaKm2)
+		require.Equal(t, codes.InvalidArgument, status.Code(err), r.area)
+		require.Equal(t, fmt.Sprintf("edge %d wraps around a pole, polar regions must be described by vertices around the pole", r.edge), status.Convert(err).Message(), r.area)
+	}
+}
@@ -0,0 +1,25 @@
+package geo
+
+import (
+	"github.com/golang/geo/earth"
+	"github.com/google/go-units/unit"
+)
+
+const (
+	// DefaultMaxAreaKm2 is the default maximum area of a polygon in square
+	// kilometers, i.e. 1000 square miles.
+	DefaultMaxAreaKm2 = 2589.988110336
+)
+
+// SteradiansToKm2 converts an area on the unit sphere, e.g. as returned by
+// s2.Loop.Area, into the corresponding area on the earth's surface in square
+// kilometers.
+func SteradiansToKm2(steradians float64) float64 {
+	return earth.AreaFromSteradians(steradians).SquareKilometers()
+}
+
+// Km2ToSteradians converts an area on the earth's surface in square
+// kilometers into the corresponding area on the unit sphere.
+func Km2ToSteradians(km2 float64) float64 {
+	return earth.SteradiansFromArea(unit.Area(km2) * unit.SquareKilometer)
+}
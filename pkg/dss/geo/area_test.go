@@ -0,0 +1,63 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rightTriangle returns the spherical triangle with a right angle at (0, 0)
+// and both legs, running along the equator and the prime meridian, spanning
+// "degrees". It also returns the area of the triangle in square kilometers,
+// which follows from its spherical excess E with tan(E/2) = tan²(a/2).
+func rightTriangle(degrees float64) (*dspb.GeoPolygon, float64) {
+	var (
+		a      = degrees * math.Pi / 180
+		excess = 2 * math.Atan(math.Pow(math.Tan(a/2), 2))
+	)
+	return &dspb.GeoPolygon{Vertices: []*dspb.LatLngPoint{
+		{Lat: 0, Lng: 0},
+		{Lat: 0, Lng: degrees},
+		{Lat: degrees, Lng: 0},
+	}}, SteradiansToKm2(excess)
+}
+
+func TestSteradiansToKm2(t *testing.T) {
+	// The whole earth.
+	require.InDelta(t, 510066073.1, SteradiansToKm2(4*math.Pi), 1)
+	require.InDelta(t, 4*math.Pi, Km2ToSteradians(SteradiansToKm2(4*math.Pi)), 1e-12)
+}
+
+func TestAreaLimitIsApplied(t *testing.T) {
+	for _, degrees := range []float64{0.01, 0.5, 0.6, 0.65, 1} {
+		polygon, area := rightTriangle(degrees)
+
+		_, err := DefaultCoverer().GeoPolygonToCellIDs(polygon, area*(1+1e-6))
+		require.NoError(t, err, "%v degrees", degrees)
+
+		_, err = DefaultCoverer().GeoPolygonToCellIDs(polygon, area*(1-1e-6))
+		require.Equal(t, codes.InvalidArgument, status.Code(err), "%v degrees", degrees)
+	}
+}
+
+func TestDefaultMaxAreaIs1000SquareMiles(t *testing.T) {
+	// Triangles with legs of 0.64 and 0.65 degrees enclose 978 and 1009
+	// square miles, respectively.
+	small, area := rightTriangle(0.64)
+	require.InDelta(t, 978, area/2.589988110336, 1)
+	_, err := DefaultCoverer().GeoPolygonToCellIDs(small, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+
+	large, area := rightTriangle(0.65)
+	require.InDelta(t, 1009, area/2.589988110336, 1)
+	_, err = DefaultCoverer().GeoPolygonToCellIDs(large, DefaultMaxAreaKm2)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, "area of 2612.03 km^2 exceeds the maximum of 2589.99 km^2", status.Convert(err).Message())
+
+	_, err = DefaultCoverer().AreaToCellIDs("0,0,0,0.65,0.65,0", DefaultMaxAreaKm2)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
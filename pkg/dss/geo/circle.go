@@ -0,0 +1,42 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/earth"
+	"github.com/golang/geo/s2"
+	"github.com/google/go-units/unit"
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+// CircleToCellIDs validates "circle" and returns the s2.CellUnion covering
+// it. The radius is measured in meters along the earth's surface. Circles
+// enclosing more than "maxAreaKm2" square kilometers are rejected.
+func (c *Coverer) CircleToCellIDs(circle *dspb.Circle, maxAreaKm2 float64) (s2.CellUnion, error) {
+	region, err := capFromCircle(circle)
+	if err != nil {
+		return nil, err
+	}
+	return c.Covering(region, maxAreaKm2)
+}
+
+// capFromCircle returns the s2.Cap corresponding to "circle".
+func capFromCircle(circle *dspb.Circle) (s2.Cap, error) {
+	center := circle.GetCenter()
+	if center == nil {
+		return s2.Cap{}, dsserr.BadRequest("missing center of circle")
+	}
+	point, err := pointFromLatLng(center.GetLat(), center.GetLng(), "center")
+	if err != nil {
+		return s2.Cap{}, err
+	}
+	radius := float64(circle.GetRadius())
+	if !(radius > 0) || math.IsInf(radius, 1) {
+		return s2.Cap{}, dsserr.BadRequest(fmt.Sprintf("radius %v is not a positive number of meters", radius))
+	}
+
+	angle := earth.AngleFromLength(unit.Length(radius) * unit.Meter)
+	return s2.CapFromCenterAngle(point, angle), nil
+}
@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/geo/s2"
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var stanford = &dspb.LatLngPoint{Lat: 37.427636, Lng: -122.170502}
+
+func TestCircleToCellIDs(t *testing.T) {
+	cells, err := DefaultCoverer().CircleToCellIDs(&dspb.Circle{Center: stanford, Radius: 1000}, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	center := s2.CellIDFromLatLng(s2.LatLngFromDegrees(stanford.Lat, stanford.Lng))
+	require.True(t, cells.ContainsCellID(center))
+
+	region, err := capFromCircle(&dspb.Circle{Center: stanford, Radius: 1000})
+	require.NoError(t, err)
+	require.InDelta(t, math.Pi, SteradiansToKm2(region.Area()), 1e-3)
+}
+
+func TestCircleAreaLimit(t *testing.T) {
+	// A circle with a radius of 28.7km encloses ~2588km^2.
+	_, err := DefaultCoverer().CircleToCellIDs(&dspb.Circle{Center: stanford, Radius: 28700}, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+
+	_, err = DefaultCoverer().CircleToCellIDs(&dspb.Circle{Center: stanford, Radius: 28800}, DefaultMaxAreaKm2)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCircleValidation(t *testing.T) {
+	for _, r := range []struct {
+		name    string
+		circle  *dspb.Circle
+		message string
+	}{
+		{"missing center", &dspb.Circle{Radius: 100}, "missing center of circle"},
+		{"center out of range", &dspb.Circle{Center: &dspb.LatLngPoint{Lat: 91}, Radius: 100}, "latitude 91 of center is out of range [-90, 90]"},
+		{"zero radius", &dspb.Circle{Center: stanford}, "radius 0 is not a positive number of meters"},
+		{"negative radius", &dspb.Circle{Center: stanford, Radius: -1}, "radius -1 is not a positive number of meters"},
+		{"radius not a number", &dspb.Circle{Center: stanford, Radius: float32(math.NaN())}, "radius NaN is not a positive number of meters"},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			_, err := DefaultCoverer().CircleToCellIDs(r.circle, DefaultMaxAreaKm2)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			require.Equal(t, r.message, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestVolume3DFootprints(t *testing.T) {
+	var (
+		circle  = &dspb.Circle{Center: stanford, Radius: 1000}
+		geojson = `{"type":"Polygon","coordinates":[[[-122.17,37.42],[-122.16,37.42],[-122.16,37.43],[-122.17,37.42]]]}`
+	)
+
+	fromCircle, err := DefaultCoverer().Volume3DToCellIDs(&dspb.Volume3D{FootprintCircle: circle}, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	expected, err := DefaultCoverer().CircleToCellIDs(circle, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.Equal(t, expected, fromCircle)
+
+	fromGeoJSON, err := DefaultCoverer().Volume3DToCellIDs(&dspb.Volume3D{FootprintGeojson: geojson}, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.NotEmpty(t, fromGeoJSON)
+
+	_, err = DefaultCoverer().Volume3DToCellIDs(&dspb.Volume3D{FootprintCircle: circle, FootprintGeojson: geojson}, DefaultMaxAreaKm2)
+	require.Equal(t, errMultipleFootprints, err)
+
+	require.False(t, HasFootprint(&dspb.Volume3D{}))
+	require.True(t, HasFootprint(&dspb.Volume3D{FootprintGeojson: geojson}))
+}
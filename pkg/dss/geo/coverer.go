@@ -0,0 +1,156 @@
+package geo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/golang/geo/s2"
+)
+
+const (
+	// DefaultMaxCells is the default desired maximum number of cells in a
+	// covering.
+	DefaultMaxCells = 8
+	// DefaultLevelMod is the default level modulus of coverings, allowing
+	// cells of every level between the minimum and maximum level.
+	DefaultLevelMod = 1
+	// maxCellLevel is the level of the smallest S2 cells.
+	maxCellLevel = 30
+)
+
+// DefaultCoveringConfig is the CoveringConfig used if a deployment does not
+// configure coverings explicitly.
+var DefaultCoveringConfig = CoveringConfig{
+	MinLevel:    DefaultMinimumCellLevel,
+	MaxLevel:    DefaultMaximumCellLevel,
+	MaxCells:    DefaultMaxCells,
+	LevelMod:    DefaultLevelMod,
+	MaxVertices: DefaultMaxVertices,
+}
+
+// CoveringConfig configures how areas are mapped to the S2 cells indexing
+// them. See s2.RegionCoverer for details.
+type CoveringConfig struct {
+	// MinLevel is the level of the largest cells used in a covering.
+	MinLevel int
+	// MaxLevel is the level of the smallest cells used in a covering.
+	MaxLevel int
+	// MaxCells is the desired maximum number of cells in a covering. It may
+	// be exceeded if MinLevel is too high for the area being covered.
+	MaxCells int
+	// LevelMod restricts coverings to cells whose level exceeds MinLevel by a
+	// multiple of LevelMod. Must be 1, 2 or 3.
+	LevelMod int
+	// MaxVertices bounds the number of vertices of the polygons being
+	// covered. Zero uses DefaultMaxVertices.
+	MaxVertices int
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c CoveringConfig) Validate() error {
+	switch {
+	case c.MinLevel < 0 || c.MinLevel > maxCellLevel:
+		return fmt.Errorf("minimum cell level %d is outside of [0, %d]", c.MinLevel, maxCellLevel)
+	case c.MaxLevel < c.MinLevel || c.MaxLevel > maxCellLevel:
+		return fmt.Errorf("maximum cell level %d is outside of [%d, %d]", c.MaxLevel, c.MinLevel, maxCellLevel)
+	case c.MaxCells < 1:
+		return fmt.Errorf("maximum number of cells %d is not positive", c.MaxCells)
+	case c.LevelMod < 1 || c.LevelMod > 3:
+		return fmt.Errorf("level modulus %d is outside of [1, 3]", c.LevelMod)
+	case c.MaxVertices != 0 && c.MaxVertices < 3:
+		return fmt.Errorf("maximum number of vertices %d is below 3", c.MaxVertices)
+	}
+	return nil
+}
+
+// Coverer maps areas to the S2 cells indexing them. A Coverer is safe for
+// concurrent use.
+type Coverer struct {
+	rc          *s2.RegionCoverer
+	maxVertices int
+}
+
+// NewCoverer returns a new Coverer configured by "config".
+func NewCoverer(config CoveringConfig) (*Coverer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	maxVertices := config.MaxVertices
+	if maxVertices == 0 {
+		maxVertices = DefaultMaxVertices
+	}
+	return &Coverer{
+		rc: &s2.RegionCoverer{
+			MinLevel: config.MinLevel,
+			MaxLevel: config.MaxLevel,
+			MaxCells: config.MaxCells,
+			LevelMod: config.LevelMod,
+		},
+		maxVertices: maxVertices,
+	}, nil
+}
+
+var (
+	defaultCoverer     *Coverer
+	defaultCovererOnce sync.Once
+)
+
+// DefaultCoverer returns the Coverer configured by DefaultCoveringConfig. It
+// is built on first use and shared by all callers.
+func DefaultCoverer() *Coverer {
+	defaultCovererOnce.Do(func() {
+		c, err := NewCoverer(DefaultCoveringConfig)
+		if err != nil {
+			panic(err)
+		}
+		defaultCoverer = c
+	})
+	return defaultCoverer
+}
+
+// CoverCells returns the cells covering the area covered by "cells". It is
+// meant for re-indexing entities after the configuration changed, the result
+// always covers at least the area covered by "cells".
+func (c *Coverer) CoverCells(cells s2.CellUnion) s2.CellUnion {
+	cu := append(s2.CellUnion(nil), cells...)
+	cu.Normalize()
+	return c.rc.Covering(&cu)
+}
+
+// Ancestors returns the ancestors of "cells" at all levels, sorted and
+// excluding "cells" themselves.
+//
+// Stores index every entity by its cells as well as by the ancestors of its
+// cells, and look up entities intersecting a set of cells Q by matching
+// entity cells against Q and Ancestors(Q), and entity ancestors against Q.
+// This finds all intersecting entities independent of the levels of the cells
+// on either side, which keeps searches correct while coverings of different
+// configurations coexist.
+func Ancestors(cells s2.CellUnion) s2.CellUnion {
+	var (
+		own    = make(map[s2.CellID]bool, len(cells))
+		seen   = make(map[s2.CellID]bool)
+		result = s2.CellUnion{}
+	)
+	for _, cell := range cells {
+		own[cell] = true
+	}
+	for _, cell := range cells {
+		for level := cell.Level() - 1; level >= 0; level-- {
+			parent := cell.Parent(level)
+			if seen[parent] {
+				// All further ancestors have been visited, too.
+				break
+			}
+			seen[parent] = true
+			if !own[parent] {
+				result = append(result, parent)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
@@ -0,0 +1,56 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCovererValidatesConfig(t *testing.T) {
+	for _, config := range []CoveringConfig{
+		{MinLevel: -1, MaxLevel: 13, MaxCells: 8, LevelMod: 1},
+		{MinLevel: 13, MaxLevel: 12, MaxCells: 8, LevelMod: 1},
+		{MinLevel: 13, MaxLevel: 31, MaxCells: 8, LevelMod: 1},
+		{MinLevel: 13, MaxLevel: 13, MaxCells: 0, LevelMod: 1},
+		{MinLevel: 13, MaxLevel: 13, MaxCells: 8, LevelMod: 4},
+		{MinLevel: 13, MaxLevel: 13, MaxCells: 8, LevelMod: 1, MaxVertices: 2},
+	} {
+		_, err := NewCoverer(config)
+		require.Error(t, err, "%+v", config)
+	}
+}
+
+func TestDefaultCovererIsShared(t *testing.T) {
+	require.NotNil(t, DefaultCoverer())
+	require.True(t, DefaultCoverer() == DefaultCoverer())
+}
+
+func TestCoverCellsChangesLevels(t *testing.T) {
+	leaf := s2.CellIDFromLatLng(s2.LatLngFromDegrees(37.427636, -122.170502))
+
+	coarse, err := NewCoverer(CoveringConfig{MinLevel: 10, MaxLevel: 10, MaxCells: 8, LevelMod: 1})
+	require.NoError(t, err)
+	require.Equal(t, s2.CellUnion{leaf.Parent(10)}, coarse.CoverCells(s2.CellUnion{leaf.Parent(13)}))
+
+	fine, err := NewCoverer(CoveringConfig{MinLevel: 11, MaxLevel: 11, MaxCells: 8, LevelMod: 1})
+	require.NoError(t, err)
+	cells := fine.CoverCells(s2.CellUnion{leaf.Parent(10)})
+	require.Len(t, cells, 4)
+	for _, cell := range cells {
+		require.Equal(t, leaf.Parent(10), cell.Parent(10))
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	var (
+		leaf   = s2.CellIDFromLatLng(s2.LatLngFromDegrees(37.427636, -122.170502))
+		first  = leaf.Parent(3)
+		second = leaf.Parent(2).ChildBegin().Next().Next()
+	)
+	require.NotEqual(t, first, second)
+
+	ancestors := Ancestors(s2.CellUnion{first, second, leaf.Parent(1)})
+	require.Equal(t, s2.CellUnion{leaf.Parent(2), leaf.Parent(0)}, ancestors)
+	require.Empty(t, Ancestors(s2.CellUnion{leaf.Parent(0)}))
+}
@@ -0,0 +1,163 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/geo/s2"
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errMalformedGeoJSON = dsserr.BadRequest("malformed GeoJSON")
+)
+
+// geoJSONObject holds the members of GeoJSON objects relevant for footprints,
+// see RFC 7946.
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSONObject  `json:"geometry"`
+}
+
+// GeoJSONToCellIDs parses "geojson" and returns the s2.CellUnion covering the
+// area it describes. "geojson" must be a Polygon or MultiPolygon geometry, or a
+// Feature with such a geometry. Every ring is validated as documented for
+// GeoPolygonToCellIDs, holes must lie within their polygon and the polygons
+// of a MultiPolygon must not overlap. Areas larger than "maxAreaKm2" square
+// kilometers are rejected.
+func (c *Coverer) GeoJSONToCellIDs(geojson string, maxAreaKm2 float64) (s2.CellUnion, error) {
+	polygon, err := polygonFromGeoJSON(geojson, c.maxVertices)
+	if err != nil {
+		return nil, err
+	}
+	return c.Covering(polygon, maxAreaKm2)
+}
+
+// polygonFromGeoJSON returns the s2.Polygon described by "geojson", which must
+// not have more than "maxVertices" vertices in total.
+func polygonFromGeoJSON(geojson string, maxVertices int) (*s2.Polygon, error) {
+	var object geoJSONObject
+	if err := json.Unmarshal([]byte(geojson), &object); err != nil {
+		return nil, errMalformedGeoJSON
+	}
+	if object.Type == "Feature" {
+		if object.Geometry == nil {
+			return nil, dsserr.BadRequest("GeoJSON Feature has no geometry")
+		}
+		object = *object.Geometry
+	}
+
+	var polygons [][][][]float64
+	switch object.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return nil, errMalformedGeoJSON
+		}
+		polygons = [][][][]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, errMalformedGeoJSON
+		}
+	default:
+		return nil, dsserr.BadRequest(fmt.Sprintf("unsupported GeoJSON type %q, must be Polygon, MultiPolygon or Feature", object.Type))
+	}
+
+	var (
+		numVertices = 0
+		shells      = make([]*s2.Loop, len(polygons))
+		holes       = make([][]*s2.Loop, len(polygons))
+		loops       []*s2.Loop
+	)
+	for i, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, dsserr.BadRequest(fmt.Sprintf("%s has no rings", polygonName(len(polygons), i)))
+		}
+		for j, ring := range polygon {
+			numVertices += len(ring)
+			if numVertices > maxVertices {
+				return nil, dsserr.BadRequest(fmt.Sprintf("GeoJSON has more than %d vertices", maxVertices))
+			}
+			loop, err := loopFromPositions(ring, maxVertices)
+			if err != nil {
+				return nil, dsserr.BadRequest(fmt.Sprintf("%s, ring %d: %s", polygonName(len(polygons), i), j, status.Convert(err).Message()))
+			}
+			if j == 0 {
+				shells[i] = loop
+			} else {
+				holes[i] = append(holes[i], loop)
+			}
+			loops = append(loops, loop)
+		}
+	}
+
+	if err := checkNesting(shells, holes); err != nil {
+		return nil, err
+	}
+
+	result := s2.PolygonFromLoops(loops)
+	if err := result.Validate(); err != nil {
+		return nil, dsserr.BadRequest(err.Error())
+	}
+	return result, nil
+}
+
+// polygonName names polygon "i" of "n" polygons in error messages.
+func polygonName(n, i int) string {
+	if n == 1 {
+		return "polygon"
+	}
+	return fmt.Sprintf("polygon %d", i)
+}
+
+// loopFromPositions returns the normalized s2.Loop described by the GeoJSON
+// positions of a linear ring, which must not have more than "maxVertices"
+// vertices.
+func loopFromPositions(ring [][]float64, maxVertices int) (*s2.Loop, error) {
+	vertices := make([]*dspb.LatLngPoint, len(ring))
+	for i, position := range ring {
+		if len(position) < 2 {
+			return nil, dsserr.BadRequest(fmt.Sprintf("position %d has less than 2 coordinates", i))
+		}
+		vertices[i] = &dspb.LatLngPoint{Lat: position[1], Lng: position[0]}
+	}
+	return loopFromVertices(vertices, maxVertices)
+}
+
+// checkNesting returns an error if a hole does not lie within its shell, if
+// two holes of the same polygon intersect, or if two polygons overlap. A
+// polygon may lie within a hole of another polygon.
+func checkNesting(shells []*s2.Loop, holes [][]*s2.Loop) error {
+	for i, shell := range shells {
+		for j, hole := range holes[i] {
+			if !shell.Contains(hole) {
+				return dsserr.BadRequest(fmt.Sprintf("%s, ring %d: hole is not within the exterior ring", polygonName(len(shells), i), j+1))
+			}
+			for k := j + 1; k < len(holes[i]); k++ {
+				if hole.Intersects(holes[i][k]) {
+					return dsserr.BadRequest(fmt.Sprintf("%s, ring %d: hole intersects ring %d", polygonName(len(shells), i), j+1, k+1))
+				}
+			}
+		}
+	}
+
+	withinHole := func(loop *s2.Loop, holes []*s2.Loop) bool {
+		for _, hole := range holes {
+			if hole.Contains(loop) {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range shells {
+		for j := i + 1; j < len(shells); j++ {
+			if shells[i].Intersects(shells[j]) && !withinHole(shells[j], holes[i]) && !withinHole(shells[i], holes[j]) {
+				return dsserr.BadRequest(fmt.Sprintf("polygon %d overlaps polygon %d", i, j))
+			}
+		}
+	}
+	return nil
+}
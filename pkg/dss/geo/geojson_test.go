@@ -0,0 +1,104 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/golang/geo/s2"
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	square     = `[[-122.2,37.4],[-122.0,37.4],[-122.0,37.6],[-122.2,37.6],[-122.2,37.4]]`
+	hole       = `[[-122.15,37.45],[-122.05,37.45],[-122.05,37.55],[-122.15,37.55],[-122.15,37.45]]`
+	farSquare  = `[[-121.2,37.4],[-121.0,37.4],[-121.0,37.6],[-121.2,37.6],[-121.2,37.4]]`
+	insideHole = `[[-122.12,37.48],[-122.08,37.48],[-122.08,37.52],[-122.12,37.52],[-122.12,37.48]]`
+)
+
+func TestGeoJSONPolygonMatchesGeoPolygon(t *testing.T) {
+	expected, err := DefaultCoverer().GeoPolygonToCellIDs(&dspb.GeoPolygon{Vertices: []*dspb.LatLngPoint{
+		{Lat: 37.4, Lng: -122.2},
+		{Lat: 37.4, Lng: -122.0},
+		{Lat: 37.6, Lng: -122.0},
+		{Lat: 37.6, Lng: -122.2},
+	}}, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+
+	for _, geojson := range []string{
+		`{"type":"Polygon","coordinates":[` + square + `]}`,
+		`{"type":"Feature","properties":{"name":"launch site"},"geometry":{"type":"Polygon","coordinates":[` + square + `]}}`,
+		`{"type":"MultiPolygon","coordinates":[[` + square + `]]}`,
+	} {
+		cells, err := DefaultCoverer().GeoJSONToCellIDs(geojson, DefaultMaxAreaKm2)
+		require.NoError(t, err, geojson)
+		require.Equal(t, expected, cells, geojson)
+
+		cells, err = DefaultCoverer().AreaToCellIDs(geojson, DefaultMaxAreaKm2)
+		require.NoError(t, err, geojson)
+		require.Equal(t, expected, cells, geojson)
+	}
+}
+
+func TestGeoJSONHolesAndMultiPolygons(t *testing.T) {
+	var (
+		center    = s2.CellIDFromLatLng(s2.LatLngFromDegrees(37.5, -122.1)).Parent(DefaultMinimumCellLevel)
+		farCenter = s2.CellIDFromLatLng(s2.LatLngFromDegrees(37.5, -121.1)).Parent(DefaultMinimumCellLevel)
+	)
+
+	cells, err := DefaultCoverer().GeoJSONToCellIDs(`{"type":"Polygon","coordinates":[`+square+`,`+hole+`]}`, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.NotEmpty(t, cells)
+	require.False(t, cells.ContainsCellID(center))
+
+	cells, err = DefaultCoverer().GeoJSONToCellIDs(`{"type":"MultiPolygon","coordinates":[[`+square+`,`+hole+`],[`+insideHole+`],[`+farSquare+`]]}`, DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.True(t, cells.ContainsCellID(center))
+	require.True(t, cells.ContainsCellID(farCenter))
+
+	polygon, err := polygonFromGeoJSON(`{"type":"Polygon","coordinates":[`+square+`,`+hole+`]}`, DefaultMaxVertices)
+	require.NoError(t, err)
+	shell, err := polygonFromGeoJSON(`{"type":"Polygon","coordinates":[`+square+`]}`, DefaultMaxVertices)
+	require.NoError(t, err)
+	inner, err := polygonFromGeoJSON(`{"type":"Polygon","coordinates":[`+hole+`]}`, DefaultMaxVertices)
+	require.NoError(t, err)
+	require.InDelta(t, shell.Area()-inner.Area(), polygon.Area(), 1e-15)
+}
+
+func TestGeoJSONValidation(t *testing.T) {
+	for _, r := range []struct {
+		name    string
+		geojson string
+		message string
+	}{
+		{"malformed", `{"type":`, "malformed GeoJSON"},
+		{"unsupported type", `{"type":"Point","coordinates":[0,0]}`, `unsupported GeoJSON type "Point", must be Polygon, MultiPolygon or Feature`},
+		{"feature without geometry", `{"type":"Feature","properties":{}}`, "GeoJSON Feature has no geometry"},
+		{"malformed coordinates", `{"type":"Polygon","coordinates":[[0,0]]}`, "malformed GeoJSON"},
+		{"polygon without rings", `{"type":"Polygon","coordinates":[]}`, "polygon has no rings"},
+		{"short position", `{"type":"Polygon","coordinates":[[[0,0],[0.01],[0.01,0.01]]]}`, "polygon, ring 0: position 1 has less than 2 coordinates"},
+		{"bowtie", `{"type":"MultiPolygon","coordinates":[[` + farSquare + `],[[[0,0],[0.01,0],[0,0.01],[0.01,0.01]]]]}`, "polygon 1, ring 0: edge 1 intersects edge 3"},
+		{"hole outside shell", `{"type":"Polygon","coordinates":[` + hole + `,` + farSquare + `]}`, "polygon, ring 1: hole is not within the exterior ring"},
+		{"intersecting holes", `{"type":"Polygon","coordinates":[` + square + `,` + hole + `,` + insideHole + `]}`, "polygon, ring 1: hole intersects ring 2"},
+		{"overlapping polygons", `{"type":"MultiPolygon","coordinates":[[` + square + `],[` + hole + `]]}`, "polygon 0 overlaps polygon 1"},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			_, err := DefaultCoverer().GeoJSONToCellIDs(r.geojson, DefaultMaxAreaKm2)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			require.Equal(t, r.message, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestGeoJSONAreaLimit(t *testing.T) {
+	geojson := `{"type":"MultiPolygon","coordinates":[[` + square + `],[` + farSquare + `]]}`
+	polygon, err := polygonFromGeoJSON(geojson, DefaultMaxVertices)
+	require.NoError(t, err)
+	area := SteradiansToKm2(polygon.Area())
+
+	_, err = DefaultCoverer().GeoJSONToCellIDs(geojson, area*(1+1e-6))
+	require.NoError(t, err)
+	_, err = DefaultCoverer().GeoJSONToCellIDs(geojson, area*(1-1e-6))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
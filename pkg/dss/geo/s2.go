@@ -3,7 +3,7 @@ package geo
 import (
 	"bufio"
 	"bytes"
-	"math"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,24 +19,13 @@ const (
 	// DefaultMaximumCellLevel is the default minimum cell level, chosen such
 	// that the maximum cell size is ~1km^2.
 	DefaultMaximumCellLevel int = 13
-	maxAllowedSqMi              = 1000
 )
 
 var (
-	// defaultRegionCoverer is the default s2.RegionCoverer for mapping areas
-	// and extents to s2.CellUnion instances.
-	defaultRegionCoverer = &s2.RegionCoverer{
-		MinLevel: DefaultMinimumCellLevel,
-		MaxLevel: DefaultMaximumCellLevel,
-	}
-	// RegionCoverer provides an overridable interface to defaultRegionCoverer
-	RegionCoverer = defaultRegionCoverer
-
 	errOddNumberOfCoordinatesInAreaString = dsserr.BadRequest("odd number of coordinates in area string")
 	errNotEnoughPointsInPolygon           = dsserr.BadRequest("not enough points in polygon")
 	errBadCoordSet                        = dsserr.BadRequest("coordinates did not create a well formed area")
-	errAreaTooLarge                       = dsserr.BadRequest("area is too large")
-	maxArea                               = maxLoopArea()
+	errMultipleFootprints                 = dsserr.BadRequest("only one of footprint, footprint_circle and footprint_geojson may be set")
 )
 
 func splitAtComma(data []byte, atEOF bool) (int, []byte, error) {
@@ -55,62 +44,100 @@ func splitAtComma(data []byte, atEOF bool) (int, []byte, error) {
 	return 0, nil, nil
 }
 
-func Volume4DToCellIDs(v4 *dspb.Volume4D) (s2.CellUnion, error) {
+func (c *Coverer) Volume4DToCellIDs(v4 *dspb.Volume4D, maxAreaKm2 float64) (s2.CellUnion, error) {
 	if v4 == nil {
 		return nil, errBadCoordSet
 	}
-	return Volume3DToCellIDs(v4.SpatialVolume)
+	return c.Volume3DToCellIDs(v4.SpatialVolume, maxAreaKm2)
 }
 
-func Volume3DToCellIDs(v3 *dspb.Volume3D) (s2.CellUnion, error) {
+// Volume3DToCellIDs returns the s2.CellUnion covering the footprint of "v3",
+// which may be given as a GeoPolygon, a Circle or a GeoJSON string.
+func (c *Coverer) Volume3DToCellIDs(v3 *dspb.Volume3D, maxAreaKm2 float64) (s2.CellUnion, error) {
 	if v3 == nil {
 		return nil, errBadCoordSet
 	}
-	return GeoPolygonToCellIDs(v3.Footprint)
+	switch n := numFootprints(v3); {
+	case n > 1:
+		return nil, errMultipleFootprints
+	case v3.FootprintCircle != nil:
+		return c.CircleToCellIDs(v3.FootprintCircle, maxAreaKm2)
+	case v3.FootprintGeojson != "":
+		return c.GeoJSONToCellIDs(v3.FootprintGeojson, maxAreaKm2)
+	}
+	return c.GeoPolygonToCellIDs(v3.Footprint, maxAreaKm2)
+}
+
+// HasFootprint returns true if any footprint is set in "v3".
+func HasFootprint(v3 *dspb.Volume3D) bool {
+	return numFootprints(v3) > 0
+}
+
+func numFootprints(v3 *dspb.Volume3D) int {
+	n := 0
+	if v3.GetFootprint() != nil {
+		n++
+	}
+	if v3.GetFootprintCircle() != nil {
+		n++
+	}
+	if v3.GetFootprintGeojson() != "" {
+		n++
+	}
+	return n
 }
 
-func GeoPolygonToCellIDs(geopolygon *dspb.GeoPolygon) (s2.CellUnion, error) {
-	var points []s2.Point
+// GeoPolygonToCellIDs validates "geopolygon" and returns the s2.CellUnion
+// covering it. Vertices may be given in either winding order. Polygons with
+// more vertices than allowed by the CoveringConfig of c, out-of-range
+// coordinates, duplicate or antipodal vertices, or self-intersecting edges
+// are rejected with a BadRequest error describing the problem, as are
+// polygons enclosing more than "maxAreaKm2" square kilometers.
+func (c *Coverer) GeoPolygonToCellIDs(geopolygon *dspb.GeoPolygon, maxAreaKm2 float64) (s2.CellUnion, error) {
 	if geopolygon == nil {
 		return nil, errBadCoordSet
 	}
-	for _, ltlng := range geopolygon.Vertices {
-		points = append(points, s2.PointFromLatLng(s2.LatLngFromDegrees(ltlng.Lat, ltlng.Lng)))
+	loop, err := loopFromVertices(geopolygon.Vertices, c.maxVertices)
+	if err != nil {
+		return nil, err
 	}
-	loop := s2.LoopFromPoints(points)
 
-	return Covering(loop)
+	return c.Covering(loop, maxAreaKm2)
 }
 
-func maxLoopArea() float64 {
-	var (
-		sqMiEarth     = 197000000. // rought square miles of earth.
-		scalingFactor = sqMiEarth / 4. * math.Pi
-	)
-	return maxAllowedSqMi / scalingFactor
+// Region is an s2.Region with a known area, e.g. an *s2.Loop, an *s2.Polygon
+// or an s2.Cap.
+type Region interface {
+	s2.Region
+	// Area returns the area of the region on the unit sphere.
+	Area() float64
 }
 
-func Covering(loop *s2.Loop) (s2.CellUnion, error) {
-	// TODO(steeling): consider setting max number of vertices.
-	loopArea := loop.Area()
-	if loopArea <= 0 {
+// Covering returns the cells covering "region", failing if "region" is empty
+// or encloses more than "maxAreaKm2" square kilometers of the earth's surface.
+func (c *Coverer) Covering(region Region, maxAreaKm2 float64) (s2.CellUnion, error) {
+	regionArea := region.Area()
+	if regionArea <= 0 {
 		return nil, errBadCoordSet
 	}
-	if loopArea > maxLoopArea() {
-		return nil, errAreaTooLarge
+	if area := SteradiansToKm2(regionArea); area > maxAreaKm2 {
+		return nil, dsserr.BadRequest(fmt.Sprintf("area of %.2f km^2 exceeds the maximum of %.2f km^2", area, maxAreaKm2))
 	}
-	return RegionCoverer.Covering(loop), nil
+	return c.rc.Covering(region), nil
 }
 
 // AreaToCellIDs parses "area" in the format 'lat0,lon0,lat1,lon1,...'
-// and returns the resulting s2.CellUnion.
-//
-// TODO(tvoss):
-//   * Agree and implement a maximum number of points in area
-func AreaToCellIDs(area string) (s2.CellUnion, error) {
+// and returns the resulting s2.CellUnion. The polygon described by "area" is
+// validated as documented for GeoPolygonToCellIDs. "area" may also be a
+// GeoJSON object as accepted by GeoJSONToCellIDs.
+func (c *Coverer) AreaToCellIDs(area string, maxAreaKm2 float64) (s2.CellUnion, error) {
+	if strings.HasPrefix(strings.TrimSpace(area), "{") {
+		return c.GeoJSONToCellIDs(area, maxAreaKm2)
+	}
+
 	var (
 		lat, lng = float64(0), float64(0)
-		points   = []s2.Point{}
+		vertices = []*dspb.LatLngPoint{}
 		counter  = 0
 		scanner  = bufio.NewScanner(strings.NewReader(area))
 	)
@@ -121,6 +148,9 @@ func AreaToCellIDs(area string) (s2.CellUnion, error) {
 	if numCoords/2 < 3 {
 		return nil, errNotEnoughPointsInPolygon
 	}
+	if numCoords/2 > c.maxVertices {
+		return nil, dsserr.BadRequest(fmt.Sprintf("polygon has %d vertices, at most %d are allowed", numCoords/2, c.maxVertices))
+	}
 	scanner.Split(splitAtComma)
 
 	for scanner.Scan() {
@@ -138,10 +168,14 @@ func AreaToCellIDs(area string) (s2.CellUnion, error) {
 				return nil, errBadCoordSet
 			}
 			lng = f
-			points = append(points, s2.PointFromLatLng(s2.LatLngFromDegrees(lat, lng)))
+			vertices = append(vertices, &dspb.LatLngPoint{Lat: lat, Lng: lng})
 		}
 
 		counter++
 	}
-	return Covering(s2.LoopFromPoints(points))
+	loop, err := loopFromVertices(vertices, c.maxVertices)
+	if err != nil {
+		return nil, err
+	}
+	return c.Covering(loop, maxAreaKm2)
 }
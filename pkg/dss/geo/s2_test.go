@@ -11,7 +11,7 @@ import (
 )
 
 func TestGeoPolygonToCellIDs(t *testing.T) {
-	got, err := GeoPolygonToCellIDs(&dspb.GeoPolygon{Vertices: []*dspb.LatLngPoint{
+	got, err := DefaultCoverer().GeoPolygonToCellIDs(&dspb.GeoPolygon{Vertices: []*dspb.LatLngPoint{
 		// Stanford
 		{
 			Lat: 37.427636,
@@ -27,7 +27,7 @@ func TestGeoPolygonToCellIDs(t *testing.T) {
 			Lat: 37.421265,
 			Lng: -122.086504,
 		},
-	}})
+	}}, DefaultMaxAreaKm2)
 
 	want := s2.CellUnion{
 		s2.CellIDFromToken("808fb0ac"),
@@ -56,37 +56,37 @@ func TestGeoPolygonToCellIDs(t *testing.T) {
 }
 
 func TestParseAreaSuccessForOddNumberOfPoints(t *testing.T) {
-	cells, err := AreaToCellIDs(`37.4047,-122.1474,37.4037,-122.1485,37.4035,-122.1466`)
+	cells, err := DefaultCoverer().AreaToCellIDs(`37.4047,-122.1474,37.4037,-122.1485,37.4035,-122.1466`, DefaultMaxAreaKm2)
 	require.NoError(t, err)
 	require.NotNil(t, cells)
 }
 
 func TestParseAreaSuccessForEvenNumberOfPoints(t *testing.T) {
-	cells, err := AreaToCellIDs(`37.4047,-122.1474,37.4037,-122.1485,37.4035,-122.1466,37.4035,-122.1466`)
+	cells, err := DefaultCoverer().AreaToCellIDs(`37.4047,-122.1474,37.4037,-122.1485,37.4035,-122.1466,37.4035,-122.1466`, DefaultMaxAreaKm2)
 	require.NoError(t, err)
 	require.NotNil(t, cells)
 }
 
 func TestParseAreaSucceedsForValidLoop(t *testing.T) {
-	cells, err := AreaToCellIDs(testdata.Loop)
+	cells, err := DefaultCoverer().AreaToCellIDs(testdata.Loop, DefaultMaxAreaKm2)
 	require.NoError(t, err)
 	require.NotNil(t, cells)
 }
 
 func TestParseAreaFailsForEmptyString(t *testing.T) {
-	cells, err := AreaToCellIDs("")
+	cells, err := DefaultCoverer().AreaToCellIDs("", DefaultMaxAreaKm2)
 	require.Error(t, err)
 	require.Nil(t, cells)
 }
 
 func TestParseAreaFailsForLoopWithOnlyTwoPoints(t *testing.T) {
-	cells, err := AreaToCellIDs(testdata.LoopWithOnlyTwoPoints)
+	cells, err := DefaultCoverer().AreaToCellIDs(testdata.LoopWithOnlyTwoPoints, DefaultMaxAreaKm2)
 	require.Error(t, err)
 	require.Nil(t, cells)
 }
 
 func TestParseAreaFailsForLoopWithOddNumberOfCoordinates(t *testing.T) {
-	cells, err := AreaToCellIDs(testdata.LoopWithOddNumberOfCoordinates)
+	cells, err := DefaultCoverer().AreaToCellIDs(testdata.LoopWithOddNumberOfCoordinates, DefaultMaxAreaKm2)
 	require.Error(t, err)
 	require.Nil(t, cells)
 }
@@ -0,0 +1,179 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/s2"
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+const (
+	// DefaultMaxVertices is the default maximum number of vertices of a
+	// polygon.
+	DefaultMaxVertices = 1000
+	// maxLongitude bounds the absolute value of longitudes. Longitudes beyond
+	// 180 degrees are normalized, see pointFromLatLng.
+	maxLongitude = 360
+	// antipodalTolerance is the maximum distance between a vertex and the
+	// antipode of its successor for which the edge connecting both is
+	// considered to be ambiguous.
+	antipodalTolerance = 1e-12
+)
+
+// loopFromVertices validates the polygon described by "vertices", which must
+// not have more than "maxVertices" vertices, and returns the corresponding
+// normalized s2.Loop.
+//
+// Consecutive duplicate vertices, including a closing vertex that repeats the
+// first one, are dropped. Vertices may be given in either winding order, the
+// resulting loop always encloses the smaller of the two regions bounded by
+// the vertices. All other problems are reported as BadRequest errors naming
+// the offending vertices or edges, which are indexed as given in "vertices".
+// Edge i connects vertex i to its successor.
+func loopFromVertices(vertices []*dspb.LatLngPoint, maxVertices int) (*s2.Loop, error) {
+	if len(vertices) < 3 {
+		return nil, errNotEnoughPointsInPolygon
+	}
+	if len(vertices) > maxVertices {
+		return nil, dsserr.BadRequest(fmt.Sprintf("polygon has %d vertices, at most %d are allowed", len(vertices), maxVertices))
+	}
+
+	if err := checkPoleEdges(vertices); err != nil {
+		return nil, err
+	}
+
+	var (
+		points  = make([]s2.Point, 0, len(vertices))
+		indices = make([]int, 0, len(vertices))
+	)
+	for i, v := range vertices {
+		p, err := pointFromLatLng(v.GetLat(), v.GetLng(), fmt.Sprintf("vertex %d", i))
+		if err != nil {
+			return nil, err
+		}
+		if len(points) > 0 && points[len(points)-1] == p {
+			continue
+		}
+		points = append(points, p)
+		indices = append(indices, i)
+	}
+	for len(points) > 1 && points[0] == points[len(points)-1] {
+		points, indices = points[:len(points)-1], indices[:len(indices)-1]
+	}
+	if len(points) < 3 {
+		return nil, errNotEnoughPointsInPolygon
+	}
+
+	if err := checkVertices(points, indices); err != nil {
+		return nil, err
+	}
+	if err := checkEdges(points, indices); err != nil {
+		return nil, err
+	}
+
+	loop := s2.LoopFromPoints(points)
+	loop.Normalize()
+	if err := loop.Validate(); err != nil {
+		return nil, dsserr.BadRequest(err.Error())
+	}
+	return loop, nil
+}
+
+// pointFromLatLng validates "lat" and "lng" and returns the corresponding
+// s2.Point. "name" names the point in error messages.
+//
+// Longitudes are normalized into [-180, 180), and all points at a pole are
+// mapped to longitude 0, so that coordinates describing the same location
+// always yield the same point. Polygons crossing the antimeridian may thus be
+// written with continuous longitudes, e.g. 179.5 and 180.5, or with
+// longitudes changing sign. As edges are geodesics, they cross the
+// antimeridian whenever that is the shorter path.
+func pointFromLatLng(lat, lng float64, name string) (s2.Point, error) {
+	if !(lat >= -90 && lat <= 90) {
+		return s2.Point{}, dsserr.BadRequest(fmt.Sprintf("latitude %v of %s is out of range [-90, 90]", lat, name))
+	}
+	if !(lng >= -maxLongitude && lng <= maxLongitude) {
+		return s2.Point{}, dsserr.BadRequest(fmt.Sprintf("longitude %v of %s is out of range [-%d, %d]", lng, name, maxLongitude, maxLongitude))
+	}
+
+	switch {
+	case atPole(lat):
+		lng = 0
+	default:
+		lng = math.Remainder(lng, 360)
+		if lng == 180 {
+			lng = -180
+		}
+	}
+	return s2.PointFromLatLng(s2.LatLngFromDegrees(lat, lng)), nil
+}
+
+// atPole returns true if "lat" is the latitude of a pole.
+func atPole(lat float64) bool {
+	return lat == 90 || lat == -90
+}
+
+// checkPoleEdges returns an error if any edge of the polygon described by
+// "vertices" runs along a pole, i.e. connects two vertices at the same pole,
+// with a latitude of exactly 90 or -90 degrees, whose longitudes differ. This
+// includes longitudes that are equal after normalization, such as -180 and
+// 180, as the edge then runs all the way around the pole. Such edges come from
+// drawing polar caps as latitude/longitude rectangles, whose edges follow
+// parallels rather than geodesics, so the resulting region would not be the
+// intended one. Polar regions have to be described by vertices around the
+// pole instead.
+//
+// This is the only check specific to poles. All other edges are geodesics,
+// including edges passing over or next to a pole, e.g. from 89.8,0 to
+// 89.8,180, and polygons may contain a pole.
+func checkPoleEdges(vertices []*dspb.LatLngPoint) error {
+	for i, v := range vertices {
+		next := vertices[(i+1)%len(vertices)]
+		if atPole(v.GetLat()) && v.GetLat() == next.GetLat() && v.GetLng() != next.GetLng() {
+			return dsserr.BadRequest(fmt.Sprintf("edge %d wraps around a pole, polar regions must be described by vertices around the pole", i))
+		}
+	}
+	return nil
+}
+
+// checkVertices returns an error if "points" contains duplicate vertices or
+// consecutive vertices that are antipodal. "indices" maps every point to its
+// index in the original polygon.
+func checkVertices(points []s2.Point, indices []int) error {
+	seen := make(map[s2.Point]int, len(points))
+	for i, p := range points {
+		if j, ok := seen[p]; ok {
+			return dsserr.BadRequest(fmt.Sprintf("vertex %d duplicates vertex %d", indices[i], indices[j]))
+		}
+		seen[p] = i
+
+		next := points[(i+1)%len(points)]
+		if p.Add(next.Vector).Norm() < antipodalTolerance {
+			return dsserr.BadRequest(fmt.Sprintf("vertices %d and %d are antipodal", indices[i], indices[(i+1)%len(points)]))
+		}
+	}
+	return nil
+}
+
+// checkEdges returns an error if any two non-adjacent edges of the loop
+// defined by "points" intersect. "indices" maps every point to its index in
+// the original polygon.
+func checkEdges(points []s2.Point, indices []int) error {
+	n := len(points)
+	for i := 0; i < n; i++ {
+		crosser := s2.NewEdgeCrosser(points[i], points[(i+1)%n])
+		// Edges i-1, i and i+1 share vertices, which is fine. Edge n-1 is
+		// adjacent to edge 0, too.
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				continue
+			}
+			if crosser.CrossingSign(points[j], points[(j+1)%n]) != s2.DoNotCross {
+				return dsserr.BadRequest(fmt.Sprintf("edge %d intersects edge %d", indices[i], indices[j]))
+			}
+		}
+	}
+	return nil
+}
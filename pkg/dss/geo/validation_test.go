@@ -0,0 +1,143 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// polygon returns a dspb.GeoPolygon with vertices at the given pairs of
+// latitude and longitude offsets to a point close to Stanford, in degrees.
+func polygon(offsets ...float64) *dspb.GeoPolygon {
+	const (
+		lat = 37.427636
+		lng = -122.170502
+	)
+	p := &dspb.GeoPolygon{}
+	for i := 0; i+1 < len(offsets); i += 2 {
+		p.Vertices = append(p.Vertices, &dspb.LatLngPoint{Lat: lat + offsets[i], Lng: lng + offsets[i+1]})
+	}
+	return p
+}
+
+func TestGeoPolygonValidation(t *testing.T) {
+	for _, r := range []struct {
+		name    string
+		polygon *dspb.GeoPolygon
+		message string
+	}{
+		{
+			name:    "counter-clockwise triangle",
+			polygon: polygon(0, 0, 0, 0.01, 0.01, 0),
+		},
+		{
+			name:    "clockwise triangle",
+			polygon: polygon(0, 0, 0.01, 0, 0, 0.01),
+		},
+		{
+			name:    "closed polygon",
+			polygon: polygon(0, 0, 0, 0.01, 0.01, 0.01, 0.01, 0, 0, 0),
+		},
+		{
+			name:    "consecutive duplicate vertices",
+			polygon: polygon(0, 0, 0, 0.01, 0, 0.01, 0.01, 0),
+		},
+		{
+			name:    "not enough distinct vertices",
+			polygon: polygon(0, 0, 0, 0.01, 0, 0.01, 0, 0),
+			message: "not enough points in polygon",
+		},
+		{
+			name:    "non-consecutive duplicate vertices",
+			polygon: polygon(0, 0, 0, 0.01, 0.01, 0.01, 0, 0.01, 0.01, 0),
+			message: "vertex 3 duplicates vertex 1",
+		},
+		{
+			name:    "bowtie",
+			polygon: polygon(0, 0, 0, 0.01, 0.01, 0, 0.01, 0.01),
+			message: "edge 1 intersects edge 3",
+		},
+		{
+			name:    "edge crossing after duplicate vertices",
+			polygon: polygon(0, 0, 0, 0, 0, 0.01, 0.01, 0, 0.01, 0.01),
+			message: "edge 2 intersects edge 4",
+		},
+		{
+			name: "antipodal vertices",
+			polygon: &dspb.GeoPolygon{Vertices: []*dspb.LatLngPoint{
+				{Lat: 0, Lng: 0},
+				{Lat: 0, Lng: 180},
+				{Lat: 45, Lng: 90},
+			}},
+			message: "vertices 0 and 1 are antipodal",
+		},
+		{
+			name:    "latitude out of range",
+			polygon: polygon(0, 0, 0, 0.01, 60, 0),
+			message: "latitude 97.427636 of vertex 2 is out of range [-90, 90]",
+		},
+		{
+			name:    "longitude out of range",
+			polygon: polygon(0, 0, 0, 0.01, 0.01, -240),
+			message: "longitude -362.170502 of vertex 2 is out of range [-360, 360]",
+		},
+		{
+			name: "latitude not a number",
+			polygon: &dspb.GeoPolygon{Vertices: []*dspb.LatLngPoint{
+				{Lat: 0, Lng: 0},
+				{Lat: math.NaN(), Lng: 0.01},
+				{Lat: 0.01, Lng: 0},
+			}},
+			message: "latitude NaN of vertex 1 is out of range [-90, 90]",
+		},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			cells, err := DefaultCoverer().GeoPolygonToCellIDs(r.polygon, DefaultMaxAreaKm2)
+			if r.message == "" {
+				require.NoError(t, err)
+				require.NotEmpty(t, cells)
+				return
+			}
+			require.Error(t, err)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			require.Equal(t, r.message, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestGeoPolygonWindingOrderIsNormalized(t *testing.T) {
+	ccw, err := DefaultCoverer().GeoPolygonToCellIDs(polygon(0, 0, 0, 0.01, 0.01, 0.01, 0.01, 0), DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	cw, err := DefaultCoverer().GeoPolygonToCellIDs(polygon(0.01, 0, 0.01, 0.01, 0, 0.01, 0, 0), DefaultMaxAreaKm2)
+	require.NoError(t, err)
+	require.Equal(t, ccw, cw)
+}
+
+func TestMaxVertices(t *testing.T) {
+	config := DefaultCoveringConfig
+	config.MaxVertices = 3
+	coverer, err := NewCoverer(config)
+	require.NoError(t, err)
+
+	_, err = coverer.GeoPolygonToCellIDs(polygon(0, 0, 0, 0.01, 0.01, 0), DefaultMaxAreaKm2)
+	require.NoError(t, err)
+
+	_, err = coverer.GeoPolygonToCellIDs(polygon(0, 0, 0, 0.01, 0.01, 0.01, 0.01, 0), DefaultMaxAreaKm2)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, "polygon has 4 vertices, at most 3 are allowed", status.Convert(err).Message())
+
+	_, err = coverer.AreaToCellIDs("37.42,-122.17,37.42,-122.16,37.43,-122.16,37.43,-122.17", DefaultMaxAreaKm2)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, "polygon has 4 vertices, at most 3 are allowed", status.Convert(err).Message())
+}
+
+func TestAreaReportsReasons(t *testing.T) {
+	_, err := DefaultCoverer().AreaToCellIDs("0,0,0,0.01,0.01,0,0.01,0.01", DefaultMaxAreaKm2)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, "edge 1 intersects edge 3", status.Convert(err).Message())
+}
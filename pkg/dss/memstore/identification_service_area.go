@@ -0,0 +1,192 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+func copyISA(isa *models.IdentificationServiceArea) *models.IdentificationServiceArea {
+	c := *isa
+	c.Cells = copyCells(isa.Cells)
+	c.StartTime = copyTime(isa.StartTime)
+	c.EndTime = copyTime(isa.EndTime)
+	c.AltitudeHi = copyFloat32(isa.AltitudeHi)
+	c.AltitudeLo = copyFloat32(isa.AltitudeLo)
+	return &c
+}
+
+// fetchISAByIDAndOwner returns the ISA identified by "id" and owned by
+// "owner". Must be called with s.mu held.
+func (s *Store) fetchISAByIDAndOwner(id models.ID, owner models.Owner) (*models.IdentificationServiceArea, error) {
+	isa, ok := s.isas[id]
+	if !ok || isa.Owner != owner {
+		return nil, sql.ErrNoRows
+	}
+	return isa, nil
+}
+
+// GetISA returns the isa identified by "id".
+func (s *Store) GetISA(ctx context.Context, id models.ID) (*models.IdentificationServiceArea, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	isa, ok := s.isas[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyISA(isa), nil
+}
+
+// InsertISA inserts the IdentificationServiceArea identified by "id" and owned
+// by "owner", affecting "cells" in the time interval ["starts", "ends"].
+//
+// Returns the created IdentificationServiceArea and all Subscriptions affected
+// by it. The notifications returned by "notify", if not nil, are enqueued
+// with the write.
+func (s *Store) InsertISA(ctx context.Context, isa *models.IdentificationServiceArea, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.fetchISAByIDAndOwner(isa.ID, isa.Owner)
+	switch {
+	case err == sql.ErrNoRows:
+		break
+	case !isa.Version.Empty() && !isa.Version.Matches(old.Version):
+		return nil, nil, dsserr.VersionMismatch("old version")
+	}
+	if err := validateExtents(isa.StartTime, isa.EndTime, isa.AltitudeLo, isa.AltitudeHi); err != nil {
+		return nil, nil, err
+	}
+
+	stored := copyISA(isa)
+	stored.Version = models.VersionFromTime(s.now())
+
+	// Subscriptions the ISA is moved away from are affected, too.
+	subscriptions := s.affectedSubscriptions(stored, old)
+	notifications, err := buildNotifications(notify, copyISA(stored), subscriptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if previous, ok := s.isas[stored.ID]; ok {
+		s.isaCells.remove(previous.ID, previous.Cells)
+	}
+	s.isas[stored.ID] = stored
+	s.isaCells.add(stored.ID, stored.Cells)
+	s.incrementNotificationIndices(subscriptions)
+	s.enqueueNotifications(notifications)
+
+	return copyISA(stored), subscriptions, nil
+}
+
+// DeleteISA deletes the IdentificationServiceArea identified by "id" and owned by "owner".
+// Returns the delete IdentificationServiceArea and all Subscriptions affected by the delete.
+// The notifications returned by "notify", if not nil, are enqueued with the
+// delete.
+func (s *Store) DeleteISA(ctx context.Context, id models.ID, owner models.Owner, version *models.Version, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.fetchISAByIDAndOwner(id, owner)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil, dsserr.NotFound(id.String())
+	case !version.Empty() && !version.Matches(old.Version):
+		return nil, nil, dsserr.VersionMismatch("old version")
+	}
+
+	subscriptions := s.affectedSubscriptions(old)
+	notifications, err := buildNotifications(notify, copyISA(old), subscriptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.isaCells.remove(old.ID, old.Cells)
+	delete(s.isas, old.ID)
+	s.incrementNotificationIndices(subscriptions)
+	s.enqueueNotifications(notifications)
+
+	return copyISA(old), subscriptions, nil
+}
+
+// SearchISAs searches IdentificationServiceArea
+// instances that intersect with "cells" and, if set, the temporal volume
+// defined by "earliest" and "latest" and the vertical interval defined by
+// "minAltitude" and "maxAltitude", restricted to "page".
+func (s *Store) SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *time.Time, latest *time.Time, minAltitude *float32, maxAltitude *float32, page *models.Page) ([]*models.IdentificationServiceArea, error) {
+	if len(cells) == 0 {
+		return nil, dsserr.BadRequest("missing cell IDs for query")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*models.IdentificationServiceArea
+	for id := range s.isaCells.lookup(cells) {
+		isa := s.isas[id]
+		if earliest != nil && isa.StartTime != nil && isa.StartTime.Before(*earliest) {
+			continue
+		}
+		if latest != nil && isa.EndTime != nil && isa.EndTime.After(*latest) {
+			continue
+		}
+		if !altitudesOverlap(isa.AltitudeLo, isa.AltitudeHi, minAltitude, maxAltitude) {
+			continue
+		}
+		if page != nil && page.After.Passed(isa.Version.ToTimestamp(), isa.ID) {
+			continue
+		}
+		result = append(result, copyISA(isa))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return inPageOrder(result[i].Version, result[i].ID, result[j].Version, result[j].ID)
+	})
+
+	if n := pageLimit(page); n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result, nil
+}
+
+// DeleteExpiredISAs deletes at most "limit" IdentificationServiceAreas that
+// ended before "threshold".
+func (s *Store) DeleteExpiredISAs(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*models.IdentificationServiceArea
+	for _, isa := range s.isas {
+		if isa.EndTime != nil && isa.EndTime.Before(threshold) {
+			expired = append(expired, isa)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].EndTime.Before(*expired[j].EndTime)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	for _, isa := range expired {
+		s.isaCells.remove(isa.ID, isa.Cells)
+		delete(s.isas, isa.ID)
+	}
+	return int64(len(expired)), nil
+}
+
+// validateExtents mirrors the CHECK constraints on the database tables.
+func validateExtents(start, end *time.Time, altitudeLo, altitudeHi *float32) error {
+	if start != nil && end != nil && !start.Before(*end) {
+		return dsserr.BadRequest("start time must be before end time")
+	}
+	if altitudeLo != nil && altitudeHi != nil && *altitudeLo > *altitudeHi {
+		return dsserr.BadRequest("altitude_lo must not be above altitude_hi")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+func copyNotification(n *models.Notification) *models.Notification {
+	result := *n
+	result.Payload = append([]byte(nil), n.Payload...)
+	result.DeadLetteredAt = copyTime(n.DeadLetteredAt)
+	return &result
+}
+
+// buildNotifications returns the notifications returned by "notify", if not
+// nil, for the change of "isa" affecting "subscriptions". "notify" receives
+// copies, so it cannot modify the state of the store.
+func buildNotifications(notify models.NotificationsFunc, isa *models.IdentificationServiceArea, subscriptions []*models.Subscription) ([]*models.Notification, error) {
+	if notify == nil {
+		return nil, nil
+	}
+	copies := make([]*models.Subscription, len(subscriptions))
+	for i, sub := range subscriptions {
+		copies[i] = copySubscription(sub)
+	}
+	return notify(isa, copies)
+}
+
+// enqueueNotifications persists "notifications" for delivery. Must be called
+// with s.mu held.
+func (s *Store) enqueueNotifications(notifications []*models.Notification) {
+	for _, n := range notifications {
+		s.notifications[n.ID] = copyNotification(n)
+	}
+}
+
+// LeaseNotifications returns at most "limit" notifications that are due at
+// "now", ordered by their next attempt, and pushes their next attempt back to
+// now + "lease".
+func (s *Store) LeaseNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*models.Notification
+	for _, n := range s.notifications {
+		if n.DeadLetteredAt == nil && !n.NextAttemptAt.After(now) {
+			due = append(due, n)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	result := make([]*models.Notification, len(due))
+	for i, n := range due {
+		n.NextAttemptAt = now.Add(lease)
+		result[i] = copyNotification(n)
+	}
+	return result, nil
+}
+
+// DeleteNotification removes the notification identified by "id".
+func (s *Store) DeleteNotification(ctx context.Context, id models.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.notifications[id]; !ok {
+		return dsserr.NotFound(id.String())
+	}
+	delete(s.notifications, id)
+	return nil
+}
+
+// UpdateNotification persists the delivery state of "n".
+func (s *Store) UpdateNotification(ctx context.Context, n *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.notifications[n.ID]
+	if !ok {
+		return dsserr.NotFound(n.ID.String())
+	}
+	old.Attempts = n.Attempts
+	old.NextAttemptAt = n.NextAttemptAt
+	old.LastError = n.LastError
+	old.DeadLetteredAt = copyTime(n.DeadLetteredAt)
+	return nil
+}
+
+// DeleteDeadLetteredNotifications deletes at most "limit" notifications that
+// were dead-lettered before "threshold" and returns the number of deleted
+// notifications.
+func (s *Store) DeleteDeadLetteredNotifications(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deadLettered []*models.Notification
+	for _, n := range s.notifications {
+		if n.DeadLetteredAt != nil && n.DeadLetteredAt.Before(threshold) {
+			deadLettered = append(deadLettered, n)
+		}
+	}
+	sort.Slice(deadLettered, func(i, j int) bool {
+		return deadLettered[i].DeadLetteredAt.Before(*deadLettered[j].DeadLetteredAt)
+	})
+	if len(deadLettered) > limit {
+		deadLettered = deadLettered[:limit]
+	}
+
+	for _, n := range deadLettered {
+		delete(s.notifications, n.ID)
+	}
+	return int64(len(deadLettered)), nil
+}
@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+// ListISAs returns at most "limit" IdentificationServiceAreas with IDs greater
+// than "after", ordered by ID.
+func (s *Store) ListISAs(ctx context.Context, after models.ID, limit int) ([]*models.IdentificationServiceArea, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []*models.IdentificationServiceArea{}
+	for id, isa := range s.isas {
+		if id > after {
+			result = append(result, copyISA(isa))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// UpdateISACells replaces the cells of the IdentificationServiceArea
+// identified by "id" if it is still at "version".
+func (s *Store) UpdateISACells(ctx context.Context, id models.ID, version *models.Version, cells s2.CellUnion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	isa, ok := s.isas[id]
+	switch {
+	case !ok:
+		return dsserr.NotFound(id.String())
+	case !version.Matches(isa.Version):
+		return dsserr.VersionMismatch("old version")
+	}
+
+	s.isaCells.remove(isa.ID, isa.Cells)
+	isa.Cells = copyCells(cells)
+	s.isaCells.add(isa.ID, isa.Cells)
+	return nil
+}
+
+// ListSubscriptions returns at most "limit" subscriptions with IDs greater
+// than "after", ordered by ID.
+func (s *Store) ListSubscriptions(ctx context.Context, after models.ID, limit int) ([]*models.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []*models.Subscription{}
+	for id, sub := range s.subscriptions {
+		if id > after {
+			result = append(result, copySubscription(sub))
+		}
+	}
+	sortSubscriptions(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// UpdateSubscriptionCells replaces the cells of the subscription identified
+// by "id" if it is still at "version".
+func (s *Store) UpdateSubscriptionCells(ctx context.Context, id models.ID, version *models.Version, cells s2.CellUnion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subscriptions[id]
+	switch {
+	case !ok:
+		return dsserr.NotFound(id.String())
+	case !version.Matches(sub.Version):
+		return dsserr.VersionMismatch("old version")
+	}
+
+	s.subscriptionCells.remove(sub.ID, sub.Cells)
+	sub.Cells = copyCells(cells)
+	s.subscriptionCells.add(sub.ID, sub.Cells)
+	return nil
+}
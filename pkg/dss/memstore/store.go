@@ -0,0 +1,198 @@
+package memstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+)
+
+// Store is an implementation of dss.Store keeping all of its state in
+// memory. It is meant for tests and local development and does not persist
+// anything across restarts.
+type Store struct {
+	mu sync.RWMutex
+
+	isas              map[models.ID]*models.IdentificationServiceArea
+	isaCells          cellIndex
+	subscriptions     map[models.ID]*models.Subscription
+	subscriptionCells cellIndex
+	notifications     map[models.ID]*models.Notification
+
+	// lastUpdate is the timestamp handed out for the most recent write. It
+	// ensures that versions are strictly increasing even if the wall clock
+	// does not advance between two writes.
+	lastUpdate time.Time
+}
+
+// New returns a new, empty Store instance.
+func New() *Store {
+	return &Store{
+		isas:              make(map[models.ID]*models.IdentificationServiceArea),
+		isaCells:          newCellIndex(),
+		subscriptions:     make(map[models.ID]*models.Subscription),
+		subscriptionCells: newCellIndex(),
+		notifications:     make(map[models.ID]*models.Notification),
+	}
+}
+
+// Close releases all resources held by the store.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.isas = make(map[models.ID]*models.IdentificationServiceArea)
+	s.isaCells = newCellIndex()
+	s.subscriptions = make(map[models.ID]*models.Subscription)
+	s.subscriptionCells = newCellIndex()
+	s.notifications = make(map[models.ID]*models.Notification)
+	return nil
+}
+
+// now returns the timestamp to use for a write, mirroring
+// transaction_timestamp() in the database-backed implementation. Must be
+// called with s.mu held.
+func (s *Store) now() time.Time {
+	t := time.Now().UTC()
+	if !t.After(s.lastUpdate) {
+		t = s.lastUpdate.Add(time.Nanosecond)
+	}
+	s.lastUpdate = t
+	return t
+}
+
+// inPageOrder returns true if the entity identified by "id1" and last updated
+// at "v1" is ordered before the one identified by "id2" and last updated at
+// "v2" in paginated search results.
+func inPageOrder(v1 *models.Version, id1 models.ID, v2 *models.Version, id2 models.ID) bool {
+	t1, t2 := v1.ToTimestamp(), v2.ToTimestamp()
+	if !t1.Equal(t2) {
+		return t1.Before(t2)
+	}
+	return id1 < id2
+}
+
+// pageLimit returns the maximum number of results to return for "page", 0
+// meaning unlimited.
+func pageLimit(page *models.Page) int {
+	if page == nil {
+		return 0
+	}
+	return page.Limit
+}
+
+// cellIndex maps cells to the entities covering them. Entities are indexed by
+// their own cells as well as by the ancestors of their cells, see
+// geo.Ancestors.
+type cellIndex struct {
+	cells     map[s2.CellID]map[models.ID]bool
+	ancestors map[s2.CellID]map[models.ID]bool
+}
+
+func newCellIndex() cellIndex {
+	return cellIndex{
+		cells:     make(map[s2.CellID]map[models.ID]bool),
+		ancestors: make(map[s2.CellID]map[models.ID]bool),
+	}
+}
+
+func addToIndex(index map[s2.CellID]map[models.ID]bool, id models.ID, cells s2.CellUnion) {
+	for _, cell := range cells {
+		ids, ok := index[cell]
+		if !ok {
+			ids = make(map[models.ID]bool)
+			index[cell] = ids
+		}
+		ids[id] = true
+	}
+}
+
+func removeFromIndex(index map[s2.CellID]map[models.ID]bool, id models.ID, cells s2.CellUnion) {
+	for _, cell := range cells {
+		ids := index[cell]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(index, cell)
+		}
+	}
+}
+
+func (ci cellIndex) add(id models.ID, cells s2.CellUnion) {
+	addToIndex(ci.cells, id, cells)
+	addToIndex(ci.ancestors, id, geo.Ancestors(cells))
+}
+
+func (ci cellIndex) remove(id models.ID, cells s2.CellUnion) {
+	removeFromIndex(ci.cells, id, cells)
+	removeFromIndex(ci.ancestors, id, geo.Ancestors(cells))
+}
+
+// lookup returns the IDs of all entities covering at least one of "cells",
+// independent of the levels of the cells involved.
+func (ci cellIndex) lookup(cells s2.CellUnion) map[models.ID]bool {
+	result := make(map[models.ID]bool)
+	for _, cu := range []s2.CellUnion{cells, geo.Ancestors(cells)} {
+		for _, cell := range cu {
+			for id := range ci.cells[cell] {
+				result[id] = true
+			}
+		}
+	}
+	for _, cell := range cells {
+		for id := range ci.ancestors[cell] {
+			result[id] = true
+		}
+	}
+	return result
+}
+
+// altitudesOverlap returns true if the vertical intervals ["lo1", "hi1"] and
+// ["lo2", "hi2"] intersect. Nil bounds are treated as unbounded.
+func altitudesOverlap(lo1, hi1, lo2, hi2 *float32) bool {
+	if hi1 != nil && lo2 != nil && *hi1 < *lo2 {
+		return false
+	}
+	if lo1 != nil && hi2 != nil && *lo1 > *hi2 {
+		return false
+	}
+	return true
+}
+
+// timesOverlap returns true if the time windows ["start1", "end1"] and
+// ["start2", "end2"] intersect. Nil bounds are treated as unbounded.
+func timesOverlap(start1, end1, start2, end2 *time.Time) bool {
+	if end1 != nil && start2 != nil && end1.Before(*start2) {
+		return false
+	}
+	if start1 != nil && end2 != nil && start1.After(*end2) {
+		return false
+	}
+	return true
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+func copyFloat32(f *float32) *float32 {
+	if f == nil {
+		return nil
+	}
+	c := *f
+	return &c
+}
+
+func copyCells(cells s2.CellUnion) s2.CellUnion {
+	if cells == nil {
+		return nil
+	}
+	c := make(s2.CellUnion, len(cells))
+	copy(c, cells)
+	return c
+}
@@ -0,0 +1,41 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/dispatcher"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/reindex"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/storetest"
+)
+
+var (
+	// Make sure that Store implements dss.Store.
+	_ dss.Store = &Store{}
+	// Make sure that Store implements dispatcher.Outbox.
+	_ dispatcher.Outbox = &Store{}
+	// Make sure that Store implements reindex.Store.
+	_ reindex.Store = &Store{}
+)
+
+func TestStoreConformance(t *testing.T) {
+	storetest.Run(t, func(ctx context.Context, t *testing.T) (dss.Store, func() error) {
+		store := New()
+		return store, store.Close
+	})
+}
+
+func TestOutboxConformance(t *testing.T) {
+	storetest.RunOutbox(t, func(ctx context.Context, t *testing.T) (storetest.OutboxStore, func() error) {
+		store := New()
+		return store, store.Close
+	})
+}
+
+func TestReindexConformance(t *testing.T) {
+	storetest.RunReindex(t, func(ctx context.Context, t *testing.T) (storetest.ReindexStore, func() error) {
+		store := New()
+		return store, store.Close
+	})
+}
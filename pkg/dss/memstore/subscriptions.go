@@ -0,0 +1,224 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+func copySubscription(sub *models.Subscription) *models.Subscription {
+	c := *sub
+	c.Cells = copyCells(sub.Cells)
+	c.StartTime = copyTime(sub.StartTime)
+	c.EndTime = copyTime(sub.EndTime)
+	c.AltitudeHi = copyFloat32(sub.AltitudeHi)
+	c.AltitudeLo = copyFloat32(sub.AltitudeLo)
+	return &c
+}
+
+func sortSubscriptions(subs []*models.Subscription) {
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].ID < subs[j].ID
+	})
+}
+
+// affectedSubscriptions returns copies of all subscriptions affected by any
+// of "isas", the extents of the same ISA before and after a change. A
+// subscription is affected by an extent if it covers at least one of the
+// cells of that extent, is not owned by the owner of the ISA and its vertical
+// extent and time window intersect those of that extent. Nil extents are
+// skipped and every affected subscription is returned once. The copies carry
+// the incremented notification index, which is stored by
+// incrementNotificationIndices. Must be called with s.mu held.
+func (s *Store) affectedSubscriptions(isas ...*models.IdentificationServiceArea) []*models.Subscription {
+	var (
+		result = []*models.Subscription{}
+		seen   = map[models.ID]bool{}
+	)
+	for _, isa := range isas {
+		if isa == nil {
+			continue
+		}
+		for id := range s.subscriptionCells.lookup(isa.Cells) {
+			sub := s.subscriptions[id]
+			if seen[id] || sub.Owner == isa.Owner {
+				continue
+			}
+			if !altitudesOverlap(sub.AltitudeLo, sub.AltitudeHi, isa.AltitudeLo, isa.AltitudeHi) {
+				continue
+			}
+			if !timesOverlap(sub.StartTime, sub.EndTime, isa.StartTime, isa.EndTime) {
+				continue
+			}
+			seen[id] = true
+			affected := copySubscription(sub)
+			affected.NotificationIndex++
+			result = append(result, affected)
+		}
+	}
+	sortSubscriptions(result)
+	return result
+}
+
+// incrementNotificationIndices stores the notification indices of
+// "affected", as returned by affectedSubscriptions. Must be called with s.mu
+// held.
+func (s *Store) incrementNotificationIndices(affected []*models.Subscription) {
+	for _, sub := range affected {
+		s.subscriptions[sub.ID].NotificationIndex = sub.NotificationIndex
+	}
+}
+
+// GetSubscription returns the subscription identified by "id".
+func (s *Store) GetSubscription(ctx context.Context, id models.ID) (*models.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copySubscription(sub), nil
+}
+
+// countSubscriptionsPerArea returns an error if the owner of "sub" holds
+// "maxPerArea" or more other subscriptions that are active at "now" and
+// intersect one of the cells of "sub". Must be called with s.mu held.
+func (s *Store) countSubscriptionsPerArea(sub *models.Subscription, now time.Time, maxPerArea int) error {
+	for _, cell := range sub.Cells {
+		count := 0
+		for id := range s.subscriptionCells.lookup(s2.CellUnion{cell}) {
+			other := s.subscriptions[id]
+			if other.ID == sub.ID || other.Owner != sub.Owner {
+				continue
+			}
+			if other.EndTime != nil && other.EndTime.Before(now) {
+				continue
+			}
+			count++
+		}
+		if count >= maxPerArea {
+			return dsserr.Exhausted(fmt.Sprintf("too many subscriptions in area, at most %d are allowed per owner", maxPerArea))
+		}
+	}
+	return nil
+}
+
+// InsertSubscription inserts subscription into the store and returns
+// the resulting subscription including its ID.
+func (s *Store) InsertSubscription(ctx context.Context, sub *models.Subscription, maxPerArea int) (*models.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.subscriptions[sub.ID]
+	if ok && !sub.Version.Empty() && !sub.Version.Matches(old.Version) {
+		return nil, dsserr.VersionMismatch("old version")
+	}
+	if err := validateExtents(sub.StartTime, sub.EndTime, sub.AltitudeLo, sub.AltitudeHi); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if maxPerArea > 0 {
+		if err := s.countSubscriptionsPerArea(sub, now, maxPerArea); err != nil {
+			return nil, err
+		}
+	}
+
+	stored := copySubscription(sub)
+	stored.Version = models.VersionFromTime(now)
+
+	if ok {
+		// The notification index is owned by the DSS, it must not be reset by
+		// updates to the subscription.
+		stored.NotificationIndex = old.NotificationIndex
+		s.subscriptionCells.remove(old.ID, old.Cells)
+	}
+	s.subscriptions[stored.ID] = stored
+	s.subscriptionCells.add(stored.ID, stored.Cells)
+
+	return copySubscription(stored), nil
+}
+
+// DeleteSubscription deletes the subscription identified by "id" and
+// returns the deleted subscription.
+func (s *Store) DeleteSubscription(ctx context.Context, id models.ID, owner models.Owner, version *models.Version) (*models.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.subscriptions[id]
+	switch {
+	case !ok || old.Owner != owner:
+		return nil, dsserr.NotFound(id.String())
+	case !version.Empty() && !version.Matches(old.Version):
+		return nil, dsserr.VersionMismatch("old version")
+	}
+
+	s.subscriptionCells.remove(old.ID, old.Cells)
+	delete(s.subscriptions, old.ID)
+
+	return copySubscription(old), nil
+}
+
+// SearchSubscriptions returns all subscriptions owned by "owner" in "cells",
+// restricted to "page".
+func (s *Store) SearchSubscriptions(ctx context.Context, cells s2.CellUnion, owner models.Owner, page *models.Page) ([]*models.Subscription, error) {
+	if len(cells) == 0 {
+		return nil, dsserr.BadRequest("no location provided")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*models.Subscription
+	for id := range s.subscriptionCells.lookup(cells) {
+		sub := s.subscriptions[id]
+		if sub.Owner != owner {
+			continue
+		}
+		if page != nil && page.After.Passed(sub.Version.ToTimestamp(), sub.ID) {
+			continue
+		}
+		result = append(result, copySubscription(sub))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return inPageOrder(result[i].Version, result[i].ID, result[j].Version, result[j].ID)
+	})
+
+	if n := pageLimit(page); n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result, nil
+}
+
+// DeleteExpiredSubscriptions deletes at most "limit" subscriptions that ended
+// before "threshold".
+func (s *Store) DeleteExpiredSubscriptions(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*models.Subscription
+	for _, sub := range s.subscriptions {
+		if sub.EndTime != nil && sub.EndTime.Before(threshold) {
+			expired = append(expired, sub)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].EndTime.Before(*expired[j].EndTime)
+	})
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	for _, sub := range expired {
+		s.subscriptionCells.remove(sub.ID, sub.Cells)
+		delete(s.subscriptions, sub.ID)
+	}
+	return int64(len(expired)), nil
+}
@@ -0,0 +1,177 @@
+// Package metrics instruments the DSS with Prometheus metrics.
+//
+// Metrics collects per-RPC latencies and status codes through gRPC
+// interceptors, and per-operation latencies, errors and domain measures such
+// as the number of cells per query, the number of subscribers notified per
+// ISA write and version conflicts through a dss.Store decorator.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "dss"
+
+var (
+	// cellBuckets are the buckets of histograms counting cells.
+	cellBuckets = prometheus.ExponentialBuckets(1, 2, 12)
+	// subscriberBuckets are the buckets of histograms counting subscribers.
+	subscriberBuckets = []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500}
+)
+
+// Metrics bundles the collectors of the DSS. The methods of a Metrics are
+// safe for concurrent use.
+type Metrics struct {
+	rpcDuration *prometheus.HistogramVec
+	rpcInFlight *prometheus.GaugeVec
+
+	storeDuration    *prometheus.HistogramVec
+	storeErrors      *prometheus.CounterVec
+	storeCells       *prometheus.HistogramVec
+	isaSubscribers   prometheus.Histogram
+	versionConflicts *prometheus.CounterVec
+}
+
+// New returns a new Metrics instance whose collectors are registered with
+// "registerer".
+func New(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rpc_duration_seconds",
+			Help:      "Duration of handled RPCs by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		rpcInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rpcs_in_flight",
+			Help:      "Number of RPCs currently being handled by method.",
+		}, []string{"method"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Duration of store operations by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		storeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "store_operation_errors_total",
+			Help:      "Number of failed store operations by operation and status code.",
+		}, []string{"operation", "code"}),
+		storeCells: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "store_operation_cells",
+			Help:      "Number of cells written or queried by store operations by operation.",
+			Buckets:   cellBuckets,
+		}, []string{"operation"}),
+		isaSubscribers: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "isa_write_subscribers",
+			Help:      "Number of subscriptions affected by a write or delete of an ISA.",
+			Buckets:   subscriberBuckets,
+		}),
+		versionConflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "version_conflicts_total",
+			Help:      "Number of writes rejected because of version mismatches or transaction conflicts by operation.",
+		}, []string{"operation"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.rpcDuration,
+		m.rpcInFlight,
+		m.storeDuration,
+		m.storeErrors,
+		m.storeCells,
+		m.isaSubscribers,
+		m.versionConflicts,
+	} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// methodName returns the name of the RPC "fullMethod", e.g.
+// "SearchIdentificationServiceAreas".
+func methodName(fullMethod string) string {
+	parts := strings.Split(fullMethod, "/")
+	return parts[len(parts)-1]
+}
+
+// observeRPC records the outcome "err" of a call to "method" that started at
+// "start".
+func (m *Metrics) observeRPC(method string, start time.Time, err error) {
+	m.rpcDuration.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
+
+// Interceptor records the duration and status code of unary calls.
+func (m *Metrics) Interceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var (
+		method   = methodName(info.FullMethod)
+		inFlight = m.rpcInFlight.WithLabelValues(method)
+		start    = time.Now()
+	)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	resp, err := handler(ctx, req)
+	m.observeRPC(method, start, err)
+	return resp, err
+}
+
+// StreamInterceptor records the duration and status code of streaming calls.
+func (m *Metrics) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	var (
+		method   = methodName(info.FullMethod)
+		inFlight = m.rpcInFlight.WithLabelValues(method)
+		start    = time.Now()
+	)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	err := handler(srv, ss)
+	m.observeRPC(method, start, err)
+	return err
+}
+
+// InstrumentHTTP returns "handler" instrumented with the duration and status
+// code of HTTP requests. The collectors are registered with "registerer".
+func InstrumentHTTP(registerer prometheus.Registerer, handler http.Handler) (http.Handler, error) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of handled HTTP requests by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+	if err := registerer.Register(duration); err != nil {
+		return nil, err
+	}
+	return promhttp.InstrumentHandlerDuration(duration, handler), nil
+}
+
+// NewRegistry returns a new prometheus.Registry including the standard
+// collectors for the Go runtime and the process.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler returns the http.Handler exposing the metrics gathered by
+// "registry".
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/memstore"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/storetest"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var cells = s2.CellUnion{s2.CellID(42), s2.CellID(84)}
+
+func newTestMetrics(t *testing.T) (*Metrics, *prometheus.Registry) {
+	registry := prometheus.NewRegistry()
+	m, err := New(registry)
+	require.NoError(t, err)
+	return m, registry
+}
+
+// histogram returns the histogram "name" with "labels" gathered from
+// "registry".
+func histogram(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) *dto.Histogram {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			if len(metric.GetLabel()) != len(labels) {
+				continue
+			}
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			return metric.GetHistogram()
+		}
+	}
+	require.Fail(t, "histogram not found", "%s %v", name, labels)
+	return nil
+}
+
+func newISA(owner models.Owner) *models.IdentificationServiceArea {
+	end := time.Now().Add(time.Hour)
+	return &models.IdentificationServiceArea{
+		ID:      models.ID(uuid.New().String()),
+		Owner:   owner,
+		Url:     "https://no/place/like/home",
+		EndTime: &end,
+		Cells:   cells,
+	}
+}
+
+func TestStoreConformance(t *testing.T) {
+	storetest.Run(t, func(ctx context.Context, t *testing.T) (dss.Store, func() error) {
+		m, _ := newTestMetrics(t)
+		store := m.Store(memstore.New())
+		return store, store.Close
+	})
+}
+
+func TestStoreRecordsOperations(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		m, registry = newTestMetrics(t)
+		store       = m.Store(memstore.New())
+	)
+
+	_, err := store.InsertSubscription(ctx, &models.Subscription{
+		ID:    models.ID(uuid.New().String()),
+		Owner: "you",
+		Url:   "https://no/place/like/home",
+		Cells: cells,
+	}, 0)
+	require.NoError(t, err)
+
+	isa, subscribers, err := store.InsertISA(ctx, newISA("me"), nil)
+	require.NoError(t, err)
+	require.Len(t, subscribers, 1)
+
+	_, err = store.SearchISAs(ctx, s2.CellUnion{s2.CellID(42)}, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(1), histogram(t, registry, "dss_store_operation_duration_seconds", map[string]string{"operation": "InsertISA"}).GetSampleCount())
+	require.Equal(t, float64(2), histogram(t, registry, "dss_store_operation_cells", map[string]string{"operation": "InsertISA"}).GetSampleSum())
+	require.Equal(t, float64(1), histogram(t, registry, "dss_store_operation_cells", map[string]string{"operation": "SearchISAs"}).GetSampleSum())
+	require.Equal(t, float64(1), histogram(t, registry, "dss_isa_write_subscribers", nil).GetSampleSum())
+
+	// Missing entities count as NotFound errors.
+	_, err = store.GetISA(ctx, models.ID(uuid.New().String()))
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.storeErrors.WithLabelValues("GetISA", codes.NotFound.String())))
+
+	// Writes with stale versions count as version conflicts.
+	stale := *isa
+	stale.Version = models.VersionFromTime(time.Unix(42, 0))
+	_, _, err = store.InsertISA(ctx, &stale, nil)
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.storeErrors.WithLabelValues("InsertISA", codes.Aborted.String())))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.versionConflicts.WithLabelValues("InsertISA")))
+}
+
+func TestOutboxRecordsOperations(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		m, registry = newTestMetrics(t)
+		outbox      = m.Outbox(memstore.New())
+	)
+
+	_, err := outbox.LeaseNotifications(ctx, time.Now(), time.Minute, 10)
+	require.NoError(t, err)
+	_, err = outbox.DeleteDeadLetteredNotifications(ctx, time.Now(), 10)
+	require.NoError(t, err)
+
+	err = outbox.DeleteNotification(ctx, models.ID(uuid.New().String()))
+	require.Error(t, err)
+
+	require.Equal(t, uint64(1), histogram(t, registry, "dss_store_operation_duration_seconds", map[string]string{"operation": "LeaseNotifications"}).GetSampleCount())
+	require.Equal(t, uint64(1), histogram(t, registry, "dss_store_operation_duration_seconds", map[string]string{"operation": "DeleteDeadLetteredNotifications"}).GetSampleCount())
+	require.Equal(t, float64(1), testutil.ToFloat64(m.storeErrors.WithLabelValues("DeleteNotification", codes.NotFound.String())))
+}
+
+func TestInterceptor(t *testing.T) {
+	var (
+		m, registry = newTestMetrics(t)
+		info        = &grpc.UnaryServerInfo{FullMethod: "/dssproto.DSService/GetIdentificationServiceArea"}
+	)
+
+	_, err := m.Interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		require.Equal(t, float64(1), testutil.ToFloat64(m.rpcInFlight.WithLabelValues("GetIdentificationServiceArea")))
+		return nil, dsserr.NotFound("foo")
+	})
+	require.Error(t, err)
+	_, err = m.Interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, float64(0), testutil.ToFloat64(m.rpcInFlight.WithLabelValues("GetIdentificationServiceArea")))
+	for _, code := range []codes.Code{codes.NotFound, codes.OK} {
+		require.Equal(t, uint64(1), histogram(t, registry, "dss_rpc_duration_seconds", map[string]string{
+			"method": "GetIdentificationServiceArea",
+			"code":   code.String(),
+		}).GetSampleCount())
+	}
+}
+
+func TestNewFailsOnDuplicateRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	_, err := New(registry)
+	require.NoError(t, err)
+	_, err = New(registry)
+	require.Error(t, err)
+}
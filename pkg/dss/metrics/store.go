@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/dispatcher"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// instrumentedStore is a dss.Store recording metrics for the operations of
+// the wrapped dss.Store.
+type instrumentedStore struct {
+	store   dss.Store
+	metrics *Metrics
+}
+
+// instrumentedOutbox is a dispatcher.Outbox recording metrics for the
+// operations of the wrapped dispatcher.Outbox.
+type instrumentedOutbox struct {
+	outbox  dispatcher.Outbox
+	metrics *Metrics
+}
+
+// Store returns "store" instrumented with m. The returned dss.Store does not
+// implement any of the optional interfaces implemented by "store"; use
+// Outbox to instrument a dispatcher.Outbox.
+func (m *Metrics) Store(store dss.Store) dss.Store {
+	return &instrumentedStore{store: store, metrics: m}
+}
+
+// Outbox returns "outbox" instrumented with m. Its operations are recorded
+// with the same metrics as the operations of Store.
+func (m *Metrics) Outbox(outbox dispatcher.Outbox) dispatcher.Outbox {
+	return &instrumentedOutbox{outbox: outbox, metrics: m}
+}
+
+// errorCode returns the status code describing "err".
+func errorCode(err error) codes.Code {
+	if err == sql.ErrNoRows {
+		return codes.NotFound
+	}
+	return status.Code(err)
+}
+
+// observeStore records the outcome "err" of the store "operation" that
+// started at "start".
+func (m *Metrics) observeStore(operation string, start time.Time, err error) {
+	m.storeDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return
+	}
+	code := errorCode(err)
+	m.storeErrors.WithLabelValues(operation, code.String()).Inc()
+	if code == codes.Aborted {
+		m.versionConflicts.WithLabelValues(operation).Inc()
+	}
+}
+
+// observe records the outcome "err" of "operation" that started at "start".
+func (s *instrumentedStore) observe(operation string, start time.Time, err error) {
+	s.metrics.observeStore(operation, start, err)
+}
+
+// observeCells records the number of cells written or queried by "operation".
+func (s *instrumentedStore) observeCells(operation string, cells s2.CellUnion) {
+	s.metrics.storeCells.WithLabelValues(operation).Observe(float64(len(cells)))
+}
+
+func (s *instrumentedStore) Close() error {
+	return s.store.Close()
+}
+
+func (s *instrumentedStore) GetISA(ctx context.Context, id models.ID) (*models.IdentificationServiceArea, error) {
+	start := time.Now()
+	isa, err := s.store.GetISA(ctx, id)
+	s.observe("GetISA", start, err)
+	return isa, err
+}
+
+func (s *instrumentedStore) DeleteISA(ctx context.Context, id models.ID, owner models.Owner, version *models.Version, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error) {
+	start := time.Now()
+	isa, subscribers, err := s.store.DeleteISA(ctx, id, owner, version, notify)
+	s.observe("DeleteISA", start, err)
+	if err == nil {
+		s.metrics.isaSubscribers.Observe(float64(len(subscribers)))
+	}
+	return isa, subscribers, err
+}
+
+func (s *instrumentedStore) InsertISA(ctx context.Context, isa *models.IdentificationServiceArea, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error) {
+	s.observeCells("InsertISA", isa.Cells)
+	start := time.Now()
+	result, subscribers, err := s.store.InsertISA(ctx, isa, notify)
+	s.observe("InsertISA", start, err)
+	if err == nil {
+		s.metrics.isaSubscribers.Observe(float64(len(subscribers)))
+	}
+	return result, subscribers, err
+}
+
+func (s *instrumentedStore) SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *time.Time, latest *time.Time, minAltitude *float32, maxAltitude *float32, page *models.Page) ([]*models.IdentificationServiceArea, error) {
+	s.observeCells("SearchISAs", cells)
+	start := time.Now()
+	isas, err := s.store.SearchISAs(ctx, cells, earliest, latest, minAltitude, maxAltitude, page)
+	s.observe("SearchISAs", start, err)
+	return isas, err
+}
+
+func (s *instrumentedStore) GetSubscription(ctx context.Context, id models.ID) (*models.Subscription, error) {
+	start := time.Now()
+	sub, err := s.store.GetSubscription(ctx, id)
+	s.observe("GetSubscription", start, err)
+	return sub, err
+}
+
+func (s *instrumentedStore) DeleteSubscription(ctx context.Context, id models.ID, owner models.Owner, version *models.Version) (*models.Subscription, error) {
+	start := time.Now()
+	sub, err := s.store.DeleteSubscription(ctx, id, owner, version)
+	s.observe("DeleteSubscription", start, err)
+	return sub, err
+}
+
+func (s *instrumentedStore) InsertSubscription(ctx context.Context, sub *models.Subscription, maxPerArea int) (*models.Subscription, error) {
+	s.observeCells("InsertSubscription", sub.Cells)
+	start := time.Now()
+	result, err := s.store.InsertSubscription(ctx, sub, maxPerArea)
+	s.observe("InsertSubscription", start, err)
+	return result, err
+}
+
+func (s *instrumentedStore) SearchSubscriptions(ctx context.Context, cells s2.CellUnion, owner models.Owner, page *models.Page) ([]*models.Subscription, error) {
+	s.observeCells("SearchSubscriptions", cells)
+	start := time.Now()
+	subs, err := s.store.SearchSubscriptions(ctx, cells, owner, page)
+	s.observe("SearchSubscriptions", start, err)
+	return subs, err
+}
+
+func (s *instrumentedStore) DeleteExpiredISAs(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	start := time.Now()
+	count, err := s.store.DeleteExpiredISAs(ctx, threshold, limit)
+	s.observe("DeleteExpiredISAs", start, err)
+	return count, err
+}
+
+func (s *instrumentedStore) DeleteExpiredSubscriptions(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	start := time.Now()
+	count, err := s.store.DeleteExpiredSubscriptions(ctx, threshold, limit)
+	s.observe("DeleteExpiredSubscriptions", start, err)
+	return count, err
+}
+
+func (o *instrumentedOutbox) LeaseNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.Notification, error) {
+	start := time.Now()
+	notifications, err := o.outbox.LeaseNotifications(ctx, now, lease, limit)
+	o.metrics.observeStore("LeaseNotifications", start, err)
+	return notifications, err
+}
+
+func (o *instrumentedOutbox) DeleteNotification(ctx context.Context, id models.ID) error {
+	start := time.Now()
+	err := o.outbox.DeleteNotification(ctx, id)
+	o.metrics.observeStore("DeleteNotification", start, err)
+	return err
+}
+
+func (o *instrumentedOutbox) UpdateNotification(ctx context.Context, n *models.Notification) error {
+	start := time.Now()
+	err := o.outbox.UpdateNotification(ctx, n)
+	o.metrics.observeStore("UpdateNotification", start, err)
+	return err
+}
+
+func (o *instrumentedOutbox) DeleteDeadLetteredNotifications(ctx context.Context, threshold time.Time, limit int) (int64, error) {
+	start := time.Now()
+	count, err := o.outbox.DeleteDeadLetteredNotifications(ctx, threshold, limit)
+	o.metrics.observeStore("DeleteDeadLetteredNotifications", start, err)
+	return count, err
+}
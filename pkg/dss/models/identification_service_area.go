@@ -27,6 +27,8 @@ func (i *IdentificationServiceArea) ToProto() (*dspb.IdentificationServiceArea,
 		Owner:      i.Owner.String(),
 		FlightsUrl: i.Url,
 		Version:    i.Version.String(),
+		AltitudeHi: float32ToWrapper(i.AltitudeHi),
+		AltitudeLo: float32ToWrapper(i.AltitudeLo),
 	}
 
 	if i.StartTime != nil {
@@ -47,7 +49,10 @@ func (i *IdentificationServiceArea) ToProto() (*dspb.IdentificationServiceArea,
 	return result, nil
 }
 
-func (i *IdentificationServiceArea) SetExtents(extents *dspb.Volume4D) error {
+// SetExtents sets the time window, altitudes and cells of i from "extents",
+// mapping the footprint to cells with "coverer". Footprints larger than
+// "maxAreaKm2" square kilometers are rejected.
+func (i *IdentificationServiceArea) SetExtents(extents *dspb.Volume4D, coverer *geo.Coverer, maxAreaKm2 float64) error {
 	var err error
 	if extents == nil {
 		return nil
@@ -78,10 +83,12 @@ func (i *IdentificationServiceArea) SetExtents(extents *dspb.Volume4D) error {
 	if wrapper := space.GetAltitudeLo(); wrapper != nil {
 		i.AltitudeLo = ptrToFloat32(wrapper.GetValue())
 	}
-	footprint := space.GetFootprint()
-	if footprint == nil {
+	if i.AltitudeLo != nil && i.AltitudeHi != nil && *i.AltitudeLo > *i.AltitudeHi {
+		return errAltitudeLoAboveHi
+	}
+	if !geo.HasFootprint(space) {
 		return nil
 	}
-	i.Cells, err = geo.GeoPolygonToCellIDs(footprint)
+	i.Cells, err = coverer.Volume3DToCellIDs(space, maxAreaKm2)
 	return err
 }
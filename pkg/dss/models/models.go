@@ -1,8 +1,11 @@
 package models
 
 import (
+	"errors"
 	"strconv"
 	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
 )
 
 const (
@@ -12,6 +15,10 @@ const (
 	versionBase = 32
 )
 
+var (
+	errAltitudeLoAboveHi = errors.New("altitude_lo must not be above altitude_hi")
+)
+
 type (
 	ID      string
 	Owner   string
@@ -83,3 +90,10 @@ func (v *Version) ToTimestamp() time.Time {
 func ptrToFloat32(f float32) *float32 {
 	return &f
 }
+
+func float32ToWrapper(f *float32) *wrappers.FloatValue {
+	if f == nil {
+		return nil
+	}
+	return &wrappers.FloatValue{Value: *f}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification is a pending callback to a subscriber, persisted in an outbox
+// until it has been delivered or given up on.
+type Notification struct {
+	ID ID
+	// Url is the callback of the subscription that is notified.
+	Url string
+	// Payload is the JSON-encoded body POSTed to Url.
+	Payload []byte
+	// Attempts counts the failed deliveries so far.
+	Attempts int
+	// NextAttemptAt is the earliest time of the next delivery attempt.
+	NextAttemptAt time.Time
+	// LastError describes the most recent failed delivery.
+	LastError string
+	// DeadLetteredAt is set once delivery has been given up on.
+	DeadLetteredAt *time.Time
+}
+
+// NotificationsFunc returns the notifications to persist for a change of
+// "isa" affecting "subscriptions". Stores persist them atomically with the
+// change and may call a NotificationsFunc several times for a single change,
+// e.g. when retrying a transaction.
+type NotificationsFunc func(isa *IdentificationServiceArea, subscriptions []*Subscription) ([]*Notification, error)
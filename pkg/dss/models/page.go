@@ -0,0 +1,112 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errMalformedPageToken = errors.New("malformed page token")
+	errForeignPageToken   = errors.New("page token was returned for a different query")
+)
+
+// Cursor marks a position in a list of entities ordered by their last update
+// and, for entities updated at the same time, their ID.
+//
+// As the position is defined in terms of the entities' own keys rather than
+// an offset, it stays valid across concurrent writes: entities that are
+// updated while a client pages through results move behind the cursor and are
+// returned again on a later page, but no entity that remains unchanged is
+// ever skipped.
+type Cursor struct {
+	UpdatedAt time.Time
+	ID        ID
+}
+
+// Page restricts a search to at most "Limit" results strictly after "After".
+// A nil "After" starts at the beginning, a "Limit" of 0 returns all remaining
+// results.
+type Page struct {
+	After *Cursor
+	Limit int
+}
+
+// CursorAfter returns the cursor pointing right behind the entity identified
+// by "id" that was last updated at "version".
+func CursorAfter(id ID, version *Version) *Cursor {
+	return &Cursor{
+		UpdatedAt: version.ToTimestamp(),
+		ID:        id,
+	}
+}
+
+// queryDigest returns a short digest of "query" to embed in page tokens.
+func queryDigest(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// Token returns an opaque string representation of c, suitable for handing
+// out to clients as a page token. The token is bound to "query", which
+// describes the search that c was returned for.
+//
+// Tokens are not signed. Clients can craft tokens for any position, which
+// only lets them skip results of searches they are allowed to run anyway.
+func (c *Cursor) Token(query string) string {
+	if c == nil {
+		return ""
+	}
+	raw := strconv.FormatInt(c.UpdatedAt.UnixNano(), versionBase) + "/" + c.ID.String() + "/" + queryDigest(query)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// CursorFromToken parses a token previously returned by Cursor.Token for
+// "query". Tokens returned for any other query are rejected. An empty token
+// yields a nil cursor.
+func CursorFromToken(token string, query string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errMalformedPageToken
+	}
+	parts := strings.Split(string(raw), "/")
+	if len(parts) != 3 {
+		return nil, errMalformedPageToken
+	}
+	nanos, err := strconv.ParseInt(parts[0], versionBase, 64)
+	if err != nil {
+		return nil, errMalformedPageToken
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, errMalformedPageToken
+	}
+	if parts[2] != queryDigest(query) {
+		return nil, errForeignPageToken
+	}
+	return &Cursor{
+		UpdatedAt: time.Unix(0, nanos).UTC(),
+		ID:        ID(id.String()),
+	}, nil
+}
+
+// Passed returns true if the entity identified by "id" and last updated at
+// "updatedAt" is ordered at or before c, i.e. if it has already been returned
+// to the client holding c.
+func (c *Cursor) Passed(updatedAt time.Time, id ID) bool {
+	if c == nil {
+		return false
+	}
+	if !updatedAt.Equal(c.UpdatedAt) {
+		return updatedAt.Before(c.UpdatedAt)
+	}
+	return id <= c.ID
+}
@@ -43,6 +43,8 @@ func (s *Subscription) ToProto() (*dspb.Subscription, error) {
 		Callbacks:         &dssproto.SubscriptionCallbacks{IdentificationServiceAreaUrl: s.Url},
 		NotificationIndex: int32(s.NotificationIndex),
 		Version:           s.Version.String(),
+		AltitudeHi:        float32ToWrapper(s.AltitudeHi),
+		AltitudeLo:        float32ToWrapper(s.AltitudeLo),
 	}
 
 	if s.StartTime != nil {
@@ -63,7 +65,10 @@ func (s *Subscription) ToProto() (*dspb.Subscription, error) {
 	return result, nil
 }
 
-func (s *Subscription) SetExtents(extents *dspb.Volume4D) error {
+// SetExtents sets the time window, altitudes and cells of s from "extents",
+// mapping the footprint to cells with "coverer". Footprints larger than
+// "maxAreaKm2" square kilometers are rejected.
+func (s *Subscription) SetExtents(extents *dspb.Volume4D, coverer *geo.Coverer, maxAreaKm2 float64) error {
 	var err error
 	if extents == nil {
 		return nil
@@ -94,11 +99,13 @@ func (s *Subscription) SetExtents(extents *dspb.Volume4D) error {
 	if wrapper := space.GetAltitudeLo(); wrapper != nil {
 		s.AltitudeLo = ptrToFloat32(wrapper.GetValue())
 	}
-	footprint := space.GetFootprint()
-	if footprint == nil {
+	if s.AltitudeLo != nil && s.AltitudeHi != nil && *s.AltitudeLo > *s.AltitudeHi {
+		return errAltitudeLoAboveHi
+	}
+	if !geo.HasFootprint(space) {
 		return nil
 	}
-	s.Cells, err = geo.GeoPolygonToCellIDs(footprint)
+	s.Cells, err = coverer.Volume3DToCellIDs(space, maxAreaKm2)
 
 	return err
 }
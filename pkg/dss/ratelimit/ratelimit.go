@@ -0,0 +1,253 @@
+// Package ratelimit limits the rate of calls per owner and RPC.
+//
+// Every owner gets a token bucket per RPC. Calls exceeding the limit of their
+// bucket fail with ResourceExhausted, and the duration after which a retry
+// may succeed is attached as RetryAfterKey header metadata. Buckets are local
+// to a backend replica, so the rates admitted by a deployment scale with the
+// number of replicas.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/auth"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RetryAfterKey is the metadata key carrying the number of seconds after
+// which a rate limited call may be retried.
+const RetryAfterKey = "retry-after"
+
+var (
+	errInvalidRate  = errors.New("rate must be positive")
+	errInvalidBurst = errors.New("burst must be positive")
+)
+
+// Limit is the limit of a token bucket.
+type Limit struct {
+	// Rate is the number of calls per second refilling the bucket.
+	Rate float64 `json:"rate"`
+	// Burst is the size of the bucket, i.e. the number of calls admitted at
+	// once after a period of inactivity.
+	Burst int `json:"burst"`
+}
+
+// Validate returns an error if l is not a valid limit.
+func (l Limit) Validate() error {
+	switch {
+	case !(l.Rate > 0):
+		return errInvalidRate
+	case l.Burst < 1:
+		return errInvalidBurst
+	}
+	return nil
+}
+
+// Limits assigns limits to RPCs.
+type Limits struct {
+	// Default applies to all RPCs not listed in Methods. Calls to these RPCs
+	// are not limited if Default is nil.
+	Default *Limit `json:"default,omitempty"`
+	// Methods maps RPC names, e.g. "SearchIdentificationServiceAreas", to
+	// their limits.
+	Methods map[string]Limit `json:"methods,omitempty"`
+}
+
+// limit returns the limit of "method", if any.
+func (l Limits) limit(method string) (Limit, bool) {
+	if limit, ok := l.Methods[method]; ok {
+		return limit, true
+	}
+	if l.Default != nil {
+		return *l.Default, true
+	}
+	return Limit{}, false
+}
+
+func (l Limits) validate() error {
+	if l.Default != nil {
+		if err := l.Default.Validate(); err != nil {
+			return fmt.Errorf("default: %v", err)
+		}
+	}
+	for method, limit := range l.Methods {
+		if err := limit.Validate(); err != nil {
+			return fmt.Errorf("%s: %v", method, err)
+		}
+	}
+	return nil
+}
+
+// Config configures a Limiter.
+type Config struct {
+	Limits
+	// Owners overrides Limits for individual owners. The limits of an owner
+	// apply to all RPCs if they have a Default, and fall back to Limits for
+	// RPCs they do not list otherwise.
+	Owners map[models.Owner]Limits `json:"owners,omitempty"`
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c Config) Validate() error {
+	if err := c.Limits.validate(); err != nil {
+		return err
+	}
+	for owner, limits := range c.Owners {
+		if err := limits.validate(); err != nil {
+			return fmt.Errorf("owner %s: %v", owner, err)
+		}
+	}
+	return nil
+}
+
+// limit returns the limit of calls to "method" by "owner", if any.
+func (c Config) limit(owner models.Owner, method string) (Limit, bool) {
+	if limits, ok := c.Owners[owner]; ok {
+		if limit, ok := limits.limit(method); ok {
+			return limit, true
+		}
+	}
+	return c.Limits.limit(method)
+}
+
+// LoadConfig reads a Config from the JSON document in the file at "path".
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse rate limits in %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// bucket is a token bucket.
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token from b at "now". Returns 0 if a token was available and
+// the duration until the next token becomes available otherwise.
+func (b *bucket) take(now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed.Seconds()*b.limit.Rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / b.limit.Rate * float64(time.Second)))
+}
+
+type bucketKey struct {
+	owner  models.Owner
+	method string
+}
+
+// Limiter limits the rate of calls per owner and RPC. The owner is taken from
+// the context of a call, so the interceptors of a Limiter have to run after
+// the ones authenticating calls. A Limiter is safe for concurrent use.
+type Limiter struct {
+	config Config
+	logger *zap.Logger
+	clock  func() time.Time
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+// New returns a new Limiter enforcing the limits in "config".
+func New(config Config, logger *zap.Logger) (*Limiter, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Limiter{
+		config:  config,
+		logger:  logger,
+		clock:   time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}, nil
+}
+
+// allow takes a token from the bucket of "owner" and "method". Returns 0 if
+// the call is admitted and the duration after which it may be retried
+// otherwise.
+func (l *Limiter) allow(owner models.Owner, method string) time.Duration {
+	limit, ok := l.config.limit(owner, method)
+	if !ok {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock()
+	key := bucketKey{owner: owner, method: method}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	return b.take(now)
+}
+
+// check admits or rejects a call to "fullMethod" in "ctx". For rejected
+// calls, it returns the error and the metadata to send to the client.
+func (l *Limiter) check(ctx context.Context, fullMethod string) (metadata.MD, error) {
+	var (
+		owner, _ = auth.OwnerFromContext(ctx)
+		parts    = strings.Split(fullMethod, "/")
+		method   = parts[len(parts)-1]
+	)
+	retryAfter := l.allow(owner, method)
+	if retryAfter == 0 {
+		return nil, nil
+	}
+	l.logger.Debug("Rate limited call", zap.String("owner", owner.String()), zap.String("method", method), zap.Duration("retry_after", retryAfter))
+	// Retry-After is given in whole seconds.
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	md := metadata.Pairs(RetryAfterKey, strconv.FormatInt(seconds, 10))
+	return md, dsserr.Exhausted(fmt.Sprintf("rate limit of %s exceeded, retry after %ds", method, seconds))
+}
+
+// Interceptor rejects unary calls exceeding their limit.
+func (l *Limiter) Interceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, err := l.check(ctx, info.FullMethod)
+	if err != nil {
+		if err := grpc.SetHeader(ctx, md); err != nil {
+			l.logger.Warn("Failed to set retry-after header", zap.Error(err))
+		}
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor rejects streaming calls exceeding their limit, counting
+// the call rather than individual messages.
+func (l *Limiter) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, err := l.check(ss.Context(), info.FullMethod)
+	if err != nil {
+		if err := ss.SetHeader(md); err != nil {
+			l.logger.Warn("Failed to set retry-after header", zap.Error(err))
+		}
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,216 @@
+package ratelimit
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/steeling/InterUSS-Platform/pkg/dss/auth"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const searchMethod = "/dssproto.DSService/SearchIdentificationServiceAreas"
+
+// recordingTransportStream records the header metadata set by interceptors.
+type recordingTransportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *recordingTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+// fakeServerStream is a grpc.ServerStream carrying a fixed context and
+// recording header metadata.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func newTestLimiter(t *testing.T, config Config) (*Limiter, *time.Time) {
+	l, err := New(config, zap.NewNop())
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	l.clock = func() time.Time {
+		return now
+	}
+	return l, &now
+}
+
+func TestBucket(t *testing.T) {
+	var (
+		now = time.Unix(1000, 0)
+		b   = &bucket{limit: Limit{Rate: 2, Burst: 2}, tokens: 2, last: now}
+	)
+	require.Equal(t, time.Duration(0), b.take(now))
+	require.Equal(t, time.Duration(0), b.take(now))
+	require.Equal(t, 500*time.Millisecond, b.take(now))
+
+	now = now.Add(250 * time.Millisecond)
+	require.Equal(t, 250*time.Millisecond, b.take(now))
+	now = now.Add(250 * time.Millisecond)
+	require.Equal(t, time.Duration(0), b.take(now))
+
+	// Buckets never hold more than Burst tokens.
+	now = now.Add(time.Hour)
+	require.Equal(t, time.Duration(0), b.take(now))
+	require.Equal(t, time.Duration(0), b.take(now))
+	require.NotEqual(t, time.Duration(0), b.take(now))
+}
+
+func TestLimitsAreResolvedPerOwnerAndMethod(t *testing.T) {
+	l, _ := newTestLimiter(t, Config{
+		Limits: Limits{
+			Default: &Limit{Rate: 1, Burst: 1},
+			Methods: map[string]Limit{"SearchIdentificationServiceAreas": {Rate: 1, Burst: 3}},
+		},
+		Owners: map[models.Owner]Limits{
+			"uss1": {Methods: map[string]Limit{"SearchIdentificationServiceAreas": {Rate: 1, Burst: 5}}},
+			"uss2": {Default: &Limit{Rate: 1, Burst: 2}},
+		},
+	})
+
+	admitted := func(owner models.Owner, method string) int {
+		n := 0
+		for l.allow(owner, method) == 0 {
+			n++
+		}
+		return n
+	}
+	require.Equal(t, 3, admitted("uss0", "SearchIdentificationServiceAreas"))
+	require.Equal(t, 1, admitted("uss0", "GetSubscription"))
+	require.Equal(t, 5, admitted("uss1", "SearchIdentificationServiceAreas"))
+	require.Equal(t, 1, admitted("uss1", "GetSubscription"))
+	// Owner defaults take precedence over method limits of all owners.
+	require.Equal(t, 2, admitted("uss2", "SearchIdentificationServiceAreas"))
+	require.Equal(t, 2, admitted("uss2", "GetSubscription"))
+}
+
+func TestUnlimitedWithoutDefault(t *testing.T) {
+	l, _ := newTestLimiter(t, Config{Limits: Limits{
+		Methods: map[string]Limit{"SearchIdentificationServiceAreas": {Rate: 1, Burst: 1}},
+	}})
+	for i := 0; i < 100; i++ {
+		require.Equal(t, time.Duration(0), l.allow("uss1", "GetSubscription"))
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	l, now := newTestLimiter(t, Config{Limits: Limits{Default: &Limit{Rate: 0.25, Burst: 1}}})
+
+	var (
+		calls   int
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls++
+			return nil, nil
+		}
+		info = &grpc.UnaryServerInfo{FullMethod: searchMethod}
+		call = func(owner models.Owner) (*recordingTransportStream, error) {
+			stream := &recordingTransportStream{}
+			ctx := grpc.NewContextWithServerTransportStream(auth.ContextWithOwner(context.Background(), owner), stream)
+			_, err := l.Interceptor(ctx, nil, info, handler)
+			return stream, err
+		}
+	)
+
+	_, err := call("uss1")
+	require.NoError(t, err)
+
+	stream, err := call("uss1")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, "rate limit of SearchIdentificationServiceAreas exceeded, retry after 4s", status.Convert(err).Message())
+	require.Equal(t, []string{"4"}, stream.header.Get(RetryAfterKey))
+
+	// Owners have separate buckets.
+	_, err = call("uss2")
+	require.NoError(t, err)
+
+	*now = now.Add(time.Second)
+	stream, err = call("uss1")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, []string{"3"}, stream.header.Get(RetryAfterKey))
+
+	*now = now.Add(3 * time.Second)
+	_, err = call("uss1")
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	l, _ := newTestLimiter(t, Config{Limits: Limits{Default: &Limit{Rate: 1, Burst: 1}}})
+
+	var (
+		calls   int
+		handler = func(srv interface{}, ss grpc.ServerStream) error {
+			calls++
+			return nil
+		}
+		info = &grpc.StreamServerInfo{FullMethod: "/dssproto.DSService/WatchSubscriptionEvents"}
+		ctx  = auth.ContextWithOwner(context.Background(), "uss1")
+	)
+
+	require.NoError(t, l.StreamInterceptor(nil, &fakeServerStream{ctx: ctx}, info, handler))
+	ss := &fakeServerStream{ctx: ctx}
+	err := l.StreamInterceptor(nil, ss, info, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, []string{"1"}, ss.header.Get(RetryAfterKey))
+	require.Equal(t, 1, calls)
+}
+
+func TestConfigValidation(t *testing.T) {
+	require.NoError(t, Config{}.Validate())
+	require.Error(t, Config{Limits: Limits{Default: &Limit{Rate: 0, Burst: 1}}}.Validate())
+	require.Error(t, Config{Limits: Limits{Methods: map[string]Limit{"GetSubscription": {Rate: 1, Burst: 0}}}}.Validate())
+	require.Error(t, Config{Owners: map[models.Owner]Limits{"uss1": {Default: &Limit{Rate: -1, Burst: 1}}}}.Validate())
+
+	_, err := New(Config{Limits: Limits{Default: &Limit{}}}, zap.NewNop())
+	require.Error(t, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "rate_limits.json")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString(`{
+		"default": {"rate": 10, "burst": 20},
+		"methods": {"SearchIdentificationServiceAreas": {"rate": 1, "burst": 5}},
+		"owners": {"uss1": {"methods": {"SearchIdentificationServiceAreas": {"rate": 2, "burst": 10}}}}
+	}`)
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+
+	config, err := LoadConfig(tmpfile.Name())
+	require.NoError(t, err)
+	require.Equal(t, Config{
+		Limits: Limits{
+			Default: &Limit{Rate: 10, Burst: 20},
+			Methods: map[string]Limit{"SearchIdentificationServiceAreas": {Rate: 1, Burst: 5}},
+		},
+		Owners: map[models.Owner]Limits{
+			"uss1": {Methods: map[string]Limit{"SearchIdentificationServiceAreas": {Rate: 2, Burst: 10}}},
+		},
+	}, config)
+
+	_, err = LoadConfig(os.DevNull)
+	require.Error(t, err)
+}
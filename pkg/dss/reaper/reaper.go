@@ -0,0 +1,179 @@
+// Package reaper implements the background garbage collection of expired
+// IdentificationServiceAreas and subscriptions.
+package reaper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRetention is the default duration for which expired records are
+	// kept before they are reaped.
+	DefaultRetention = 24 * time.Hour
+	// DefaultBatchSize is the default maximum number of records deleted per
+	// store call.
+	DefaultBatchSize = 1000
+	// DefaultInterval is the default duration between two reaper runs.
+	DefaultInterval = 5 * time.Minute
+)
+
+var (
+	errInvalidRetention = errors.New("retention must not be negative")
+	errInvalidBatchSize = errors.New("batch size must be positive")
+	errInvalidInterval  = errors.New("interval must be positive")
+)
+
+// Config configures a Reaper.
+type Config struct {
+	// Retention is the duration for which records are kept after they ended.
+	Retention time.Duration
+	// BatchSize is the maximum number of records deleted per store call.
+	BatchSize int
+	// Interval is the duration between two runs.
+	Interval time.Duration
+}
+
+// Stats counts the records removed by a Reaper.
+type Stats struct {
+	ISAs          int64
+	Subscriptions int64
+}
+
+// Reaper periodically deletes expired IdentificationServiceAreas and
+// subscriptions from a dss.Store.
+//
+// Multiple Reaper instances, e.g. one per backend replica, can safely operate
+// on the same store: every batch is a single, atomic delete, and records that
+// were already removed by another instance are simply not counted.
+type Reaper struct {
+	store  dss.Store
+	config Config
+	logger *zap.Logger
+	clock  func() time.Time
+
+	isas          atomic.Int64
+	subscriptions atomic.Int64
+}
+
+// New returns a new Reaper instance deleting expired records from "store"
+// according to "config".
+func New(store dss.Store, config Config, logger *zap.Logger) (*Reaper, error) {
+	switch {
+	case config.Retention < 0:
+		return nil, errInvalidRetention
+	case config.BatchSize <= 0:
+		return nil, errInvalidBatchSize
+	case config.Interval <= 0:
+		return nil, errInvalidInterval
+	}
+	return &Reaper{
+		store:  store,
+		config: config,
+		logger: logger,
+		clock:  time.Now,
+	}, nil
+}
+
+// Stats returns the number of records removed since r was created.
+func (r *Reaper) Stats() Stats {
+	return Stats{
+		ISAs:          r.isas.Load(),
+		Subscriptions: r.subscriptions.Load(),
+	}
+}
+
+// Register registers counters of the records removed by r with
+// "registerer", exporting Stats.
+func (r *Reaper) Register(registerer prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "dss",
+			Subsystem: "reaper",
+			Name:      "deleted_identification_service_areas_total",
+			Help:      "Number of expired ISAs deleted by the garbage collection.",
+		}, func() float64 { return float64(r.Stats().ISAs) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "dss",
+			Subsystem: "reaper",
+			Name:      "deleted_subscriptions_total",
+			Help:      "Number of expired subscriptions deleted by the garbage collection.",
+		}, func() float64 { return float64(r.Stats().Subscriptions) }),
+	} {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run reaps expired records every r.config.Interval until "ctx" is done.
+// Failed runs are logged and retried on the next tick.
+func (r *Reaper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		stats, err := r.ReapOnce(ctx)
+		switch {
+		case err == context.Canceled || err == context.DeadlineExceeded:
+			return ctx.Err()
+		case err != nil:
+			r.logger.Error("Failed to reap expired records", zap.Error(err))
+		case stats.ISAs > 0 || stats.Subscriptions > 0:
+			r.logger.Info("Reaped expired records",
+				zap.Int64("identification_service_areas", stats.ISAs),
+				zap.Int64("subscriptions", stats.Subscriptions))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReapOnce deletes all records that ended before now minus the configured
+// retention period, in batches of at most r.config.BatchSize records. It
+// returns the number of records deleted by this call.
+func (r *Reaper) ReapOnce(ctx context.Context) (Stats, error) {
+	var (
+		stats     Stats
+		threshold = r.clock().Add(-r.config.Retention)
+		err       error
+	)
+
+	stats.ISAs, err = r.reap(ctx, threshold, r.store.DeleteExpiredISAs, &r.isas)
+	if err != nil {
+		return stats, err
+	}
+	stats.Subscriptions, err = r.reap(ctx, threshold, r.store.DeleteExpiredSubscriptions, &r.subscriptions)
+	return stats, err
+}
+
+// reap calls "deleteExpired" until a batch comes back incomplete, adding the
+// number of deleted records to "total".
+func (r *Reaper) reap(ctx context.Context, threshold time.Time, deleteExpired func(context.Context, time.Time, int) (int64, error), total *atomic.Int64) (int64, error) {
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		n, err := deleteExpired(ctx, threshold, r.config.BatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+		total.Add(n)
+		if n < int64(r.config.BatchSize) {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/memstore"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	now    = time.Now().UTC()
+	config = Config{
+		Retention: time.Hour,
+		BatchSize: 2,
+		Interval:  time.Minute,
+	}
+)
+
+func setUpStore(ctx context.Context, t *testing.T, expired, active int) *memstore.Store {
+	store := memstore.New()
+	for i := 0; i < expired+active; i++ {
+		var (
+			startTime = now.Add(-3 * time.Hour)
+			endTime   = now.Add(-2 * time.Hour)
+		)
+		if i >= expired {
+			endTime = now.Add(time.Hour)
+		}
+
+		_, _, err := store.InsertISA(ctx, &models.IdentificationServiceArea{
+			ID:        models.ID(fmt.Sprintf("isa-%d", i)),
+			Owner:     "me-myself-and-i",
+			Url:       "https://no/place/like/home",
+			StartTime: &startTime,
+			EndTime:   &endTime,
+			Cells:     s2.CellUnion{s2.CellID(42)},
+		}, nil)
+		require.NoError(t, err)
+
+		_, err = store.InsertSubscription(ctx, &models.Subscription{
+			ID:        models.ID(fmt.Sprintf("subscription-%d", i)),
+			Owner:     "me-myself-and-i",
+			Url:       "https://no/place/like/home",
+			StartTime: &startTime,
+			EndTime:   &endTime,
+			Cells:     s2.CellUnion{s2.CellID(42)},
+		}, 0)
+		require.NoError(t, err)
+	}
+	return store
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	for _, r := range []struct {
+		name   string
+		config Config
+		err    error
+	}{
+		{
+			name:   "valid",
+			config: config,
+		},
+		{
+			name:   "negative-retention",
+			config: Config{Retention: -time.Hour, BatchSize: 1, Interval: time.Minute},
+			err:    errInvalidRetention,
+		},
+		{
+			name:   "zero-batch-size",
+			config: Config{Retention: time.Hour, Interval: time.Minute},
+			err:    errInvalidBatchSize,
+		},
+		{
+			name:   "zero-interval",
+			config: Config{Retention: time.Hour, BatchSize: 1},
+			err:    errInvalidInterval,
+		},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			_, err := New(memstore.New(), r.config, zap.NewNop())
+			require.Equal(t, r.err, err)
+		})
+	}
+}
+
+func TestReapOnceDeletesExpiredRecordsInBatches(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = setUpStore(ctx, t, 5, 3)
+	)
+	r, err := New(store, config, zap.NewNop())
+	require.NoError(t, err)
+	r.clock = func() time.Time { return now }
+
+	stats, err := r.ReapOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Stats{ISAs: 5, Subscriptions: 5}, stats)
+
+	isas, err := store.SearchISAs(ctx, s2.CellUnion{s2.CellID(42)}, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, isas, 3)
+
+	subscriptions, err := store.SearchSubscriptions(ctx, s2.CellUnion{s2.CellID(42)}, "me-myself-and-i", nil)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 3)
+
+	stats, err = r.ReapOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Stats{}, stats)
+	require.Equal(t, Stats{ISAs: 5, Subscriptions: 5}, r.Stats())
+}
+
+func TestRegisterExportsStats(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		store    = setUpStore(ctx, t, 4, 1)
+		registry = prometheus.NewRegistry()
+	)
+	r, err := New(store, config, zap.NewNop())
+	require.NoError(t, err)
+	r.clock = func() time.Time { return now }
+	require.NoError(t, r.Register(registry))
+
+	_, err = r.ReapOnce(ctx)
+	require.NoError(t, err)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	counters := map[string]float64{}
+	for _, family := range families {
+		counters[family.GetName()] = family.GetMetric()[0].GetCounter().GetValue()
+	}
+	require.Equal(t, map[string]float64{
+		"dss_reaper_deleted_identification_service_areas_total": 4,
+		"dss_reaper_deleted_subscriptions_total":                4,
+	}, counters)
+}
+
+func TestReapOnceHonorsRetention(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = setUpStore(ctx, t, 2, 0)
+	)
+	r, err := New(store, Config{Retention: 3 * time.Hour, BatchSize: 10, Interval: time.Minute}, zap.NewNop())
+	require.NoError(t, err)
+	r.clock = func() time.Time { return now }
+
+	stats, err := r.ReapOnce(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Stats{}, stats)
+}
+
+func TestConcurrentReapersDeleteEachRecordOnce(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		store   = setUpStore(ctx, t, 20, 0)
+		reapers = make([]*Reaper, 4)
+		results = make(chan error, len(reapers))
+	)
+	for i := range reapers {
+		r, err := New(store, config, zap.NewNop())
+		require.NoError(t, err)
+		r.clock = func() time.Time { return now }
+		reapers[i] = r
+	}
+
+	for _, r := range reapers {
+		go func(r *Reaper) {
+			_, err := r.ReapOnce(ctx)
+			results <- err
+		}(r)
+	}
+	for range reapers {
+		require.NoError(t, <-results)
+	}
+
+	var total Stats
+	for _, r := range reapers {
+		total.ISAs += r.Stats().ISAs
+		total.Subscriptions += r.Stats().Subscriptions
+	}
+	require.Equal(t, Stats{ISAs: 20, Subscriptions: 20}, total)
+}
+
+func TestRunStopsWhenContextIsDone(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		store       = setUpStore(ctx, t, 3, 0)
+		done        = make(chan error)
+	)
+	r, err := New(store, config, zap.NewNop())
+	require.NoError(t, err)
+	r.clock = func() time.Time { return now }
+
+	go func() {
+		done <- r.Run(ctx)
+	}()
+
+	for r.Stats().Subscriptions < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+	require.Equal(t, Stats{ISAs: 3, Subscriptions: 3}, r.Stats())
+}
@@ -0,0 +1,143 @@
+// Package reindex rebuilds the cells indexing IdentificationServiceAreas and
+// subscriptions after the covering configuration of a deployment changed.
+//
+// Stores match cells across levels (see geo.Ancestors), so searches stay
+// correct while entities indexed with the old and the new configuration
+// coexist, and re-indexing can run against a live database. Entities
+// written before cell ancestors were indexed have to be re-indexed before
+// switching to coarser levels, though.
+package reindex
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultBatchSize is the default number of entities listed per store call.
+const DefaultBatchSize = 100
+
+var (
+	errInvalidBatchSize = errors.New("batch size must be positive")
+)
+
+// Store is implemented by stores whose cell index can be rebuilt.
+type Store interface {
+	// ListISAs returns at most "limit" IdentificationServiceAreas with IDs
+	// greater than "after", ordered by ID and including their cells.
+	ListISAs(ctx context.Context, after models.ID, limit int) ([]*models.IdentificationServiceArea, error)
+
+	// UpdateISACells replaces the cells of the IdentificationServiceArea
+	// identified by "id" with "cells", without changing its version. Fails
+	// with NotFound if the IdentificationServiceArea does not exist anymore,
+	// and with Aborted if it is not at "version" anymore.
+	UpdateISACells(ctx context.Context, id models.ID, version *models.Version, cells s2.CellUnion) error
+
+	// ListSubscriptions returns at most "limit" subscriptions with IDs
+	// greater than "after", ordered by ID and including their cells.
+	ListSubscriptions(ctx context.Context, after models.ID, limit int) ([]*models.Subscription, error)
+
+	// UpdateSubscriptionCells replaces the cells of the subscription
+	// identified by "id" with "cells", without changing its version. Fails
+	// with NotFound if the subscription does not exist anymore, and with
+	// Aborted if it is not at "version" anymore.
+	UpdateSubscriptionCells(ctx context.Context, id models.ID, version *models.Version, cells s2.CellUnion) error
+}
+
+// Stats counts the entities handled by a Reindexer.
+type Stats struct {
+	ISAs          int64
+	Subscriptions int64
+	// Skipped counts entities that were modified or deleted concurrently.
+	// Their cells have been written by the concurrent operation.
+	Skipped int64
+}
+
+// Reindexer covers the cells of all entities in a Store with a geo.Coverer
+// and replaces the cells by the resulting covering.
+type Reindexer struct {
+	store     Store
+	coverer   *geo.Coverer
+	batchSize int
+	logger    *zap.Logger
+}
+
+// New returns a new Reindexer re-indexing the entities in "store" with
+// "coverer", listing "batchSize" entities at a time.
+func New(store Store, coverer *geo.Coverer, batchSize int, logger *zap.Logger) (*Reindexer, error) {
+	if batchSize <= 0 {
+		return nil, errInvalidBatchSize
+	}
+	return &Reindexer{
+		store:     store,
+		coverer:   coverer,
+		batchSize: batchSize,
+		logger:    logger,
+	}, nil
+}
+
+// Run re-indexes all entities in the store. Every entity is updated in its
+// own transaction, and entities that are modified concurrently are skipped.
+// Run can safely be repeated, e.g. after it failed.
+func (r *Reindexer) Run(ctx context.Context) (Stats, error) {
+	var stats Stats
+
+	var after models.ID
+	for {
+		isas, err := r.store.ListISAs(ctx, after, r.batchSize)
+		if err != nil {
+			return stats, err
+		}
+		for _, isa := range isas {
+			err := r.store.UpdateISACells(ctx, isa.ID, isa.Version, r.coverer.CoverCells(isa.Cells))
+			if err := r.count(&stats.ISAs, &stats.Skipped, isa.ID, err); err != nil {
+				return stats, err
+			}
+			after = isa.ID
+		}
+		if len(isas) < r.batchSize {
+			break
+		}
+	}
+
+	after = ""
+	for {
+		subscriptions, err := r.store.ListSubscriptions(ctx, after, r.batchSize)
+		if err != nil {
+			return stats, err
+		}
+		for _, sub := range subscriptions {
+			err := r.store.UpdateSubscriptionCells(ctx, sub.ID, sub.Version, r.coverer.CoverCells(sub.Cells))
+			if err := r.count(&stats.Subscriptions, &stats.Skipped, sub.ID, err); err != nil {
+				return stats, err
+			}
+			after = sub.ID
+		}
+		if len(subscriptions) < r.batchSize {
+			break
+		}
+	}
+
+	return stats, nil
+}
+
+// count records the outcome "err" of updating the cells of the entity
+// identified by "id", returning "err" if re-indexing has to be aborted.
+func (r *Reindexer) count(updated *int64, skipped *int64, id models.ID, err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		*updated++
+		return nil
+	case codes.NotFound, codes.Aborted:
+		r.logger.Info("Skipping concurrently modified entity", zap.String("id", id.String()), zap.Error(err))
+		*skipped++
+		return nil
+	}
+	return err
+}
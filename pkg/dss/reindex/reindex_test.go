@@ -0,0 +1,87 @@
+package reindex
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/google/uuid"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/memstore"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	leaf = s2.CellIDFromLatLng(s2.LatLngFromDegrees(37.427636, -122.170502))
+)
+
+func TestNewRejectsInvalidBatchSize(t *testing.T) {
+	_, err := New(memstore.New(), geo.DefaultCoverer(), 0, zap.NewNop())
+	require.Equal(t, errInvalidBatchSize, err)
+}
+
+func TestRunCoarsensCells(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		store   = memstore.New()
+		endTime = time.Now().Add(time.Hour)
+		isas    []*models.IdentificationServiceArea
+		subs    []*models.Subscription
+	)
+	defer func() {
+		require.NoError(t, store.Close())
+	}()
+
+	for i := 0; i < 5; i++ {
+		isa, _, err := store.InsertISA(ctx, &models.IdentificationServiceArea{
+			ID:      models.ID(uuid.New().String()),
+			Owner:   "me",
+			Url:     "https://no/place/like/home",
+			EndTime: &endTime,
+			Cells:   s2.CellUnion{leaf.Parent(13)},
+		}, nil)
+		require.NoError(t, err)
+		isas = append(isas, isa)
+	}
+	for i := 0; i < 3; i++ {
+		sub, err := store.InsertSubscription(ctx, &models.Subscription{
+			ID:      models.ID(uuid.New().String()),
+			Owner:   "you",
+			Url:     "https://no/place/like/home",
+			EndTime: &endTime,
+			Cells:   s2.CellUnion{leaf.Parent(13)},
+		}, 0)
+		require.NoError(t, err)
+		subs = append(subs, sub)
+	}
+
+	coverer, err := geo.NewCoverer(geo.CoveringConfig{MinLevel: 10, MaxLevel: 10, MaxCells: 8, LevelMod: 1})
+	require.NoError(t, err)
+	reindexer, err := New(store, coverer, 2, zap.NewNop())
+	require.NoError(t, err)
+
+	stats, err := reindexer.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Stats{ISAs: 5, Subscriptions: 3}, stats)
+
+	for _, isa := range isas {
+		reindexed, err := store.GetISA(ctx, isa.ID)
+		require.NoError(t, err)
+		require.Equal(t, s2.CellUnion{leaf.Parent(10)}, reindexed.Cells)
+		require.True(t, isa.Version.Matches(reindexed.Version))
+	}
+	for _, sub := range subs {
+		reindexed, err := store.GetSubscription(ctx, sub.ID)
+		require.NoError(t, err)
+		require.Equal(t, s2.CellUnion{leaf.Parent(10)}, reindexed.Cells)
+		require.True(t, sub.Version.Matches(reindexed.Version))
+	}
+
+	// Re-indexing is idempotent.
+	stats, err = reindexer.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Stats{ISAs: 5, Subscriptions: 3}, stats)
+}
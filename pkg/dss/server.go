@@ -7,12 +7,21 @@ import (
 
 	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 
 	"github.com/steeling/InterUSS-Platform/pkg/dss/auth"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/dispatcher"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/events"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/validations"
 	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
 	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -20,9 +29,119 @@ var (
 	ReadISAScope  = "dss.read.identification_service_areas"
 )
 
+// instrumentationName names the tracer of this package.
+const instrumentationName = "github.com/steeling/InterUSS-Platform/pkg/dss"
+
+// DefaultMaxPageSize is the maximum number of results returned by a single
+// search if Server.MaxPageSize is not set.
+const DefaultMaxPageSize = 100
+
+// DefaultMaxSubscriptionsPerArea is the maximum number of subscriptions an
+// owner may hold in an area if Server.MaxSubscriptionsPerArea is not set.
+const DefaultMaxSubscriptionsPerArea = 10
+
 // Server implements dssproto.DiscoveryAndSynchronizationService.
 type Server struct {
 	Store Store
+	// Events receives all changes to IdentificationServiceAreas committed by
+	// s and feeds WatchSubscriptionEvents. Changes committed by other servers
+	// sharing Store are not received. Streaming is unavailable if Events is
+	// nil.
+	Events *events.Bus
+	// Dispatcher delivers notifications to subscriber callbacks on behalf of
+	// writing clients. Delivery by the DSS is disabled if Dispatcher is nil.
+	Dispatcher *dispatcher.Dispatcher
+	// MaxPageSize caps the number of results returned by a single search.
+	// Requests asking for more results, or not specifying a page size at all,
+	// receive at most MaxPageSize results. DefaultMaxPageSize applies if
+	// MaxPageSize is not positive.
+	MaxPageSize int
+	// Coverer maps areas to the cells indexing them. geo.DefaultCoverer()
+	// applies if Coverer is nil.
+	Coverer *geo.Coverer
+	// MaxSearchAreaKm2, MaxISAAreaKm2 and MaxSubscriptionAreaKm2 bound the
+	// areas of searches, ISA footprints and subscription footprints in square
+	// kilometers, respectively. geo.DefaultMaxAreaKm2 applies to every bound
+	// that is not positive.
+	MaxSearchAreaKm2       float64
+	MaxISAAreaKm2          float64
+	MaxSubscriptionAreaKm2 float64
+	// MaxSubscriptionsPerArea bounds the number of active subscriptions an
+	// owner may hold intersecting any single cell. Writes exceeding the bound
+	// fail with ResourceExhausted. DefaultMaxSubscriptionsPerArea applies if
+	// MaxSubscriptionsPerArea is not positive.
+	MaxSubscriptionsPerArea int
+	// ISATimePolicy and SubscriptionTimePolicy bound the time windows of
+	// IdentificationServiceAreas and subscriptions, respectively. Fields that
+	// are not positive default to the corresponding fields of
+	// DefaultISATimePolicy and DefaultSubscriptionTimePolicy.
+	ISATimePolicy          TimePolicy
+	SubscriptionTimePolicy TimePolicy
+}
+
+// maxArea returns the area bound "configured", or the default bound if none
+// is configured.
+func maxArea(configured float64) float64 {
+	if configured <= 0 {
+		return geo.DefaultMaxAreaKm2
+	}
+	return configured
+}
+
+// maxSubscriptionsPerArea returns the maximum number of subscriptions an owner
+// may hold in an area.
+func (s *Server) maxSubscriptionsPerArea() int {
+	if s.MaxSubscriptionsPerArea <= 0 {
+		return DefaultMaxSubscriptionsPerArea
+	}
+	return s.MaxSubscriptionsPerArea
+}
+
+// coverer returns the geo.Coverer to use for mapping areas to cells.
+func (s *Server) coverer() *geo.Coverer {
+	if s.Coverer == nil {
+		return geo.DefaultCoverer()
+	}
+	return s.Coverer
+}
+
+// badExtents returns the error to report to clients if setting extents failed
+// with "err". BadRequest errors, e.g. describing why a footprint is not a
+// valid polygon, are passed through, all other errors are masked.
+func badExtents(err error) error {
+	if status.Code(err) == codes.InvalidArgument {
+		return err
+	}
+	return dsserr.BadRequest("bad extents")
+}
+
+// pageQuery describes the search of "owner" with the parameters "req", which
+// must have its page_size and page_token cleared. Page tokens are bound to it,
+// so that they cannot be replayed against a different search.
+func pageQuery(owner models.Owner, req proto.Message) string {
+	return string(owner) + "/" + proto.CompactTextString(req)
+}
+
+// page returns the page of results requested by "pageSize" and "pageToken"
+// for the search described by "query". The returned page asks for one more
+// result than the client receives, which tells whether there is a next page.
+func (s *Server) page(pageSize int32, pageToken string, query string) (*models.Page, int, error) {
+	if pageSize < 0 {
+		return nil, 0, dsserr.BadRequest("page_size must not be negative")
+	}
+	after, err := models.CursorFromToken(pageToken, query)
+	if err != nil {
+		return nil, 0, dsserr.BadRequest("bad page_token")
+	}
+
+	size := s.MaxPageSize
+	if size <= 0 {
+		size = DefaultMaxPageSize
+	}
+	if pageSize > 0 && int(pageSize) < size {
+		size = int(pageSize)
+	}
+	return &models.Page{After: after, Limit: size + 1}, size, nil
 }
 
 func (s *Server) AuthScopes() map[string][]string {
@@ -34,10 +153,19 @@ func (s *Server) AuthScopes() map[string][]string {
 		"DeleteSubscription":               []string{ReadISAScope},
 		"SearchSubscriptions":              []string{ReadISAScope},
 		"SearchIdentificationServiceAreas": []string{ReadISAScope},
+		"WatchSubscriptionEvents":          []string{ReadISAScope},
 	}
 }
 
+// startSpan starts the span of the handler "name" in ctx.
+func startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, "dss.Server/"+name, trace.WithAttributes(attributes...))
+}
+
 func (s *Server) GetIdentificationServiceArea(ctx context.Context, req *dspb.GetIdentificationServiceAreaRequest) (*dspb.GetIdentificationServiceAreaResponse, error) {
+	ctx, span := startSpan(ctx, "GetIdentificationServiceArea", attribute.String("dss.id", req.GetId()))
+	defer span.End()
+
 	isa, err := s.Store.GetISA(ctx, models.ID(req.GetId()))
 	if err == sql.ErrNoRows {
 		return nil, dsserr.NotFound(req.GetId())
@@ -55,6 +183,9 @@ func (s *Server) GetIdentificationServiceArea(ctx context.Context, req *dspb.Get
 }
 
 func (s *Server) PutIdentificationServiceArea(ctx context.Context, req *dspb.PutIdentificationServiceAreaRequest) (*dspb.PutIdentificationServiceAreaResponse, error) {
+	ctx, span := startSpan(ctx, "PutIdentificationServiceArea", attribute.String("dss.id", req.GetId()))
+	defer span.End()
+
 	owner, ok := auth.OwnerFromContext(ctx)
 	if !ok {
 		return nil, dsserr.PermissionDenied("missing owner from context")
@@ -76,14 +207,28 @@ func (s *Server) PutIdentificationServiceArea(ctx context.Context, req *dspb.Put
 		Version: version,
 	}
 
-	if err := isa.SetExtents(params.GetExtents()); err != nil {
-		return nil, dsserr.BadRequest("bad extents")
+	if err := isa.SetExtents(params.GetExtents(), s.coverer(), maxArea(s.MaxISAAreaKm2)); err != nil {
+		return nil, badExtents(err)
+	}
+	span.SetAttributes(attribute.Int("dss.cells", len(isa.Cells)))
+	isa.StartTime, isa.EndTime, err = s.ISATimePolicy.withDefaults(DefaultISATimePolicy).apply(time.Now(), isa.StartTime, isa.EndTime)
+	if err != nil {
+		return nil, err
 	}
 
-	isa, subscribers, err := s.Store.InsertISA(ctx, isa)
+	// We can't tell creates from updates without a version as both are
+	// upserts, so we rely on clients passing the version for updates.
+	eventType := events.Updated
+	if version.Empty() {
+		eventType = events.Created
+	}
+
+	isa, subscribers, err := s.Store.InsertISA(ctx, isa, s.notifications(eventType))
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attribute.Int("dss.subscribers", len(subscribers)))
+	s.publish(events.Event{Type: eventType, ISA: isa, Subscriptions: subscribers})
 
 	pbISA, err := isa.ToProto()
 	if err != nil {
@@ -102,6 +247,9 @@ func (s *Server) PutIdentificationServiceArea(ctx context.Context, req *dspb.Put
 }
 
 func (s *Server) DeleteIdentificationServiceArea(ctx context.Context, req *dspb.DeleteIdentificationServiceAreaRequest) (*dspb.DeleteIdentificationServiceAreaResponse, error) {
+	ctx, span := startSpan(ctx, "DeleteIdentificationServiceArea", attribute.String("dss.id", req.GetId()))
+	defer span.End()
+
 	owner, ok := auth.OwnerFromContext(ctx)
 	if !ok {
 		return nil, dsserr.PermissionDenied("missing owner from context")
@@ -110,10 +258,12 @@ func (s *Server) DeleteIdentificationServiceArea(ctx context.Context, req *dspb.
 	if err != nil {
 		return nil, dsserr.BadRequest("bad version")
 	}
-	isa, subscribers, err := s.Store.DeleteISA(ctx, models.ID(req.GetId()), owner, version)
+	isa, subscribers, err := s.Store.DeleteISA(ctx, models.ID(req.GetId()), owner, version, s.notifications(events.Deleted))
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attribute.Int("dss.subscribers", len(subscribers)))
+	s.publish(events.Event{Type: events.Deleted, ISA: isa, Subscriptions: subscribers})
 
 	p, err := isa.ToProto()
 	if err != nil {
@@ -131,6 +281,9 @@ func (s *Server) DeleteIdentificationServiceArea(ctx context.Context, req *dspb.
 }
 
 func (s *Server) DeleteSubscription(ctx context.Context, req *dspb.DeleteSubscriptionRequest) (*dspb.DeleteSubscriptionResponse, error) {
+	ctx, span := startSpan(ctx, "DeleteSubscription", attribute.String("dss.id", req.GetId()))
+	defer span.End()
+
 	owner, ok := auth.OwnerFromContext(ctx)
 	if !ok {
 		return nil, dsserr.PermissionDenied("missing owner from context")
@@ -143,6 +296,9 @@ func (s *Server) DeleteSubscription(ctx context.Context, req *dspb.DeleteSubscri
 	if err != nil {
 		return nil, err
 	}
+	if s.Events != nil {
+		s.Events.CloseSubscription(subscription.ID)
+	}
 	p, err := subscription.ToProto()
 	if err != nil {
 		return nil, dsserr.Internal(err.Error())
@@ -153,10 +309,14 @@ func (s *Server) DeleteSubscription(ctx context.Context, req *dspb.DeleteSubscri
 }
 
 func (s *Server) SearchIdentificationServiceAreas(ctx context.Context, req *dspb.SearchIdentificationServiceAreasRequest) (*dspb.SearchIdentificationServiceAreasResponse, error) {
-	cu, err := geo.AreaToCellIDs(req.GetArea())
+	ctx, span := startSpan(ctx, "SearchIdentificationServiceAreas")
+	defer span.End()
+
+	cu, err := s.coverer().AreaToCellIDs(req.GetArea(), maxArea(s.MaxSearchAreaKm2))
 	if err != nil {
-		return nil, dsserr.BadRequest("bad area")
+		return nil, err
 	}
+	span.SetAttributes(attribute.Int("dss.cells", len(cu)))
 
 	var (
 		earliest *time.Time
@@ -179,11 +339,43 @@ func (s *Server) SearchIdentificationServiceAreas(ctx context.Context, req *dspb
 		}
 	}
 
-	isas, err := s.Store.SearchISAs(ctx, cu, earliest, latest)
+	// proto3 doesn't distinguish between unset and zero, so we treat zero
+	// altitudes as not specified.
+	var (
+		minAltitude *float32
+		maxAltitude *float32
+	)
+	if v := req.GetMinAltitude(); v != 0 {
+		f := float32(v)
+		minAltitude = &f
+	}
+	if v := req.GetMaxAltitude(); v != 0 {
+		f := float32(v)
+		maxAltitude = &f
+	}
+	if minAltitude != nil && maxAltitude != nil && *minAltitude > *maxAltitude {
+		return nil, dsserr.BadRequest("min_altitude must not be above max_altitude")
+	}
+
+	query := proto.Clone(req).(*dspb.SearchIdentificationServiceAreasRequest)
+	query.PageSize, query.PageToken = 0, ""
+	pq := pageQuery("", query)
+	page, size, err := s.page(req.GetPageSize(), req.GetPageToken(), pq)
 	if err != nil {
 		return nil, err
 	}
 
+	isas, err := s.Store.SearchISAs(ctx, cu, earliest, latest, minAltitude, maxAltitude, page)
+	if err != nil {
+		return nil, err
+	}
+	var nextPageToken string
+	if len(isas) > size {
+		isas = isas[:size]
+		last := isas[size-1]
+		nextPageToken = models.CursorAfter(last.ID, last.Version).Token(pq)
+	}
+
 	areas := make([]*dspb.IdentificationServiceArea, len(isas))
 	for i := range isas {
 		a, err := isas[i].ToProto()
@@ -194,25 +386,44 @@ func (s *Server) SearchIdentificationServiceAreas(ctx context.Context, req *dspb
 	}
 
 	return &dspb.SearchIdentificationServiceAreasResponse{
-		ServiceAreas: areas,
+		ServiceAreas:  areas,
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
 func (s *Server) SearchSubscriptions(ctx context.Context, req *dspb.SearchSubscriptionsRequest) (*dspb.SearchSubscriptionsResponse, error) {
+	ctx, span := startSpan(ctx, "SearchSubscriptions")
+	defer span.End()
+
 	owner, ok := auth.OwnerFromContext(ctx)
 	if !ok {
 		return nil, dsserr.PermissionDenied("missing owner from context")
 	}
 
-	cu, err := geo.AreaToCellIDs(req.GetArea())
+	cu, err := s.coverer().AreaToCellIDs(req.GetArea(), maxArea(s.MaxSearchAreaKm2))
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attribute.Int("dss.cells", len(cu)))
 
-	subscriptions, err := s.Store.SearchSubscriptions(ctx, cu, owner)
+	query := proto.Clone(req).(*dspb.SearchSubscriptionsRequest)
+	query.PageSize, query.PageToken = 0, ""
+	pq := pageQuery(owner, query)
+	page, size, err := s.page(req.GetPageSize(), req.GetPageToken(), pq)
 	if err != nil {
 		return nil, err
 	}
+
+	subscriptions, err := s.Store.SearchSubscriptions(ctx, cu, owner, page)
+	if err != nil {
+		return nil, err
+	}
+	var nextPageToken string
+	if len(subscriptions) > size {
+		subscriptions = subscriptions[:size]
+		last := subscriptions[size-1]
+		nextPageToken = models.CursorAfter(last.ID, last.Version).Token(pq)
+	}
 	sp := make([]*dspb.Subscription, len(subscriptions))
 	for i, _ := range subscriptions {
 		sp[i], err = subscriptions[i].ToProto()
@@ -223,10 +434,14 @@ func (s *Server) SearchSubscriptions(ctx context.Context, req *dspb.SearchSubscr
 
 	return &dspb.SearchSubscriptionsResponse{
 		Subscriptions: sp,
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
 func (s *Server) GetSubscription(ctx context.Context, req *dspb.GetSubscriptionRequest) (*dspb.GetSubscriptionResponse, error) {
+	ctx, span := startSpan(ctx, "GetSubscription", attribute.String("dss.id", req.GetId()))
+	defer span.End()
+
 	subscription, err := s.Store.GetSubscription(ctx, models.ID(req.GetId()))
 	if err == sql.ErrNoRows {
 		return nil, dsserr.NotFound(req.GetId())
@@ -245,6 +460,9 @@ func (s *Server) GetSubscription(ctx context.Context, req *dspb.GetSubscriptionR
 
 // TODO(steeling) openapi 2 spec requires only 1 parameter in the body
 func (s *Server) PutSubscription(ctx context.Context, req *dspb.PutSubscriptionRequest) (*dspb.PutSubscriptionResponse, error) {
+	ctx, span := startSpan(ctx, "PutSubscription", attribute.String("dss.id", req.GetId()))
+	defer span.End()
+
 	owner, ok := auth.OwnerFromContext(ctx)
 	if !ok {
 		return nil, dsserr.PermissionDenied("missing owner from context")
@@ -271,13 +489,18 @@ func (s *Server) PutSubscription(ctx context.Context, req *dspb.PutSubscriptionR
 		Version: version,
 	}
 
-	sub, err = s.Store.InsertSubscription(ctx, sub)
+	if err := sub.SetExtents(params.GetExtents(), s.coverer(), maxArea(s.MaxSubscriptionAreaKm2)); err != nil {
+		return nil, badExtents(err)
+	}
+	span.SetAttributes(attribute.Int("dss.cells", len(sub.Cells)))
+	sub.StartTime, sub.EndTime, err = s.SubscriptionTimePolicy.withDefaults(DefaultSubscriptionTimePolicy).apply(time.Now(), sub.StartTime, sub.EndTime)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := sub.SetExtents(params.GetExtents()); err != nil {
-		return nil, dsserr.BadRequest("bad extents")
+	sub, err = s.Store.InsertSubscription(ctx, sub, s.maxSubscriptionsPerArea())
+	if err != nil {
+		return nil, err
 	}
 
 	p, err := sub.ToProto()
@@ -288,3 +511,76 @@ func (s *Server) PutSubscription(ctx context.Context, req *dspb.PutSubscriptionR
 		Subscription: p,
 	}, nil
 }
+
+// notifications returns the models.NotificationsFunc to write with an ISA
+// change of type "t", or nil if delivery by the DSS is disabled.
+func (s *Server) notifications(t events.Type) models.NotificationsFunc {
+	if s.Dispatcher == nil {
+		return nil
+	}
+	return s.Dispatcher.Notifications(t)
+}
+
+// publish publishes "e" to s.Events, if configured. Notifications for
+// s.Dispatcher are written by the store together with the change.
+func (s *Server) publish(e events.Event) {
+	if s.Events != nil {
+		s.Events.Publish(e)
+	}
+}
+
+func (s *Server) WatchSubscriptionEvents(req *dspb.WatchSubscriptionEventsRequest, stream dspb.DSService_WatchSubscriptionEventsServer) error {
+	ctx, span := startSpan(stream.Context(), "WatchSubscriptionEvents", attribute.String("dss.id", req.GetId()))
+	defer span.End()
+
+	owner, ok := auth.OwnerFromContext(ctx)
+	if !ok {
+		return dsserr.PermissionDenied("missing owner from context")
+	}
+	if err := validations.ValidateUUID(req); err != nil {
+		return err
+	}
+	if s.Events == nil {
+		return dsserr.Unimplemented("event streaming is not enabled")
+	}
+
+	// Start watching before looking up the subscription so that no event
+	// committed after the lookup is missed.
+	id := models.ID(req.GetId())
+	watcher := s.Events.Watch(id)
+	defer watcher.Close()
+
+	subscription, err := s.Store.GetSubscription(ctx, id)
+	switch {
+	case err == sql.ErrNoRows:
+		return dsserr.NotFound(req.GetId())
+	case err != nil:
+		return err
+	case subscription.Owner != owner:
+		return dsserr.PermissionDenied("subscription is owned by a different client")
+	}
+
+	sent := 0
+	defer func() { span.SetAttributes(attribute.Int("dss.events", sent)) }()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case n, ok := <-watcher.Notifications():
+			if !ok {
+				if watcher.Err() == events.ErrOverflow {
+					return dsserr.Exhausted("too many pending events, watch again")
+				}
+				return nil
+			}
+			p, err := n.ToProto()
+			if err != nil {
+				return dsserr.Internal(err.Error())
+			}
+			if err := stream.Send(p); err != nil {
+				return err
+			}
+			sent++
+		}
+	}
+}
@@ -2,289 +2,546 @@ package dss
 
 import (
 	"context"
-	"errors"
 	"testing"
 	"time"
 
 	"github.com/steeling/InterUSS-Platform/pkg/dss/auth"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/events"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/geo"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/geo/testdata"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/memstore"
 	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
 	dspb "github.com/steeling/InterUSS-Platform/pkg/dssproto"
 
-	"github.com/golang/geo/s2"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/uuid"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-type mockStore struct {
-	mock.Mock
-}
+// footprint is the polygon described by testdata.Loop.
+var footprint = &dspb.GeoPolygon{Vertices: []*dspb.LatLngPoint{
+	{Lat: 37.427636, Lng: -122.170502},
+	{Lat: 37.408799, Lng: -122.064069},
+	{Lat: 37.421265, Lng: -122.086504},
+}}
 
-func (ms *mockStore) Close() error {
-	return ms.Called().Error(0)
+func newServer() *Server {
+	return &Server{
+		Store: memstore.New(),
+	}
 }
 
-func (ms *mockStore) InsertSubscription(ctx context.Context, s *models.Subscription) (*models.Subscription, error) {
-	args := ms.Called(ctx, s)
-	return args.Get(0).(*models.Subscription), args.Error(1)
+func subscriptionRequest(id models.ID, extents *dspb.Volume4D) *dspb.PutSubscriptionRequest {
+	return &dspb.PutSubscriptionRequest{
+		Id: id.String(),
+		Params: &dspb.PutSubscriptionParameters{
+			Callbacks: &dspb.SubscriptionCallbacks{IdentificationServiceAreaUrl: "https://no/place/like/home"},
+			Extents:   extents,
+		},
+	}
 }
 
-func (ms *mockStore) GetSubscription(ctx context.Context, id models.ID) (*models.Subscription, error) {
-	args := ms.Called(ctx, id)
-	return args.Get(0).(*models.Subscription), args.Error(1)
+// putSubscription creates a subscription covering footprint on behalf of the
+// owner in ctx.
+func putSubscription(ctx context.Context, t *testing.T, s *Server, id models.ID) *dspb.Subscription {
+	resp, err := s.PutSubscription(ctx, subscriptionRequest(id, &dspb.Volume4D{
+		SpatialVolume: &dspb.Volume3D{Footprint: footprint},
+	}))
+	require.NoError(t, err)
+	return resp.Subscription
 }
 
-func (ms *mockStore) DeleteSubscription(ctx context.Context, id models.ID, owner models.Owner, version *models.Version) (*models.Subscription, error) {
-	args := ms.Called(ctx, id, owner, version)
-	return args.Get(0).(*models.Subscription), args.Error(1)
+// putISA creates an IdentificationServiceArea within "space" on behalf of the
+// owner in ctx.
+func putISA(ctx context.Context, t *testing.T, s *Server, id models.ID, space *dspb.Volume3D) *dspb.PutIdentificationServiceAreaResponse {
+	resp, err := s.PutIdentificationServiceArea(ctx, &dspb.PutIdentificationServiceAreaRequest{
+		Id: id.String(),
+		Params: &dspb.PutIdentificationServiceAreaParameters{
+			FlightsUrl: "https://no/place/like/home/for/flights",
+			Extents:    &dspb.Volume4D{SpatialVolume: space},
+		},
+	})
+	require.NoError(t, err)
+	return resp
 }
 
-func (ms *mockStore) SearchSubscriptions(ctx context.Context, cells s2.CellUnion, owner models.Owner) ([]*models.Subscription, error) {
-	args := ms.Called(ctx, cells, owner)
-	return args.Get(0).([]*models.Subscription), args.Error(1)
-}
+func TestDeleteSubscription(t *testing.T) {
+	var (
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+		id  = models.ID(uuid.New().String())
+	)
 
-func (ms *mockStore) GetISA(ctx context.Context, id models.ID) (*models.IdentificationServiceArea, error) {
-	args := ms.Called(ctx, id)
-	return args.Get(0).(*models.IdentificationServiceArea), args.Error(1)
-}
+	putSubscription(ctx, t, s, id)
+	resp, err := s.DeleteSubscription(ctx, &dspb.DeleteSubscriptionRequest{
+		Id: id.String(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, id.String(), resp.Subscription.Id)
 
-func (ms *mockStore) DeleteISA(ctx context.Context, id models.ID, owner models.Owner, version *models.Version) (*models.IdentificationServiceArea, []*models.Subscription, error) {
-	args := ms.Called(ctx, id, owner, version)
-	return args.Get(0).(*models.IdentificationServiceArea), args.Get(1).([]*models.Subscription), args.Error(2)
+	_, err = s.DeleteSubscription(ctx, &dspb.DeleteSubscriptionRequest{
+		Id: id.String(),
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
 }
 
-func (ms *mockStore) InsertISA(ctx context.Context, isa *models.IdentificationServiceArea) (*models.IdentificationServiceArea, []*models.Subscription, error) {
-	args := ms.Called(ctx, isa)
-	return args.Get(0).(*models.IdentificationServiceArea), args.Get(1).([]*models.Subscription), args.Error(2)
+func TestGetSubscription(t *testing.T) {
+	var (
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+		id  = models.ID(uuid.New().String())
+	)
+
+	_, err := s.GetSubscription(ctx, &dspb.GetSubscriptionRequest{
+		Id: id.String(),
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	putSubscription(ctx, t, s, id)
+	resp, err := s.GetSubscription(ctx, &dspb.GetSubscriptionRequest{
+		Id: id.String(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, id.String(), resp.Subscription.Id)
+	require.Equal(t, "foo", resp.Subscription.Owner)
 }
 
-func (ms *mockStore) SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *time.Time, latest *time.Time) ([]*models.IdentificationServiceArea, error) {
-	args := ms.Called(ctx, cells, earliest, latest)
-	return args.Get(0).([]*models.IdentificationServiceArea), args.Error(1)
+func TestSearchSubscriptionsFailsIfOwnerMissingFromContext(t *testing.T) {
+	_, err := newServer().SearchSubscriptions(context.Background(), &dspb.SearchSubscriptionsRequest{
+		Area: testdata.Loop,
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
 }
 
-func TestDeleteSubscriptionCallsIntoMockStore(t *testing.T) {
+func TestSearchSubscriptionsFailsForInvalidArea(t *testing.T) {
 	ctx := auth.ContextWithOwner(context.Background(), "foo")
 
-	for _, r := range []struct {
-		name         string
-		id           models.ID
-		subscription *models.Subscription
-		err          error
-	}{
-		{
-			name:         "subscription-is-returned-if-returned-from-store",
-			id:           models.ID(uuid.New().String()),
-			subscription: &models.Subscription{},
-		},
-		{
-			name: "error-is-returned-if-returned-from-store",
-			id:   models.ID(uuid.New().String()),
-			err:  errors.New("failed to look up subscription for ID"),
-		},
-	} {
-		t.Run(r.name, func(t *testing.T) {
-			store := &mockStore{}
-			store.On("DeleteSubscription", mock.Anything, r.id, mock.Anything, mock.Anything).Return(
-				r.subscription, r.err,
-			)
-			s := &Server{
-				Store: store,
-			}
-
-			_, err := s.DeleteSubscription(ctx, &dspb.DeleteSubscriptionRequest{
-				Id: r.id.String(),
-			})
-			require.Equal(t, r.err, err)
-			require.True(t, store.AssertExpectations(t))
-		})
-	}
-}
-
-func TestGetSubscriptionCallsIntoMockStore(t *testing.T) {
-	for _, r := range []struct {
-		name         string
-		id           models.ID
-		subscription *models.Subscription
-		err          error
-	}{
-		{
-			name:         "subscription-is-returned-if-returned-from-store",
-			id:           models.ID(uuid.New().String()),
-			subscription: &models.Subscription{},
-		},
-		{
-			name: "error-is-returned-if-returned-from-store",
-			id:   models.ID(uuid.New().String()),
-			err:  errors.New("failed to look up subscription for ID"),
-		},
-	} {
-		t.Run(r.name, func(t *testing.T) {
-			store := &mockStore{}
-			store.On("GetSubscription", mock.Anything, r.id).Return(
-				r.subscription, r.err,
-			)
-			s := &Server{
-				Store: store,
-			}
-
-			_, err := s.GetSubscription(context.Background(), &dspb.GetSubscriptionRequest{
-				Id: r.id.String(),
-			})
-			require.Equal(t, r.err, err)
-			require.True(t, store.AssertExpectations(t))
-		})
-	}
+	_, err := newServer().SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
+		Area: testdata.LoopWithOddNumberOfCoordinates,
+	})
+	require.Error(t, err)
 }
 
-func TestSearchSubscriptionsFailsIfOwnerMissingFromContext(t *testing.T) {
+func TestAreaLimitsAreConfiguredSeparately(t *testing.T) {
 	var (
-		ctx = context.Background()
-		ms  = &mockStore{}
-		s   = &Server{
-			Store: &mockStore{},
-		}
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+		id  = models.ID(uuid.New().String())
 	)
+	s.MaxSearchAreaKm2 = 0.1
+	s.MaxSubscriptionAreaKm2 = 1000
 
 	_, err := s.SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
 		Area: testdata.Loop,
 	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "exceeds the maximum of 0.10 km^2")
 
-	require.Error(t, err)
-	require.True(t, ms.AssertExpectations(t))
+	putSubscription(ctx, t, s, id)
+
+	s.MaxSubscriptionAreaKm2 = 0.1
+	_, err = s.PutSubscription(ctx, subscriptionRequest(id, &dspb.Volume4D{
+		SpatialVolume: &dspb.Volume3D{Footprint: footprint},
+	}))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
 }
 
-func TestSearchSubscriptionsFailsForInvalidArea(t *testing.T) {
+func TestSubscriptionTimePolicy(t *testing.T) {
 	var (
 		ctx = auth.ContextWithOwner(context.Background(), "foo")
-		ms  = &mockStore{}
-		s   = &Server{
-			Store: &mockStore{},
-		}
+		s   = newServer()
+		id  = models.ID(uuid.New().String())
 	)
+	s.SubscriptionTimePolicy = TimePolicy{MaxLeadTime: time.Hour}
+	request := func(start time.Time) *dspb.PutSubscriptionRequest {
+		ts, err := ptypes.TimestampProto(start)
+		require.NoError(t, err)
+		return subscriptionRequest(id, &dspb.Volume4D{
+			TimeStart:     ts,
+			SpatialVolume: &dspb.Volume3D{Footprint: footprint},
+		})
+	}
 
-	_, err := s.SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
-		Area: testdata.LoopWithOddNumberOfCoordinates,
-	})
+	_, err := s.PutSubscription(ctx, request(time.Now().Add(2*time.Hour)))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "is more than 1h0m0s in the future")
 
-	require.Error(t, err)
-	require.True(t, ms.AssertExpectations(t))
+	// Missing end times default to the default duration of subscriptions.
+	start := time.Now().Add(30 * time.Minute).UTC()
+	resp, err := s.PutSubscription(ctx, request(start))
+	require.NoError(t, err)
+	end, err := ptypes.Timestamp(resp.Subscription.Expires)
+	require.NoError(t, err)
+	require.True(t, end.Equal(start.Add(DefaultSubscriptionTimePolicy.DefaultDuration)), "end time %s", end)
 }
 
-func TestSearchSubscriptionsCallsIntoStore(t *testing.T) {
+func TestSearchSubscriptionsReturnsOwnedSubscriptions(t *testing.T) {
 	var (
-		owner = models.Owner("foo")
-		ctx   = auth.ContextWithOwner(context.Background(), owner)
-		ms    = &mockStore{}
-		s     = &Server{
-			Store: ms,
-		}
+		ctx   = auth.ContextWithOwner(context.Background(), "foo")
+		other = auth.ContextWithOwner(context.Background(), "bar")
+		s     = newServer()
+		id    = models.ID(uuid.New().String())
 	)
 
-	ms.On("SearchSubscriptions", mock.Anything, mock.Anything, owner).Return(
-		[]*models.Subscription{
-			{
-				ID:                models.ID(uuid.New().String()),
-				Owner:             owner,
-				Url:               "https://no/place/like/home",
-				NotificationIndex: 42,
-			},
-		}, error(nil),
-	)
+	putSubscription(ctx, t, s, id)
+	putSubscription(other, t, s, models.ID(uuid.New().String()))
+
 	resp, err := s.SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
 		Area: testdata.Loop,
 	})
-
 	require.NoError(t, err)
-	require.NotNil(t, resp)
 	require.Len(t, resp.Subscriptions, 1)
-	require.True(t, ms.AssertExpectations(t))
+	require.Equal(t, id.String(), resp.Subscriptions[0].Id)
+	require.Empty(t, resp.NextPageToken)
 }
 
 func TestDeleteIdentificationServiceAreaRequiresOwnerInContext(t *testing.T) {
+	_, err := newServer().DeleteIdentificationServiceArea(context.Background(), &dspb.DeleteIdentificationServiceAreaRequest{
+		Id: uuid.New().String(),
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestDeleteIdentificationServiceAreaReturnsSubscribers(t *testing.T) {
 	var (
-		id = uuid.New().String()
-		ms = &mockStore{}
-		s  = &Server{
-			Store: ms,
-		}
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+		id  = models.ID(uuid.New().String())
 	)
 
-	_, err := s.DeleteIdentificationServiceArea(context.Background(), &dspb.DeleteIdentificationServiceAreaRequest{
-		Id: id,
+	// Writers are not notified of their own changes.
+	putSubscription(ctx, t, s, models.ID(uuid.New().String()))
+	putSubscription(auth.ContextWithOwner(context.Background(), "bar"), t, s, models.ID(uuid.New().String()))
+	putISA(ctx, t, s, id, &dspb.Volume3D{Footprint: footprint})
+
+	resp, err := s.DeleteIdentificationServiceArea(ctx, &dspb.DeleteIdentificationServiceAreaRequest{
+		Id: id.String(),
 	})
+	require.NoError(t, err)
+	require.Equal(t, id.String(), resp.ServiceArea.Id)
+	require.Len(t, resp.Subscribers, 1)
+	require.Equal(t, int32(2), resp.Subscribers[0].Subscriptions[0].NotificationIndex)
 
-	require.Error(t, err)
-	require.True(t, ms.AssertExpectations(t))
+	_, err = s.DeleteIdentificationServiceArea(ctx, &dspb.DeleteIdentificationServiceAreaRequest{
+		Id: id.String(),
+	})
+	require.Equal(t, codes.NotFound, status.Code(err))
 }
 
-func TestDeleteIdentificationServiceAreaCallsIntoStore(t *testing.T) {
+func TestSearchIdentificationServiceAreas(t *testing.T) {
 	var (
-		owner = models.Owner("foo")
-		id    = models.ID(uuid.New().String())
-		ctx   = auth.ContextWithOwner(context.Background(), owner)
-		ms    = &mockStore{}
-		s     = &Server{
-			Store: ms,
-		}
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+		id  = models.ID(uuid.New().String())
 	)
 
-	ms.On("DeleteISA", ctx, id, owner, mock.Anything).Return(
-		&models.IdentificationServiceArea{
-			ID:    models.ID(id),
-			Owner: models.Owner("me-myself-and-i"),
-			Url:   "https://no/place/like/home",
-		},
-		[]*models.Subscription{
-			{
-				NotificationIndex: 42,
-				Url:               "https://no/place/like/home",
-			},
-		}, error(nil),
+	putISA(ctx, t, s, id, &dspb.Volume3D{Footprint: footprint})
+
+	resp, err := s.SearchIdentificationServiceAreas(context.Background(), &dspb.SearchIdentificationServiceAreasRequest{
+		Area: testdata.Loop,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.ServiceAreas, 1)
+	require.Equal(t, id.String(), resp.ServiceAreas[0].Id)
+}
+
+func TestSearchIdentificationServiceAreasFiltersByAltitude(t *testing.T) {
+	var (
+		ctx  = auth.ContextWithOwner(context.Background(), "foo")
+		s    = newServer()
+		low  = models.ID(uuid.New().String())
+		high = models.ID(uuid.New().String())
 	)
-	resp, err := s.DeleteIdentificationServiceArea(ctx, &dspb.DeleteIdentificationServiceAreaRequest{
-		Id: id.String(),
+
+	putISA(ctx, t, s, low, &dspb.Volume3D{
+		Footprint:  footprint,
+		AltitudeLo: &wrappers.FloatValue{Value: 0},
+		AltitudeHi: &wrappers.FloatValue{Value: 50},
+	})
+	putISA(ctx, t, s, high, &dspb.Volume3D{
+		Footprint:  footprint,
+		AltitudeLo: &wrappers.FloatValue{Value: 120},
+		AltitudeHi: &wrappers.FloatValue{Value: 150},
 	})
 
+	resp, err := s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:        testdata.Loop,
+		MinAltitude: 100,
+		MaxAltitude: 200,
+	})
 	require.NoError(t, err)
-	require.NotNil(t, resp)
-	require.Len(t, resp.Subscribers, 1)
-	require.True(t, ms.AssertExpectations(t))
+	require.Len(t, resp.ServiceAreas, 1)
+	require.Equal(t, high.String(), resp.ServiceAreas[0].Id)
+
+	_, err = s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:        testdata.Loop,
+		MinAltitude: 200,
+		MaxAltitude: 100,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
 }
 
-func TestSearchIdentificationServiceAreasCallsIntoStore(t *testing.T) {
+func TestSearchIdentificationServiceAreasPaginates(t *testing.T) {
 	var (
-		ctx = context.Background()
-		ms  = &mockStore{}
-		s   = &Server{
-			Store: ms,
-		}
+		ctx  = auth.ContextWithOwner(context.Background(), "foo")
+		s    = newServer()
+		seen = map[string]bool{}
 	)
 
-	ms.On("SearchISAs", ctx, mock.Anything, (*time.Time)(nil), (*time.Time)(nil)).Return(
-		[]*models.IdentificationServiceArea{
-			{
-				ID:    models.ID(uuid.New().String()),
-				Owner: models.Owner("me-myself-and-i"),
-				Url:   "https://no/place/like/home",
-			},
-		}, error(nil),
-	)
+	for i := 0; i < 3; i++ {
+		putISA(ctx, t, s, models.ID(uuid.New().String()), &dspb.Volume3D{Footprint: footprint})
+	}
+
 	resp, err := s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
-		Area: testdata.Loop,
+		Area:     testdata.Loop,
+		PageSize: 2,
 	})
+	require.NoError(t, err)
+	require.Len(t, resp.ServiceAreas, 2)
+	require.NotEmpty(t, resp.NextPageToken)
+	for _, isa := range resp.ServiceAreas {
+		seen[isa.Id] = true
+	}
 
+	resp, err = s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:      testdata.Loop,
+		PageSize:  2,
+		PageToken: resp.NextPageToken,
+	})
 	require.NoError(t, err)
-	require.NotNil(t, resp)
 	require.Len(t, resp.ServiceAreas, 1)
-	require.True(t, ms.AssertExpectations(t))
+	require.Empty(t, resp.NextPageToken)
+	require.False(t, seen[resp.ServiceAreas[0].Id])
+}
+
+func TestSearchEnforcesMaxPageSize(t *testing.T) {
+	var (
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+	)
+	s.MaxPageSize = 2
+
+	for i := 0; i < 3; i++ {
+		putSubscription(ctx, t, s, models.ID(uuid.New().String()))
+	}
+
+	resp, err := s.SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
+		Area:     testdata.Loop,
+		PageSize: 1000,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Subscriptions, 2)
+	require.NotEmpty(t, resp.NextPageToken)
+}
+
+func TestSearchFailsForBadPageParameters(t *testing.T) {
+	var (
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+	)
+
+	for _, r := range []struct {
+		name      string
+		pageSize  int32
+		pageToken string
+	}{
+		{"negative page size", -1, ""},
+		{"malformed token", 0, "not-a-token"},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			_, err := s.SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
+				Area:      testdata.Loop,
+				PageSize:  r.pageSize,
+				PageToken: r.pageToken,
+			})
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+			_, err = s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+				Area:      testdata.Loop,
+				PageSize:  r.pageSize,
+				PageToken: r.pageToken,
+			})
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestSearchRejectsMalformedPageTokenIDs(t *testing.T) {
+	var (
+		ctx   = auth.ContextWithOwner(context.Background(), "foo")
+		s     = newServer()
+		query = pageQuery("", &dspb.SearchIdentificationServiceAreasRequest{Area: testdata.Loop})
+	)
+
+	_, err := s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:      testdata.Loop,
+		PageToken: models.CursorAfter(models.ID(uuid.New().String()), models.VersionFromTime(time.Now())).Token(query),
+	})
+	require.NoError(t, err)
+
+	_, err = s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:      testdata.Loop,
+		PageToken: models.CursorAfter("' OR 1=1 --", models.VersionFromTime(time.Now())).Token(query),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSearchRejectsPageTokensOfOtherSearches(t *testing.T) {
+	var (
+		ctx = auth.ContextWithOwner(context.Background(), "foo")
+		s   = newServer()
+	)
+
+	for i := 0; i < 3; i++ {
+		putISA(ctx, t, s, models.ID(uuid.New().String()), &dspb.Volume3D{Footprint: footprint})
+		putSubscription(ctx, t, s, models.ID(uuid.New().String()))
+	}
+
+	resp, err := s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:     testdata.Loop,
+		PageSize: 1,
+	})
+	require.NoError(t, err)
+	token := resp.NextPageToken
+	require.NotEmpty(t, token)
+
+	// The page size may change between pages.
+	_, err = s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:      testdata.Loop,
+		PageSize:  2,
+		PageToken: token,
+	})
+	require.NoError(t, err)
+
+	_, err = s.SearchIdentificationServiceAreas(ctx, &dspb.SearchIdentificationServiceAreasRequest{
+		Area:        testdata.Loop,
+		MaxAltitude: 100,
+		PageToken:   token,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
+		Area:      testdata.Loop,
+		PageToken: token,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	subs, err := s.SearchSubscriptions(ctx, &dspb.SearchSubscriptionsRequest{
+		Area:     testdata.Loop,
+		PageSize: 1,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, subs.NextPageToken)
+
+	// Tokens are bound to the owner of the searched subscriptions.
+	_, err = s.SearchSubscriptions(auth.ContextWithOwner(context.Background(), "bar"), &dspb.SearchSubscriptionsRequest{
+		Area:      testdata.Loop,
+		PageToken: subs.NextPageToken,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// watchStream implements dspb.DSService_WatchSubscriptionEventsServer,
+// forwarding sent events to a channel.
+type watchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *dspb.SubscriptionEvent
+}
+
+func (ws *watchStream) Context() context.Context {
+	return ws.ctx
+}
+
+func (ws *watchStream) Send(e *dspb.SubscriptionEvent) error {
+	ws.events <- e
+	return nil
+}
+
+// lookupSignalingStore signals every lookup of a subscription, which tells
+// that WatchSubscriptionEvents started watching.
+type lookupSignalingStore struct {
+	*memstore.Store
+	lookups chan models.ID
+}
+
+func (ls *lookupSignalingStore) GetSubscription(ctx context.Context, id models.ID) (*models.Subscription, error) {
+	sub, err := ls.Store.GetSubscription(ctx, id)
+	ls.lookups <- id
+	return sub, err
+}
+
+func TestWatchSubscriptionEventsStreamsEvents(t *testing.T) {
+	var (
+		owner       = models.Owner("foo")
+		id          = models.ID(uuid.New().String())
+		ctx, cancel = context.WithCancel(auth.ContextWithOwner(context.Background(), owner))
+		store       = &lookupSignalingStore{Store: memstore.New(), lookups: make(chan models.ID, 1)}
+		s           = &Server{
+			Store:  store,
+			Events: events.NewBus(events.DefaultBufferSize),
+		}
+		stream = &watchStream{ctx: ctx, events: make(chan *dspb.SubscriptionEvent, 1)}
+		done   = make(chan error)
+	)
+	defer cancel()
+	putSubscription(ctx, t, s, id)
+
+	go func() {
+		done <- s.WatchSubscriptionEvents(&dspb.WatchSubscriptionEventsRequest{Id: id.String()}, stream)
+	}()
+	require.Equal(t, id, <-store.lookups)
+
+	isa := models.ID(uuid.New().String())
+	putISA(auth.ContextWithOwner(context.Background(), "bar"), t, s, isa, &dspb.Volume3D{Footprint: footprint})
+
+	e := <-stream.events
+	require.Equal(t, dspb.SubscriptionEvent_CREATED, e.Type)
+	require.Equal(t, isa.String(), e.ServiceArea.Id)
+	require.Equal(t, id.String(), e.Subscription.Subscription)
+	require.Equal(t, int32(1), e.Subscription.NotificationIndex)
+
+	s.Events.CloseSubscription(id)
+	require.NoError(t, <-done)
+}
+
+func TestWatchSubscriptionEventsEndsWhenClientDisconnects(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(auth.ContextWithOwner(context.Background(), "foo"))
+		id          = models.ID(uuid.New().String())
+		s           = newServer()
+	)
+	s.Events = events.NewBus(events.DefaultBufferSize)
+	putSubscription(ctx, t, s, id)
+
+	cancel()
+	err := s.WatchSubscriptionEvents(&dspb.WatchSubscriptionEventsRequest{Id: id.String()}, &watchStream{ctx: ctx})
+	require.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestWatchSubscriptionEventsRequiresOwnership(t *testing.T) {
+	var (
+		id = models.ID(uuid.New().String())
+		s  = newServer()
+	)
+	s.Events = events.NewBus(events.DefaultBufferSize)
+	putSubscription(auth.ContextWithOwner(context.Background(), "bar"), t, s, id)
+
+	stream := &watchStream{ctx: auth.ContextWithOwner(context.Background(), "foo")}
+	err := s.WatchSubscriptionEvents(&dspb.WatchSubscriptionEventsRequest{Id: id.String()}, stream)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream = &watchStream{ctx: auth.ContextWithOwner(context.Background(), "bar")}
+	err = s.WatchSubscriptionEvents(&dspb.WatchSubscriptionEventsRequest{Id: uuid.New().String()}, stream)
+	require.Equal(t, codes.NotFound, status.Code(err))
 }
 
 func TestDefaultRegionCovererProducesResults(t *testing.T) {
-	cover, err := geo.AreaToCellIDs(testdata.Loop)
+	cover, err := geo.DefaultCoverer().AreaToCellIDs(testdata.Loop, geo.DefaultMaxAreaKm2)
 	require.NoError(t, err)
 	require.NotNil(t, cover)
 }
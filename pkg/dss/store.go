@@ -16,12 +16,26 @@ type Store interface {
 
 	// Delete deletes the IdentificationServiceArea identified by "id" and owned by "owner".
 	// Returns the delete IdentificationServiceArea and all Subscriptions affected by the delete.
-	DeleteISA(ctx context.Context, id models.ID, owner models.Owner, version *models.Version) (*models.IdentificationServiceArea, []*models.Subscription, error)
+	// The notification index of every affected Subscription is incremented as
+	// part of the delete, and the returned Subscriptions carry the new value.
+	// If "notify" is not nil, the notifications it returns are persisted as
+	// part of the delete, and the delete fails if "notify" does.
+	DeleteISA(ctx context.Context, id models.ID, owner models.Owner, version *models.Version, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error)
 
-	InsertISA(ctx context.Context, isa *models.IdentificationServiceArea) (*models.IdentificationServiceArea, []*models.Subscription, error)
+	// InsertISA creates or updates "isa" and returns the resulting
+	// IdentificationServiceArea and all Subscriptions affected by it. The
+	// notification index of every affected Subscription is incremented as
+	// part of the write, and the returned Subscriptions carry the new value.
+	// If "notify" is not nil, the notifications it returns are persisted as
+	// part of the write, and the write fails if "notify" does.
+	InsertISA(ctx context.Context, isa *models.IdentificationServiceArea, notify models.NotificationsFunc) (*models.IdentificationServiceArea, []*models.Subscription, error)
 
-	// SearchSubscriptions returns all subscriptions ownded by "owner" in "cells".
-	SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *time.Time, latest *time.Time) ([]*models.IdentificationServiceArea, error)
+	// SearchISAs returns all IdentificationServiceAreas in "cells" that
+	// intersect the temporal volume defined by "earliest" and "latest" and the
+	// vertical interval defined by "minAltitude" and "maxAltitude". Nil bounds
+	// are not applied. Results are ordered by their last update and ID and
+	// restricted to "page", a nil "page" returning all results.
+	SearchISAs(ctx context.Context, cells s2.CellUnion, earliest *time.Time, latest *time.Time, minAltitude *float32, maxAltitude *float32, page *models.Page) ([]*models.IdentificationServiceArea, error)
 
 	// GetSubscription returns the subscription identified by "id".
 	GetSubscription(ctx context.Context, id models.ID) (*models.Subscription, error)
@@ -30,10 +44,29 @@ type Store interface {
 	// returns the deleted subscription.
 	DeleteSubscription(ctx context.Context, id models.ID, owner models.Owner, version *models.Version) (*models.Subscription, error)
 
-	InsertSubscription(ctx context.Context, s *models.Subscription) (*models.Subscription, error)
+	// InsertSubscription creates or updates "s". Updates keep the current
+	// notification index of the subscription.
+	//
+	// If "maxPerArea" is positive, the write fails with ResourceExhausted if
+	// the owner of "s" would hold more than "maxPerArea" active, i.e. not yet
+	// ended, subscriptions intersecting any of the cells of "s". The check is
+	// part of the write, so concurrent writes cannot exceed the limit.
+	InsertSubscription(ctx context.Context, s *models.Subscription, maxPerArea int) (*models.Subscription, error)
 
 	// SearchSubscriptions returns all subscriptions ownded by "owner" in "cells".
-	SearchSubscriptions(ctx context.Context, cells s2.CellUnion, owner models.Owner) ([]*models.Subscription, error)
+	// Results are ordered by their last update and ID and restricted to "page",
+	// a nil "page" returning all results.
+	SearchSubscriptions(ctx context.Context, cells s2.CellUnion, owner models.Owner, page *models.Page) ([]*models.Subscription, error)
+
+	// DeleteExpiredISAs deletes at most "limit" IdentificationServiceAreas
+	// that ended before "threshold", together with their cells, and returns
+	// the number of deleted IdentificationServiceAreas.
+	DeleteExpiredISAs(ctx context.Context, threshold time.Time, limit int) (int64, error)
+
+	// DeleteExpiredSubscriptions deletes at most "limit" subscriptions that
+	// ended before "threshold", together with their cells, and returns the
+	// number of deleted subscriptions.
+	DeleteExpiredSubscriptions(ctx context.Context, threshold time.Time, limit int) (int64, error)
 }
 
 // NewNilStore returns a nil Store instance.
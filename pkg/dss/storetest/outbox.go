@@ -0,0 +1,255 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/google/uuid"
+	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/dispatcher"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// OutboxStore is a dss.Store persisting notifications for a
+// dispatcher.Dispatcher.
+type OutboxStore interface {
+	dss.Store
+	dispatcher.Outbox
+}
+
+// OutboxSetUpFunc returns a fresh, empty OutboxStore instance and a function
+// tearing it down again. OutboxSetUpFunc is expected to skip "t" if the store
+// is not available in the current environment.
+type OutboxSetUpFunc func(ctx context.Context, t *testing.T) (OutboxStore, func() error)
+
+// RunOutbox executes the conformance suite against the outboxes returned by
+// "setUp".
+func RunOutbox(t *testing.T, setUp OutboxSetUpFunc) {
+	for _, c := range []struct {
+		name string
+		test func(ctx context.Context, t *testing.T, outbox OutboxStore)
+	}{
+		{"NotificationsWrittenWithISAs", testNotificationsWrittenWithISAs},
+		{"FailedNotificationsRollBackISAs", testFailedNotificationsRollBackISAs},
+		{"LeaseNotifications", testLeaseNotifications},
+		{"UpdateAndDeleteNotifications", testUpdateAndDeleteNotifications},
+		{"DeleteDeadLetteredNotifications", testDeleteDeadLetteredNotifications},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			outbox, tearDown := setUp(ctx, t)
+			require.NotNil(t, outbox)
+			defer func() {
+				require.NoError(t, tearDown())
+			}()
+			c.test(ctx, t, outbox)
+		})
+	}
+}
+
+func newNotification(nextAttemptAt time.Time) *models.Notification {
+	return &models.Notification{
+		ID:            models.ID(uuid.New().String()),
+		Url:           "https://no/place/like/home",
+		Payload:       []byte(`{"type":"CREATED"}`),
+		NextAttemptAt: nextAttemptAt,
+	}
+}
+
+// enqueue persists "notifications" by inserting an ISA returning them.
+func enqueue(ctx context.Context, t *testing.T, outbox OutboxStore, notifications ...*models.Notification) {
+	_, _, err := outbox.InsertISA(ctx, newISA("me", cells), func(*models.IdentificationServiceArea, []*models.Subscription) ([]*models.Notification, error) {
+		return notifications, nil
+	})
+	require.NoError(t, err)
+}
+
+func notificationIDs(ns []*models.Notification) []models.ID {
+	ids := make([]models.ID, len(ns))
+	for i, n := range ns {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+func testNotificationsWrittenWithISAs(ctx context.Context, t *testing.T, outbox OutboxStore) {
+	var (
+		sub          = newSubscription("you", cells[:1])
+		elsewhere    = newSubscription("you", s2.CellUnion{s2.CellID(210)})
+		isa          = newISA("me", cells)
+		onInsert     = newNotification(now)
+		onDelete     = newNotification(now)
+		notifiedSubs []models.ID
+	)
+	for _, s := range []*models.Subscription{sub, elsewhere} {
+		_, err := outbox.InsertSubscription(ctx, s, 0)
+		require.NoError(t, err)
+	}
+
+	// notify returns "n" for the first of the affected subscriptions, which
+	// it records. Transactions may be retried, calling notify more than once.
+	notify := func(n *models.Notification) models.NotificationsFunc {
+		return func(notified *models.IdentificationServiceArea, subscriptions []*models.Subscription) ([]*models.Notification, error) {
+			require.Equal(t, isa.ID, notified.ID)
+			require.NotEmpty(t, subscriptions)
+			notifiedSubs = subscriptionIDs(subscriptions)
+			n.Url = subscriptions[0].Url
+			return []*models.Notification{n}, nil
+		}
+	}
+
+	inserted, _, err := outbox.InsertISA(ctx, isa, notify(onInsert))
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{sub.ID}, notifiedSubs)
+
+	_, _, err = outbox.DeleteISA(ctx, isa.ID, isa.Owner, inserted.Version, notify(onDelete))
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{sub.ID}, notifiedSubs)
+
+	leased, err := outbox.LeaseNotifications(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{onInsert.ID, onDelete.ID}, notificationIDs(leased))
+	for _, n := range leased {
+		require.Equal(t, sub.Url, n.Url)
+	}
+}
+
+func testFailedNotificationsRollBackISAs(ctx context.Context, t *testing.T, outbox OutboxStore) {
+	var (
+		sub       = newSubscription("you", cells[:1])
+		isa       = newISA("me", cells)
+		errNotify = errors.New("failed to build notifications")
+	)
+	fail := func(*models.IdentificationServiceArea, []*models.Subscription) ([]*models.Notification, error) {
+		return nil, errNotify
+	}
+	_, err := outbox.InsertSubscription(ctx, sub, 0)
+	require.NoError(t, err)
+
+	_, _, err = outbox.InsertISA(ctx, isa, fail)
+	require.Equal(t, errNotify, err)
+	_, err = outbox.GetISA(ctx, isa.ID)
+	require.Equal(t, sql.ErrNoRows, err)
+
+	inserted, _, err := outbox.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+
+	_, _, err = outbox.DeleteISA(ctx, isa.ID, isa.Owner, inserted.Version, fail)
+	require.Equal(t, errNotify, err)
+	_, err = outbox.GetISA(ctx, isa.ID)
+	require.NoError(t, err)
+
+	// Only the successful insert incremented the notification index.
+	got, err := outbox.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, got.NotificationIndex)
+
+	leased, err := outbox.LeaseNotifications(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Empty(t, leased)
+}
+
+func testLeaseNotifications(ctx context.Context, t *testing.T, outbox OutboxStore) {
+	var (
+		later   = newNotification(now.Add(-time.Minute))
+		earlier = newNotification(now.Add(-time.Hour))
+		future  = newNotification(now.Add(time.Hour))
+		lease   = 10 * time.Minute
+	)
+	enqueue(ctx, t, outbox, later, earlier, future)
+
+	leased, err := outbox.LeaseNotifications(ctx, now, lease, 1)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{earlier.ID}, notificationIDs(leased))
+	require.Equal(t, earlier.Payload, leased[0].Payload)
+	leasedUntil := now.Add(lease)
+	requireSameTime(t, &leasedUntil, &leased[0].NextAttemptAt)
+
+	leased, err = outbox.LeaseNotifications(ctx, now, lease, 10)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{later.ID}, notificationIDs(leased))
+
+	leased, err = outbox.LeaseNotifications(ctx, now, lease, 10)
+	require.NoError(t, err)
+	require.Empty(t, leased)
+
+	// Leases expire.
+	leased, err = outbox.LeaseNotifications(ctx, now.Add(2*time.Hour), lease, 10)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{earlier.ID, later.ID, future.ID}, notificationIDs(leased))
+}
+
+func testUpdateAndDeleteNotifications(ctx context.Context, t *testing.T, outbox OutboxStore) {
+	var (
+		retried      = newNotification(now)
+		deadLettered = newNotification(now)
+		delivered    = newNotification(now)
+	)
+	enqueue(ctx, t, outbox, retried, deadLettered, delivered)
+
+	retried.Attempts = 1
+	retried.LastError = "callback responded with 500 Internal Server Error"
+	retried.NextAttemptAt = now.Add(time.Minute)
+	require.NoError(t, outbox.UpdateNotification(ctx, retried))
+
+	deadLettered.Attempts = 10
+	deadLettered.DeadLetteredAt = &now
+	require.NoError(t, outbox.UpdateNotification(ctx, deadLettered))
+
+	require.NoError(t, outbox.DeleteNotification(ctx, delivered.ID))
+	requireCode(t, codes.NotFound, outbox.DeleteNotification(ctx, delivered.ID))
+	requireCode(t, codes.NotFound, outbox.UpdateNotification(ctx, delivered))
+
+	leased, err := outbox.LeaseNotifications(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Empty(t, leased)
+
+	leased, err = outbox.LeaseNotifications(ctx, now.Add(time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{retried.ID}, notificationIDs(leased))
+	require.Equal(t, 1, leased[0].Attempts)
+	require.Equal(t, retried.LastError, leased[0].LastError)
+}
+
+func testDeleteDeadLetteredNotifications(ctx context.Context, t *testing.T, outbox OutboxStore) {
+	var (
+		earlier = newNotification(now)
+		later   = newNotification(now)
+		recent  = newNotification(now)
+		pending = newNotification(now)
+	)
+	enqueue(ctx, t, outbox, earlier, later, recent, pending)
+
+	for n, at := range map[*models.Notification]time.Time{
+		earlier: now.Add(-2 * time.Hour),
+		later:   now.Add(-time.Hour),
+		recent:  now,
+	} {
+		at := at
+		n.DeadLetteredAt = &at
+		require.NoError(t, outbox.UpdateNotification(ctx, n))
+	}
+
+	threshold := now.Add(-time.Minute)
+	count, err := outbox.DeleteDeadLetteredNotifications(ctx, threshold, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	requireCode(t, codes.NotFound, outbox.DeleteNotification(ctx, earlier.ID))
+
+	count, err = outbox.DeleteDeadLetteredNotifications(ctx, threshold, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	requireCode(t, codes.NotFound, outbox.DeleteNotification(ctx, later.ID))
+
+	count, err = outbox.DeleteDeadLetteredNotifications(ctx, threshold, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+	require.NoError(t, outbox.DeleteNotification(ctx, recent.ID))
+	require.NoError(t, outbox.DeleteNotification(ctx, pending.ID))
+}
@@ -0,0 +1,160 @@
+package storetest
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/reindex"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// ReindexStore is a dss.Store whose cell index can be rebuilt.
+type ReindexStore interface {
+	dss.Store
+	reindex.Store
+}
+
+// ReindexSetUpFunc returns a fresh, empty ReindexStore instance and a function
+// tearing it down again. ReindexSetUpFunc is expected to skip "t" if the store
+// is not available in the current environment.
+type ReindexSetUpFunc func(ctx context.Context, t *testing.T) (ReindexStore, func() error)
+
+// RunReindex executes the conformance suite against the stores returned by
+// "setUp".
+func RunReindex(t *testing.T, setUp ReindexSetUpFunc) {
+	for _, c := range []struct {
+		name string
+		test func(ctx context.Context, t *testing.T, store ReindexStore)
+	}{
+		{"ListISAs", testListISAs},
+		{"UpdateISACells", testUpdateISACells},
+		{"ListSubscriptions", testListSubscriptions},
+		{"UpdateSubscriptionCells", testUpdateSubscriptionCells},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, tearDown := setUp(ctx, t)
+			require.NotNil(t, store)
+			defer func() {
+				require.NoError(t, tearDown())
+			}()
+			c.test(ctx, t, store)
+		})
+	}
+}
+
+func testListISAs(ctx context.Context, t *testing.T, store ReindexStore) {
+	var ids []models.ID
+	for i := 0; i < 5; i++ {
+		isa, _, err := store.InsertISA(ctx, newISA("me", s2.CellUnion{12494535935418957824}), nil)
+		require.NoError(t, err)
+		ids = append(ids, isa.ID)
+	}
+	sortIDs(ids)
+
+	isas, err := store.ListISAs(ctx, "", 3)
+	require.NoError(t, err)
+	require.Len(t, isas, 3)
+	for i, isa := range isas {
+		require.Equal(t, ids[i], isa.ID)
+		require.Equal(t, s2.CellUnion{12494535935418957824}, isa.Cells)
+	}
+
+	isas, err = store.ListISAs(ctx, ids[2], 3)
+	require.NoError(t, err)
+	require.Len(t, isas, 2)
+	require.Equal(t, ids[3], isas[0].ID)
+	require.Equal(t, ids[4], isas[1].ID)
+
+	isas, err = store.ListISAs(ctx, ids[4], 3)
+	require.NoError(t, err)
+	require.Empty(t, isas)
+}
+
+func testUpdateISACells(ctx context.Context, t *testing.T, store ReindexStore) {
+	var (
+		fine, coarse, elsewhere = levelCells()
+		isa, _, err             = store.InsertISA(ctx, newISA("me", s2.CellUnion{fine}), nil)
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, store.UpdateISACells(ctx, isa.ID, isa.Version, s2.CellUnion{coarse}))
+
+	// The version is unchanged, the new cells are in effect.
+	updated, err := store.GetISA(ctx, isa.ID)
+	require.NoError(t, err)
+	require.True(t, isa.Version.Matches(updated.Version))
+	require.Equal(t, s2.CellUnion{coarse}, updated.Cells)
+
+	isas, err := store.SearchISAs(ctx, s2.CellUnion{coarse.ChildBeginAtLevel(15).Next()}, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, isas, 1)
+
+	isas, err = store.SearchISAs(ctx, s2.CellUnion{elsewhere}, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, isas)
+
+	// Updates of modified or deleted ISAs fail.
+	modified := *isa
+	modified.Url = "https://new/url"
+	_, _, err = store.InsertISA(ctx, &modified, nil)
+	require.NoError(t, err)
+	requireCode(t, codes.Aborted, store.UpdateISACells(ctx, isa.ID, isa.Version, s2.CellUnion{fine}))
+
+	requireCode(t, codes.NotFound, store.UpdateISACells(ctx, models.ID("4348c8e5-0b1c-43cf-9114-2e67a4532765"), isa.Version, s2.CellUnion{fine}))
+}
+
+func testListSubscriptions(ctx context.Context, t *testing.T, store ReindexStore) {
+	var ids []models.ID
+	for i := 0; i < 5; i++ {
+		sub, err := store.InsertSubscription(ctx, newSubscription("you", s2.CellUnion{12494535935418957824}), 0)
+		require.NoError(t, err)
+		ids = append(ids, sub.ID)
+	}
+	sortIDs(ids)
+
+	subs, err := store.ListSubscriptions(ctx, "", 3)
+	require.NoError(t, err)
+	require.Equal(t, ids[:3], subscriptionIDs(subs))
+	for _, sub := range subs {
+		require.Equal(t, s2.CellUnion{12494535935418957824}, sub.Cells)
+	}
+
+	subs, err = store.ListSubscriptions(ctx, ids[2], 3)
+	require.NoError(t, err)
+	require.Equal(t, ids[3:], subscriptionIDs(subs))
+}
+
+func testUpdateSubscriptionCells(ctx context.Context, t *testing.T, store ReindexStore) {
+	var (
+		fine, coarse, _ = levelCells()
+		sub, err        = store.InsertSubscription(ctx, newSubscription("you", s2.CellUnion{fine}), 0)
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, store.UpdateSubscriptionCells(ctx, sub.ID, sub.Version, s2.CellUnion{coarse}))
+
+	updated, err := store.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	require.True(t, sub.Version.Matches(updated.Version))
+	require.Equal(t, s2.CellUnion{coarse}, updated.Cells)
+
+	subs, err := store.SearchSubscriptions(ctx, s2.CellUnion{coarse.ChildBeginAtLevel(15).Next()}, "you", nil)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{sub.ID}, subscriptionIDs(subs))
+
+	_, err = store.DeleteSubscription(ctx, sub.ID, sub.Owner, nil)
+	require.NoError(t, err)
+	requireCode(t, codes.NotFound, store.UpdateSubscriptionCells(ctx, sub.ID, sub.Version, s2.CellUnion{fine}))
+}
+
+func sortIDs(ids []models.ID) {
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+}
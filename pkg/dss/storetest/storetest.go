@@ -0,0 +1,1054 @@
+// Package storetest provides a conformance suite that every dss.Store
+// implementation is expected to pass.
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"github.com/google/uuid"
+	"github.com/steeling/InterUSS-Platform/pkg/dss"
+	"github.com/steeling/InterUSS-Platform/pkg/dss/models"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetUpFunc returns a fresh, empty dss.Store instance and a function tearing
+// it down again. SetUpFunc is expected to skip "t" if the store is not
+// available in the current environment.
+type SetUpFunc func(ctx context.Context, t *testing.T) (dss.Store, func() error)
+
+var (
+	// Cockroach stores timestamps with microsecond precision.
+	now       = time.Now().UTC().Truncate(time.Microsecond)
+	startTime = now.Add(-24 * time.Hour)
+	endTime   = now.Add(24 * time.Hour)
+	overflow  = -1
+	cells     = s2.CellUnion{
+		s2.CellID(42),
+		s2.CellID(84),
+		s2.CellID(126),
+		s2.CellID(168),
+		s2.CellID(uint64(overflow)),
+	}
+)
+
+// Run executes the conformance suite against the stores returned by "setUp".
+func Run(t *testing.T, setUp SetUpFunc) {
+	for _, c := range []struct {
+		name string
+		test func(context.Context, *testing.T, dss.Store)
+	}{
+		{"ISAInsertGetDelete", testISAInsertGetDelete},
+		{"ISAVersionChecks", testISAVersionChecks},
+		{"ISASearch", testISASearch},
+		{"ISASearchAltitudes", testISASearchAltitudes},
+		{"ISASearchPagination", testISASearchPagination},
+		{"ISASearchAcrossLevels", testISASearchAcrossLevels},
+		{"ISASubscriberFanOut", testISASubscriberFanOut},
+		{"ISASubscriberFanOutAltitudes", testISASubscriberFanOutAltitudes},
+		{"ISASubscriberFanOutTimes", testISASubscriberFanOutTimes},
+		{"NotificationIndices", testNotificationIndices},
+		{"ISAMovedOutOfSubscription", testISAMovedOutOfSubscription},
+		{"DeleteExpiredISAs", testDeleteExpiredISAs},
+		{"DeleteExpiredSubscriptions", testDeleteExpiredSubscriptions},
+		{"SubscriptionInsertGetDelete", testSubscriptionInsertGetDelete},
+		{"SubscriptionVersionChecks", testSubscriptionVersionChecks},
+		{"SubscriptionSearch", testSubscriptionSearch},
+		{"SubscriptionSearchPagination", testSubscriptionSearchPagination},
+		{"SubscriptionSearchAcrossLevels", testSubscriptionSearchAcrossLevels},
+		{"SubscriptionsPerArea", testSubscriptionsPerArea},
+		{"ConcurrentSubscriptionsPerArea", testConcurrentSubscriptionsPerArea},
+		{"ConcurrentInserts", testConcurrentInserts},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, tearDown := setUp(ctx, t)
+			require.NotNil(t, store)
+			defer func() {
+				require.NoError(t, tearDown())
+			}()
+			c.test(ctx, t, store)
+		})
+	}
+}
+
+func newISA(owner models.Owner, cells s2.CellUnion) *models.IdentificationServiceArea {
+	return &models.IdentificationServiceArea{
+		ID:        models.ID(uuid.New().String()),
+		Owner:     owner,
+		Url:       "https://no/place/like/home/for/flights",
+		StartTime: &startTime,
+		EndTime:   &endTime,
+		Cells:     cells,
+	}
+}
+
+func float32Ptr(f float32) *float32 {
+	return &f
+}
+
+func newSubscription(owner models.Owner, cells s2.CellUnion) *models.Subscription {
+	return &models.Subscription{
+		ID:        models.ID(uuid.New().String()),
+		Owner:     owner,
+		Url:       "https://no/place/like/home",
+		StartTime: &startTime,
+		EndTime:   &endTime,
+		Cells:     cells,
+	}
+}
+
+func requireCode(t *testing.T, code codes.Code, err error) {
+	require.Error(t, err)
+	require.Equal(t, code, status.Code(err), "unexpected error: %v", err)
+}
+
+func requireSameTime(t *testing.T, want, got *time.Time) {
+	if want == nil {
+		require.Nil(t, got)
+		return
+	}
+	require.NotNil(t, got)
+	require.True(t, want.Equal(*got), "want %s, got %s", want, got)
+}
+
+func requireSameISA(t *testing.T, want, got *models.IdentificationServiceArea) {
+	require.NotNil(t, got)
+	require.Equal(t, want.ID, got.ID)
+	require.Equal(t, want.Owner, got.Owner)
+	require.Equal(t, want.Url, got.Url)
+	require.Equal(t, want.AltitudeLo, got.AltitudeLo)
+	require.Equal(t, want.AltitudeHi, got.AltitudeHi)
+	requireSameTime(t, want.StartTime, got.StartTime)
+	requireSameTime(t, want.EndTime, got.EndTime)
+}
+
+func requireSameSubscription(t *testing.T, want, got *models.Subscription) {
+	require.NotNil(t, got)
+	require.Equal(t, want.ID, got.ID)
+	require.Equal(t, want.Owner, got.Owner)
+	require.Equal(t, want.Url, got.Url)
+	require.Equal(t, want.NotificationIndex, got.NotificationIndex)
+	require.Equal(t, want.AltitudeLo, got.AltitudeLo)
+	require.Equal(t, want.AltitudeHi, got.AltitudeHi)
+	requireSameTime(t, want.StartTime, got.StartTime)
+	requireSameTime(t, want.EndTime, got.EndTime)
+}
+
+func subscriptionIDs(subs []*models.Subscription) []models.ID {
+	ids := []models.ID{}
+	for _, sub := range subs {
+		ids = append(ids, sub.ID)
+	}
+	return ids
+}
+
+// levelCells returns cells of different levels close to Stanford. "fine" is
+// a descendant of "coarse", "elsewhere" is disjoint from both but shares
+// ancestors with them.
+func levelCells() (fine, coarse, elsewhere s2.CellID) {
+	leaf := s2.CellIDFromLatLng(s2.LatLngFromDegrees(37.427636, -122.170502))
+	fine, coarse = leaf.Parent(15), leaf.Parent(10)
+	elsewhere = coarse.Next().ChildBeginAtLevel(15)
+	return fine, coarse, elsewhere
+}
+
+func testISAInsertGetDelete(ctx context.Context, t *testing.T, store dss.Store) {
+	isa := newISA("me", cells)
+	isa.AltitudeLo = float32Ptr(10)
+	isa.AltitudeHi = float32Ptr(120.5)
+
+	_, err := store.GetISA(ctx, isa.ID)
+	require.Equal(t, sql.ErrNoRows, err)
+
+	inserted, _, err := store.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+	requireSameISA(t, isa, inserted)
+	require.False(t, inserted.Version.Empty())
+
+	got, err := store.GetISA(ctx, isa.ID)
+	require.NoError(t, err)
+	requireSameISA(t, isa, got)
+	require.True(t, inserted.Version.Matches(got.Version))
+
+	// Can't delete other owners' data.
+	_, _, err = store.DeleteISA(ctx, isa.ID, "you", inserted.Version, nil)
+	requireCode(t, codes.NotFound, err)
+
+	deleted, _, err := store.DeleteISA(ctx, isa.ID, isa.Owner, inserted.Version, nil)
+	require.NoError(t, err)
+	requireSameISA(t, isa, deleted)
+
+	_, err = store.GetISA(ctx, isa.ID)
+	require.Equal(t, sql.ErrNoRows, err)
+
+	_, _, err = store.DeleteISA(ctx, isa.ID, isa.Owner, nil, nil)
+	requireCode(t, codes.NotFound, err)
+}
+
+func testISAVersionChecks(ctx context.Context, t *testing.T, store dss.Store) {
+	isa := newISA("me", cells)
+
+	v1, _, err := store.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+
+	// Updating with the current version succeeds and yields a new version.
+	update := *v1
+	update.Url = "https://new/url"
+	v2, _, err := store.InsertISA(ctx, &update, nil)
+	require.NoError(t, err)
+	require.Equal(t, "https://new/url", v2.Url)
+	require.False(t, v1.Version.Matches(v2.Version))
+
+	// Updating with a stale version fails.
+	stale := *v2
+	stale.Version = v1.Version
+	_, _, err = store.InsertISA(ctx, &stale, nil)
+	requireCode(t, codes.Aborted, err)
+
+	// Updating without a version always succeeds.
+	unversioned := *v2
+	unversioned.Version = nil
+	v3, _, err := store.InsertISA(ctx, &unversioned, nil)
+	require.NoError(t, err)
+
+	// Deleting with a stale version fails.
+	_, _, err = store.DeleteISA(ctx, isa.ID, isa.Owner, v2.Version, nil)
+	requireCode(t, codes.Aborted, err)
+
+	_, _, err = store.DeleteISA(ctx, isa.ID, isa.Owner, v3.Version, nil)
+	require.NoError(t, err)
+}
+
+func testISASearch(ctx context.Context, t *testing.T, store dss.Store) {
+	_, _, err := store.InsertISA(ctx, newISA("me", cells), nil)
+	require.NoError(t, err)
+
+	_, err = store.SearchISAs(ctx, s2.CellUnion{}, nil, nil, nil, nil, nil)
+	requireCode(t, codes.InvalidArgument, err)
+
+	var (
+		offset  = 100 * time.Second
+		before  = startTime.Add(-offset)
+		after   = endTime.Add(offset)
+		later   = endTime.Add(2 * offset)
+		between = startTime.Add(offset)
+	)
+
+	for _, r := range []struct {
+		name        string
+		cells       s2.CellUnion
+		earliest    *time.Time
+		latest      *time.Time
+		expectedLen int
+	}{
+		{
+			name:        "search for empty cell",
+			cells:       s2.CellUnion{s2.CellID(210)},
+			expectedLen: 0,
+		},
+		{
+			name:        "search for only one cell",
+			cells:       s2.CellUnion{s2.CellID(42)},
+			expectedLen: 1,
+		},
+		{
+			name:        "search for only one cell with high bit set",
+			cells:       s2.CellUnion{s2.CellID(uint64(overflow))},
+			expectedLen: 1,
+		},
+		{
+			name:        "search with nil timestamps",
+			cells:       cells,
+			expectedLen: 1,
+		},
+		{
+			name:        "search with exact timestamps",
+			cells:       cells,
+			earliest:    &startTime,
+			latest:      &endTime,
+			expectedLen: 1,
+		},
+		{
+			name:        "search with non-matching time span",
+			cells:       cells,
+			earliest:    &after,
+			latest:      &later,
+			expectedLen: 0,
+		},
+		{
+			name:        "search with expanded time span",
+			cells:       cells,
+			earliest:    &before,
+			latest:      &after,
+			expectedLen: 1,
+		},
+		{
+			name:        "search with earliest after start",
+			cells:       cells,
+			earliest:    &between,
+			expectedLen: 0,
+		},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			isas, err := store.SearchISAs(ctx, r.cells, r.earliest, r.latest, nil, nil, nil)
+			require.NoError(t, err)
+			require.Len(t, isas, r.expectedLen)
+		})
+	}
+}
+
+func testISASubscriberFanOut(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		overlapping = newSubscription("you", cells[:1])
+		own         = newSubscription("me", cells[:1])
+		elsewhere   = newSubscription("you", s2.CellUnion{s2.CellID(210)})
+	)
+	for _, sub := range []*models.Subscription{overlapping, own, elsewhere} {
+		_, err := store.InsertSubscription(ctx, sub, 0)
+		require.NoError(t, err)
+	}
+
+	isa := newISA("me", cells)
+	inserted, subscribers, err := store.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{overlapping.ID}, subscriptionIDs(subscribers))
+
+	_, subscribers, err = store.DeleteISA(ctx, isa.ID, isa.Owner, inserted.Version, nil)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{overlapping.ID}, subscriptionIDs(subscribers))
+}
+
+func testISASearchAltitudes(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		low       = newISA("me", cells)
+		high      = newISA("me", cells)
+		unbounded = newISA("me", cells)
+	)
+	low.AltitudeLo, low.AltitudeHi = float32Ptr(0), float32Ptr(100)
+	high.AltitudeLo, high.AltitudeHi = float32Ptr(1000), float32Ptr(2000)
+	for _, isa := range []*models.IdentificationServiceArea{low, high, unbounded} {
+		_, _, err := store.InsertISA(ctx, isa, nil)
+		require.NoError(t, err)
+	}
+
+	for _, r := range []struct {
+		name        string
+		minAltitude *float32
+		maxAltitude *float32
+		expected    []models.ID
+	}{
+		{
+			name:     "no altitude bounds",
+			expected: []models.ID{low.ID, high.ID, unbounded.ID},
+		},
+		{
+			name:        "only min altitude",
+			minAltitude: float32Ptr(500),
+			expected:    []models.ID{high.ID, unbounded.ID},
+		},
+		{
+			name:        "only max altitude",
+			maxAltitude: float32Ptr(500),
+			expected:    []models.ID{low.ID, unbounded.ID},
+		},
+		{
+			name:        "bounds touching both ISAs",
+			minAltitude: float32Ptr(100),
+			maxAltitude: float32Ptr(1000),
+			expected:    []models.ID{low.ID, high.ID, unbounded.ID},
+		},
+		{
+			name:        "bounds between both ISAs",
+			minAltitude: float32Ptr(200),
+			maxAltitude: float32Ptr(300),
+			expected:    []models.ID{unbounded.ID},
+		},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			isas, err := store.SearchISAs(ctx, cells, nil, nil, r.minAltitude, r.maxAltitude, nil)
+			require.NoError(t, err)
+			ids := []models.ID{}
+			for _, isa := range isas {
+				ids = append(ids, isa.ID)
+			}
+			require.ElementsMatch(t, r.expected, ids)
+		})
+	}
+}
+
+func testISASearchPagination(ctx context.Context, t *testing.T, store dss.Store) {
+	var inserted []*models.IdentificationServiceArea
+	for i := 0; i < 5; i++ {
+		isa, _, err := store.InsertISA(ctx, newISA("me", cells), nil)
+		require.NoError(t, err)
+		inserted = append(inserted, isa)
+	}
+
+	all, err := store.SearchISAs(ctx, cells, nil, nil, nil, nil, &models.Page{})
+	require.NoError(t, err)
+	require.Len(t, all, len(inserted))
+
+	var (
+		page = &models.Page{Limit: 2}
+		seen = map[models.ID]int{}
+	)
+	for i := 0; ; i++ {
+		isas, err := store.SearchISAs(ctx, cells, nil, nil, nil, nil, page)
+		require.NoError(t, err)
+		require.True(t, len(isas) <= page.Limit)
+		if len(isas) == 0 {
+			break
+		}
+		for _, isa := range isas {
+			seen[isa.ID]++
+		}
+		if i == 0 {
+			// Updating an ISA that has already been returned moves it behind
+			// the cursor, without affecting the remaining pages.
+			updated := *isas[0]
+			updated.Cells = cells
+			_, _, err := store.InsertISA(ctx, &updated, nil)
+			require.NoError(t, err)
+		}
+		last := isas[len(isas)-1]
+		page.After = models.CursorAfter(last.ID, last.Version)
+	}
+
+	require.Len(t, seen, len(inserted))
+	for _, isa := range inserted {
+		require.NotZero(t, seen[isa.ID], "missing %s", isa.ID)
+	}
+}
+
+func testISASearchAcrossLevels(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		fine, coarse, elsewhere = levelCells()
+		fineISA                 = newISA("me", s2.CellUnion{fine})
+		coarseISA               = newISA("me", s2.CellUnion{coarse})
+	)
+	for _, isa := range []*models.IdentificationServiceArea{fineISA, coarseISA} {
+		_, _, err := store.InsertISA(ctx, isa, nil)
+		require.NoError(t, err)
+	}
+
+	for _, r := range []struct {
+		name     string
+		cells    s2.CellUnion
+		expected []models.ID
+	}{
+		{"fine cell", s2.CellUnion{fine}, []models.ID{fineISA.ID, coarseISA.ID}},
+		{"coarse cell", s2.CellUnion{coarse}, []models.ID{fineISA.ID, coarseISA.ID}},
+		{"ancestor of both", s2.CellUnion{coarse.Parent(5)}, []models.ID{fineISA.ID, coarseISA.ID}},
+		{"descendant of both", s2.CellUnion{fine.ChildBeginAtLevel(20)}, []models.ID{fineISA.ID, coarseISA.ID}},
+		{"disjoint fine cell", s2.CellUnion{elsewhere}, []models.ID{}},
+		{"disjoint coarse cell", s2.CellUnion{elsewhere.Parent(10)}, []models.ID{}},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			isas, err := store.SearchISAs(ctx, r.cells, nil, nil, nil, nil, nil)
+			require.NoError(t, err)
+			ids := []models.ID{}
+			for _, isa := range isas {
+				ids = append(ids, isa.ID)
+			}
+			require.ElementsMatch(t, r.expected, ids)
+		})
+	}
+
+	// Deleting an ISA removes the ancestors of its cells, too.
+	_, _, err := store.DeleteISA(ctx, fineISA.ID, fineISA.Owner, nil, nil)
+	require.NoError(t, err)
+	isas, err := store.SearchISAs(ctx, s2.CellUnion{fine.Parent(12)}, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, isas, 1)
+	require.Equal(t, coarseISA.ID, isas[0].ID)
+}
+
+func testISASubscriberFanOutAltitudes(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		low       = newSubscription("you", cells)
+		high      = newSubscription("you", cells)
+		unbounded = newSubscription("you", cells)
+	)
+	low.AltitudeLo, low.AltitudeHi = float32Ptr(0), float32Ptr(100)
+	high.AltitudeLo, high.AltitudeHi = float32Ptr(1000), float32Ptr(2000)
+	for _, sub := range []*models.Subscription{low, high, unbounded} {
+		_, err := store.InsertSubscription(ctx, sub, 0)
+		require.NoError(t, err)
+	}
+
+	isa := newISA("me", cells)
+	isa.AltitudeLo, isa.AltitudeHi = float32Ptr(50), float32Ptr(120)
+	inserted, subscribers, err := store.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{low.ID, unbounded.ID}, subscriptionIDs(subscribers))
+
+	// Moving the ISA up affects the subscriptions of both the old and the
+	// new altitudes.
+	update := *inserted
+	update.AltitudeLo, update.AltitudeHi = float32Ptr(1500), nil
+	inserted, subscribers, err = store.InsertISA(ctx, &update, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{low.ID, high.ID, unbounded.ID}, subscriptionIDs(subscribers))
+
+	_, subscribers, err = store.DeleteISA(ctx, isa.ID, isa.Owner, inserted.Version, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{high.ID, unbounded.ID}, subscriptionIDs(subscribers))
+}
+
+func testISASubscriberFanOutTimes(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		second = time.Second
+		hour   = time.Hour
+		// withWindow returns a subscription active in ["start", "end"].
+		withWindow = func(start, end *time.Time) *models.Subscription {
+			sub := newSubscription("you", cells)
+			sub.StartTime, sub.EndTime = start, end
+			return sub
+		}
+		at = func(base time.Time, offset time.Duration) *time.Time {
+			result := base.Add(offset)
+			return &result
+		}
+
+		expired      = withWindow(at(startTime, -hour), at(startTime, -second))
+		future       = withWindow(at(endTime, second), at(endTime, hour))
+		endsAtStart  = withWindow(at(startTime, -hour), at(startTime, 0))
+		startsAtEnd  = withWindow(at(endTime, 0), at(endTime, hour))
+		inside       = withWindow(at(startTime, hour), at(endTime, -hour))
+		enclosing    = withWindow(at(startTime, -hour), at(endTime, hour))
+		openEnded    = withWindow(at(startTime, -hour), nil)
+		openStarted  = withWindow(nil, at(startTime, -second))
+		withoutTimes = withWindow(nil, nil)
+	)
+	for _, sub := range []*models.Subscription{
+		expired, future, endsAtStart, startsAtEnd, inside, enclosing, openEnded, openStarted, withoutTimes,
+	} {
+		_, err := store.InsertSubscription(ctx, sub, 0)
+		require.NoError(t, err)
+	}
+
+	isa := newISA("me", cells)
+	inserted, subscribers, err := store.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{
+		endsAtStart.ID, startsAtEnd.ID, inside.ID, enclosing.ID, openEnded.ID, withoutTimes.ID,
+	}, subscriptionIDs(subscribers))
+
+	_, subscribers, err = store.DeleteISA(ctx, isa.ID, isa.Owner, inserted.Version, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{
+		endsAtStart.ID, startsAtEnd.ID, inside.ID, enclosing.ID, openEnded.ID, withoutTimes.ID,
+	}, subscriptionIDs(subscribers))
+
+	// Moving the window of an ISA affects the subscriptions of both the old
+	// and the new window, including those touching only the old one.
+	moved := newISA("me", cells)
+	inserted, _, err = store.InsertISA(ctx, moved, nil)
+	require.NoError(t, err)
+	update := *inserted
+	update.StartTime, update.EndTime = at(endTime, 0), at(endTime, 2*hour)
+	inserted, subscribers, err = store.InsertISA(ctx, &update, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{
+		endsAtStart.ID, startsAtEnd.ID, inside.ID, enclosing.ID, openEnded.ID, withoutTimes.ID, future.ID,
+	}, subscriptionIDs(subscribers))
+
+	_, subscribers, err = store.DeleteISA(ctx, moved.ID, moved.Owner, inserted.Version, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{
+		startsAtEnd.ID, enclosing.ID, openEnded.ID, withoutTimes.ID, future.ID,
+	}, subscriptionIDs(subscribers))
+
+	// ISAs without a time window affect all subscriptions in their cells.
+	unbounded := newISA("me", cells)
+	unbounded.StartTime, unbounded.EndTime = nil, nil
+	_, subscribers, err = store.InsertISA(ctx, unbounded, nil)
+	require.NoError(t, err)
+	require.Len(t, subscribers, 9)
+}
+
+func testNotificationIndices(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		sub       = newSubscription("you", cells[:1])
+		elsewhere = newSubscription("you", s2.CellUnion{s2.CellID(210)})
+	)
+	for _, s := range []*models.Subscription{sub, elsewhere} {
+		_, err := store.InsertSubscription(ctx, s, 0)
+		require.NoError(t, err)
+	}
+
+	// notificationIndexOf returns the notification index reported for "sub"
+	// in "subscribers".
+	notificationIndexOf := func(subscribers []*models.Subscription) int {
+		require.Len(t, subscribers, 1)
+		require.Equal(t, sub.ID, subscribers[0].ID)
+		return subscribers[0].NotificationIndex
+	}
+
+	isa := newISA("me", cells)
+	created, subscribers, err := store.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+	onCreate := notificationIndexOf(subscribers)
+	require.Equal(t, 1, onCreate)
+
+	updated, subscribers, err := store.InsertISA(ctx, created, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, notificationIndexOf(subscribers))
+
+	// Updates by the owner of the subscription keep the notification index.
+	got, err := store.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	got.Url = "https://new/url"
+	got, err = store.InsertSubscription(ctx, got, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, got.NotificationIndex)
+
+	_, subscribers, err = store.DeleteISA(ctx, isa.ID, isa.Owner, updated.Version, nil)
+	require.NoError(t, err)
+	onDelete := notificationIndexOf(subscribers)
+	require.Equal(t, 3, onDelete)
+
+	got, err = store.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, got.NotificationIndex)
+
+	got, err = store.GetSubscription(ctx, elsewhere.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, got.NotificationIndex)
+
+	// A subscriber that missed the notification for the update can detect
+	// the gap from the notifications it did receive.
+	require.Equal(t, 1, onDelete-onCreate-1)
+}
+
+func testISAMovedOutOfSubscription(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		left  = newSubscription("you", cells[:1])
+		right = newSubscription("you", cells[2:3])
+		both  = newSubscription("you", cells[:3])
+	)
+	for _, s := range []*models.Subscription{left, right, both} {
+		_, err := store.InsertSubscription(ctx, s, 0)
+		require.NoError(t, err)
+	}
+
+	isa := newISA("me", cells[:1])
+	inserted, subscribers, err := store.InsertISA(ctx, isa, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{left.ID, both.ID}, subscriptionIDs(subscribers))
+
+	// Subscribers of the area the ISA is moved out of learn about the move.
+	// Subscriptions covering both areas are notified once.
+	update := *inserted
+	update.Cells = cells[2:3]
+	_, subscribers, err = store.InsertISA(ctx, &update, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{left.ID, right.ID, both.ID}, subscriptionIDs(subscribers))
+
+	for _, want := range []struct {
+		sub   *models.Subscription
+		index int
+	}{
+		{left, 2},
+		{right, 1},
+		{both, 2},
+	} {
+		got, err := store.GetSubscription(ctx, want.sub.ID)
+		require.NoError(t, err)
+		require.Equal(t, want.index, got.NotificationIndex)
+	}
+}
+
+func testSubscriptionInsertGetDelete(ctx context.Context, t *testing.T, store dss.Store) {
+	for _, r := range []struct {
+		name  string
+		input *models.Subscription
+	}{
+		{
+			name:  "a subscription with startTime and endTime",
+			input: newSubscription("me", cells),
+		},
+		{
+			name: "a subscription without startTime and endTime",
+			input: &models.Subscription{
+				ID:    models.ID(uuid.New().String()),
+				Owner: "me",
+				Url:   "https://no/place/like/home",
+				Cells: cells,
+			},
+		},
+		{
+			name: "a subscription with altitudes",
+			input: &models.Subscription{
+				ID:         models.ID(uuid.New().String()),
+				Owner:      "me",
+				Url:        "https://no/place/like/home",
+				Cells:      cells,
+				AltitudeLo: float32Ptr(0),
+				AltitudeHi: float32Ptr(400),
+			},
+		},
+		{
+			name: "a subscription with a notification index",
+			input: &models.Subscription{
+				ID:                models.ID(uuid.New().String()),
+				Owner:             "me",
+				Url:               "https://no/place/like/home",
+				NotificationIndex: 42,
+				Cells:             cells,
+			},
+		},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			_, err := store.GetSubscription(ctx, r.input.ID)
+			require.Equal(t, sql.ErrNoRows, err)
+
+			inserted, err := store.InsertSubscription(ctx, r.input, 0)
+			require.NoError(t, err)
+			requireSameSubscription(t, r.input, inserted)
+			require.False(t, inserted.Version.Empty())
+
+			got, err := store.GetSubscription(ctx, r.input.ID)
+			require.NoError(t, err)
+			requireSameSubscription(t, r.input, got)
+			require.True(t, inserted.Version.Matches(got.Version))
+
+			// Can't delete other owners' data.
+			_, err = store.DeleteSubscription(ctx, r.input.ID, "you", inserted.Version)
+			requireCode(t, codes.NotFound, err)
+
+			deleted, err := store.DeleteSubscription(ctx, r.input.ID, r.input.Owner, inserted.Version)
+			require.NoError(t, err)
+			requireSameSubscription(t, r.input, deleted)
+
+			_, err = store.GetSubscription(ctx, r.input.ID)
+			require.Equal(t, sql.ErrNoRows, err)
+		})
+	}
+}
+
+func testSubscriptionVersionChecks(ctx context.Context, t *testing.T, store dss.Store) {
+	sub := newSubscription("me", cells)
+
+	v1, err := store.InsertSubscription(ctx, sub, 0)
+	require.NoError(t, err)
+
+	update := *v1
+	update.Url = "https://new/url"
+	v2, err := store.InsertSubscription(ctx, &update, 0)
+	require.NoError(t, err)
+	require.Equal(t, "https://new/url", v2.Url)
+	require.False(t, v1.Version.Matches(v2.Version))
+
+	stale := *v2
+	stale.Version = v1.Version
+	_, err = store.InsertSubscription(ctx, &stale, 0)
+	requireCode(t, codes.Aborted, err)
+
+	_, err = store.DeleteSubscription(ctx, sub.ID, sub.Owner, v1.Version)
+	requireCode(t, codes.Aborted, err)
+
+	_, err = store.DeleteSubscription(ctx, sub.ID, sub.Owner, v2.Version)
+	require.NoError(t, err)
+}
+
+func testSubscriptionSearch(ctx context.Context, t *testing.T, store dss.Store) {
+	owners := []models.Owner{"me", "my", "self", "and", "i"}
+	for i, owner := range owners {
+		// Every owner holds one subscription touching a growing set of cells.
+		_, err := store.InsertSubscription(ctx, newSubscription(owner, cells[:i+1]), 0)
+		require.NoError(t, err)
+	}
+
+	_, err := store.SearchSubscriptions(ctx, s2.CellUnion{}, "me", nil)
+	requireCode(t, codes.InvalidArgument, err)
+
+	for _, owner := range owners {
+		found, err := store.SearchSubscriptions(ctx, cells, owner, nil)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		require.Equal(t, owner, found[0].Owner)
+	}
+
+	// Only "me" doesn't cover the last cell.
+	found, err := store.SearchSubscriptions(ctx, cells[len(owners)-1:], "me", nil)
+	require.NoError(t, err)
+	require.Len(t, found, 0)
+
+	found, err = store.SearchSubscriptions(ctx, cells, "nobody", nil)
+	require.NoError(t, err)
+	require.Len(t, found, 0)
+}
+
+func testSubscriptionSearchPagination(ctx context.Context, t *testing.T, store dss.Store) {
+	var inserted []models.ID
+	for i := 0; i < 5; i++ {
+		sub, err := store.InsertSubscription(ctx, newSubscription("me", cells), 0)
+		require.NoError(t, err)
+		inserted = append(inserted, sub.ID)
+	}
+	_, err := store.InsertSubscription(ctx, newSubscription("you", cells), 0)
+	require.NoError(t, err)
+
+	var (
+		page = &models.Page{Limit: 2}
+		seen []models.ID
+	)
+	for {
+		found, err := store.SearchSubscriptions(ctx, cells, "me", page)
+		require.NoError(t, err)
+		require.True(t, len(found) <= page.Limit)
+		if len(found) == 0 {
+			break
+		}
+		seen = append(seen, subscriptionIDs(found)...)
+		last := found[len(found)-1]
+		page.After = models.CursorAfter(last.ID, last.Version)
+	}
+
+	// Without concurrent writes, every subscription is returned exactly once,
+	// in insertion order.
+	require.Equal(t, inserted, seen)
+}
+
+func testSubscriptionSearchAcrossLevels(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		fine, coarse, elsewhere = levelCells()
+		sub                     = newSubscription("you", s2.CellUnion{coarse})
+	)
+	_, err := store.InsertSubscription(ctx, sub, 0)
+	require.NoError(t, err)
+
+	found, err := store.SearchSubscriptions(ctx, s2.CellUnion{fine}, "you", nil)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{sub.ID}, subscriptionIDs(found))
+
+	found, err = store.SearchSubscriptions(ctx, s2.CellUnion{elsewhere}, "you", nil)
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	// ISAs notify subscriptions indexed at different levels.
+	_, subscribers, err := store.InsertISA(ctx, newISA("me", s2.CellUnion{fine}), nil)
+	require.NoError(t, err)
+	require.Equal(t, []models.ID{sub.ID}, subscriptionIDs(subscribers))
+
+	_, subscribers, err = store.InsertISA(ctx, newISA("me", s2.CellUnion{elsewhere}), nil)
+	require.NoError(t, err)
+	require.Empty(t, subscribers)
+}
+
+func testSubscriptionsPerArea(ctx context.Context, t *testing.T, store dss.Store) {
+	const limit = 3
+	var (
+		fine, coarse, elsewhere = levelCells()
+		subs                    []*models.Subscription
+	)
+	for i := 0; i < limit; i++ {
+		sub, err := store.InsertSubscription(ctx, newSubscription("me", s2.CellUnion{fine}), limit)
+		require.NoError(t, err)
+		subs = append(subs, sub)
+	}
+
+	// Subscriptions intersecting the area exceed the limit, independent of
+	// the levels of their cells.
+	_, err := store.InsertSubscription(ctx, newSubscription("me", s2.CellUnion{fine}), limit)
+	requireCode(t, codes.ResourceExhausted, err)
+	_, err = store.InsertSubscription(ctx, newSubscription("me", s2.CellUnion{coarse}), limit)
+	requireCode(t, codes.ResourceExhausted, err)
+
+	// Updates do not count the subscription being updated.
+	update := *subs[0]
+	update.Url = "https://no/place/like/the/office"
+	_, err = store.InsertSubscription(ctx, &update, limit)
+	require.NoError(t, err)
+
+	// Other owners and other areas are not affected.
+	_, err = store.InsertSubscription(ctx, newSubscription("you", s2.CellUnion{fine}), limit)
+	require.NoError(t, err)
+	_, err = store.InsertSubscription(ctx, newSubscription("me", s2.CellUnion{elsewhere}), limit)
+	require.NoError(t, err)
+
+	// Subscriptions that ended do not count.
+	ended := *subs[1]
+	end := now.Add(-time.Hour)
+	ended.EndTime = &end
+	_, err = store.InsertSubscription(ctx, &ended, limit)
+	require.NoError(t, err)
+	_, err = store.InsertSubscription(ctx, newSubscription("me", s2.CellUnion{fine}), limit)
+	require.NoError(t, err)
+
+	// A limit of 0 disables the check.
+	_, err = store.InsertSubscription(ctx, newSubscription("me", s2.CellUnion{fine}), 0)
+	require.NoError(t, err)
+}
+
+func testConcurrentSubscriptionsPerArea(ctx context.Context, t *testing.T, store dss.Store) {
+	const (
+		limit = 3
+		n     = 10
+	)
+	var (
+		fine, _, _ = levelCells()
+		wg         sync.WaitGroup
+		errs       = make(chan error, n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.InsertSubscription(ctx, newSubscription("me", s2.CellUnion{fine}), limit)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch status.Code(err) {
+		case codes.OK:
+			succeeded++
+		case codes.ResourceExhausted:
+		case codes.Aborted:
+			// Persistent transaction conflicts are surfaced to clients.
+		default:
+			require.NoError(t, err)
+		}
+	}
+	require.True(t, succeeded > 0 && succeeded <= limit, "%d subscriptions were inserted", succeeded)
+
+	found, err := store.SearchSubscriptions(ctx, s2.CellUnion{fine}, "me", nil)
+	require.NoError(t, err)
+	require.Len(t, found, succeeded)
+}
+
+func testDeleteExpiredISAs(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		threshold = now.Add(-time.Hour)
+		expired   []*models.IdentificationServiceArea
+		active    = newISA("me", cells)
+		unbounded = newISA("me", cells)
+	)
+	unbounded.EndTime = nil
+	for i := 0; i < 3; i++ {
+		isa := newISA("me", cells)
+		end := threshold.Add(-time.Duration(i+1) * time.Minute)
+		isa.EndTime = &end
+		expired = append(expired, isa)
+	}
+	for _, isa := range append(expired, active, unbounded) {
+		_, _, err := store.InsertISA(ctx, isa, nil)
+		require.NoError(t, err)
+	}
+
+	// Deletes are capped at "limit".
+	count, err := store.DeleteExpiredISAs(ctx, threshold, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	count, err = store.DeleteExpiredISAs(ctx, threshold, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	count, err = store.DeleteExpiredISAs(ctx, threshold, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+
+	for _, isa := range expired {
+		_, err := store.GetISA(ctx, isa.ID)
+		require.Equal(t, sql.ErrNoRows, err)
+	}
+
+	// Cells of expired ISAs are gone, too.
+	isas, err := store.SearchISAs(ctx, cells, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	ids := []models.ID{}
+	for _, isa := range isas {
+		ids = append(ids, isa.ID)
+	}
+	require.ElementsMatch(t, []models.ID{active.ID, unbounded.ID}, ids)
+}
+
+func testDeleteExpiredSubscriptions(ctx context.Context, t *testing.T, store dss.Store) {
+	var (
+		threshold = now.Add(-time.Hour)
+		expired   []*models.Subscription
+		active    = newSubscription("you", cells)
+		unbounded = newSubscription("you", cells)
+	)
+	unbounded.EndTime = nil
+	for i := 0; i < 3; i++ {
+		sub := newSubscription("you", cells)
+		end := threshold.Add(-time.Duration(i+1) * time.Minute)
+		sub.EndTime = &end
+		expired = append(expired, sub)
+	}
+	for _, sub := range append(expired, active, unbounded) {
+		_, err := store.InsertSubscription(ctx, sub, 0)
+		require.NoError(t, err)
+	}
+
+	count, err := store.DeleteExpiredSubscriptions(ctx, threshold, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	count, err = store.DeleteExpiredSubscriptions(ctx, threshold, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	count, err = store.DeleteExpiredSubscriptions(ctx, threshold, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+
+	for _, sub := range expired {
+		_, err := store.GetSubscription(ctx, sub.ID)
+		require.Equal(t, sql.ErrNoRows, err)
+	}
+
+	// Cells of expired subscriptions are gone, too.
+	found, err := store.SearchSubscriptions(ctx, cells, "you", nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []models.ID{active.ID, unbounded.ID}, subscriptionIDs(found))
+}
+
+func testConcurrentInserts(ctx context.Context, t *testing.T, store dss.Store) {
+	const n = 10
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 2*n)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := store.InsertISA(ctx, newISA(models.Owner(fmt.Sprintf("isa-owner-%d", i)), cells), nil)
+			errs <- err
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.InsertSubscription(ctx, newSubscription(models.Owner(fmt.Sprintf("subscription-owner-%d", i)), cells), 0)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	isas, err := store.SearchISAs(ctx, cells, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, isas, n)
+
+	_, subscribers, err := store.InsertISA(ctx, newISA("late-comer", cells), nil)
+	require.NoError(t, err)
+	require.Len(t, subscribers, n)
+}
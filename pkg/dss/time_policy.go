@@ -0,0 +1,85 @@
+package dss
+
+import (
+	"fmt"
+	"time"
+
+	dsserr "github.com/steeling/InterUSS-Platform/pkg/errors"
+)
+
+var (
+	// DefaultISATimePolicy applies to the fields of Server.ISATimePolicy that
+	// are not set.
+	DefaultISATimePolicy = TimePolicy{
+		MaxDuration:     24 * time.Hour,
+		MaxLeadTime:     24 * time.Hour,
+		DefaultDuration: time.Hour,
+	}
+	// DefaultSubscriptionTimePolicy applies to the fields of
+	// Server.SubscriptionTimePolicy that are not set.
+	DefaultSubscriptionTimePolicy = TimePolicy{
+		MaxDuration:     24 * time.Hour,
+		MaxLeadTime:     24 * time.Hour,
+		DefaultDuration: 24 * time.Hour,
+	}
+)
+
+// TimePolicy bounds the time windows of IdentificationServiceAreas or
+// subscriptions.
+type TimePolicy struct {
+	// MaxDuration bounds the duration between start and end time.
+	MaxDuration time.Duration
+	// MaxLeadTime bounds how far in the future a time window may start.
+	MaxLeadTime time.Duration
+	// DefaultDuration is the duration of time windows without an end time.
+	// It must not exceed MaxDuration.
+	DefaultDuration time.Duration
+}
+
+// withDefaults returns p with all fields that are not positive replaced by
+// the corresponding fields of "defaults".
+func (p TimePolicy) withDefaults(defaults TimePolicy) TimePolicy {
+	if p.MaxDuration <= 0 {
+		p.MaxDuration = defaults.MaxDuration
+	}
+	if p.MaxLeadTime <= 0 {
+		p.MaxLeadTime = defaults.MaxLeadTime
+	}
+	if p.DefaultDuration <= 0 {
+		p.DefaultDuration = defaults.DefaultDuration
+	}
+	if p.DefaultDuration > p.MaxDuration {
+		p.DefaultDuration = p.MaxDuration
+	}
+	return p
+}
+
+// apply checks the time window from "start" to "end" against p at "now" and
+// returns the resulting window. A missing start time defaults to "now", a
+// missing end time to DefaultDuration after the start time. Violations are
+// reported as BadRequest errors.
+func (p TimePolicy) apply(now time.Time, start, end *time.Time) (*time.Time, *time.Time, error) {
+	if start == nil {
+		start = &now
+	}
+	if end == nil {
+		e := start.Add(p.DefaultDuration)
+		end = &e
+	}
+
+	switch {
+	case end.Before(now):
+		return nil, nil, dsserr.BadRequest(fmt.Sprintf("time_end %s is in the past", formatTime(*end)))
+	case !start.Before(*end):
+		return nil, nil, dsserr.BadRequest(fmt.Sprintf("time_start %s is not before time_end %s", formatTime(*start), formatTime(*end)))
+	case start.Sub(now) > p.MaxLeadTime:
+		return nil, nil, dsserr.BadRequest(fmt.Sprintf("time_start %s is more than %s in the future", formatTime(*start), p.MaxLeadTime))
+	case end.Sub(*start) > p.MaxDuration:
+		return nil, nil, dsserr.BadRequest(fmt.Sprintf("duration %s from time_start to time_end exceeds the maximum of %s", end.Sub(*start), p.MaxDuration))
+	}
+	return start, end, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,110 @@
+package dss
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestTimePolicy(t *testing.T) {
+	var (
+		now    = time.Date(2019, 8, 1, 12, 0, 0, 0, time.UTC)
+		policy = TimePolicy{
+			MaxDuration:     24 * time.Hour,
+			MaxLeadTime:     2 * time.Hour,
+			DefaultDuration: time.Hour,
+		}
+	)
+	for _, r := range []struct {
+		name       string
+		start, end *time.Time
+		wantStart  time.Time
+		wantEnd    time.Time
+		message    string
+	}{
+		{
+			name:      "start and end",
+			start:     timePtr(now.Add(time.Hour)),
+			end:       timePtr(now.Add(3 * time.Hour)),
+			wantStart: now.Add(time.Hour),
+			wantEnd:   now.Add(3 * time.Hour),
+		},
+		{
+			name:      "ongoing",
+			start:     timePtr(now.Add(-time.Hour)),
+			end:       timePtr(now.Add(time.Hour)),
+			wantStart: now.Add(-time.Hour),
+			wantEnd:   now.Add(time.Hour),
+		},
+		{
+			name:      "missing start",
+			end:       timePtr(now.Add(3 * time.Hour)),
+			wantStart: now,
+			wantEnd:   now.Add(3 * time.Hour),
+		},
+		{
+			name:      "missing end",
+			start:     timePtr(now.Add(time.Hour)),
+			wantStart: now.Add(time.Hour),
+			wantEnd:   now.Add(2 * time.Hour),
+		},
+		{
+			name:      "missing start and end",
+			wantStart: now,
+			wantEnd:   now.Add(time.Hour),
+		},
+		{
+			name:    "end in the past",
+			start:   timePtr(now.Add(-2 * time.Hour)),
+			end:     timePtr(now.Add(-time.Hour)),
+			message: "time_end 2019-08-01T11:00:00Z is in the past",
+		},
+		{
+			name:    "start after end",
+			start:   timePtr(now.Add(2 * time.Hour)),
+			end:     timePtr(now.Add(time.Hour)),
+			message: "time_start 2019-08-01T14:00:00Z is not before time_end 2019-08-01T13:00:00Z",
+		},
+		{
+			name:    "start too far in the future",
+			start:   timePtr(now.Add(3 * time.Hour)),
+			message: "time_start 2019-08-01T15:00:00Z is more than 2h0m0s in the future",
+		},
+		{
+			name:    "too long",
+			start:   timePtr(now.Add(-time.Hour)),
+			end:     timePtr(now.Add(24 * time.Hour)),
+			message: "duration 25h0m0s from time_start to time_end exceeds the maximum of 24h0m0s",
+		},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			start, end, err := policy.apply(now, r.start, r.end)
+			if r.message != "" {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				require.Equal(t, r.message, status.Convert(err).Message())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, r.wantStart, *start)
+			require.Equal(t, r.wantEnd, *end)
+		})
+	}
+}
+
+func TestTimePolicyDefaults(t *testing.T) {
+	require.Equal(t, DefaultSubscriptionTimePolicy, TimePolicy{}.withDefaults(DefaultSubscriptionTimePolicy))
+
+	// The default duration never exceeds the maximum duration.
+	require.Equal(t, TimePolicy{
+		MaxDuration:     time.Hour,
+		MaxLeadTime:     DefaultSubscriptionTimePolicy.MaxLeadTime,
+		DefaultDuration: time.Hour,
+	}, TimePolicy{MaxDuration: time.Hour}.withDefaults(DefaultSubscriptionTimePolicy))
+}
@@ -9,6 +9,7 @@ import (
 	proto "github.com/golang/protobuf/proto"
 	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
+	_ "github.com/grpc-ecosystem/grpc-gateway/protoc-gen-swagger/options"
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -27,6 +28,87 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type SubscriptionEvent_Type int32
+
+const (
+	SubscriptionEvent_UNKNOWN SubscriptionEvent_Type = 0
+	SubscriptionEvent_CREATED SubscriptionEvent_Type = 1
+	SubscriptionEvent_UPDATED SubscriptionEvent_Type = 2
+	SubscriptionEvent_DELETED SubscriptionEvent_Type = 3
+)
+
+var SubscriptionEvent_Type_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "CREATED",
+	2: "UPDATED",
+	3: "DELETED",
+}
+
+var SubscriptionEvent_Type_value = map[string]int32{
+	"UNKNOWN": 0,
+	"CREATED": 1,
+	"UPDATED": 2,
+	"DELETED": 3,
+}
+
+func (x SubscriptionEvent_Type) String() string {
+	return proto.EnumName(SubscriptionEvent_Type_name, int32(x))
+}
+
+func (SubscriptionEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_e6b4bd547de77484, []int{27, 0}
+}
+
+// A circular area on the earth.
+type Circle struct {
+	// Center of the circle.
+	Center *LatLngPoint `protobuf:"bytes,1,opt,name=center,proto3" json:"center,omitempty"`
+	// Radius of the circle in meters, measured along the earth's surface.
+	Radius               float32  `protobuf:"fixed32,2,opt,name=radius,proto3" json:"radius,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Circle) Reset()         { *m = Circle{} }
+func (m *Circle) String() string { return proto.CompactTextString(m) }
+func (*Circle) ProtoMessage()    {}
+func (*Circle) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e6b4bd547de77484, []int{0}
+}
+
+func (m *Circle) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Circle.Unmarshal(m, b)
+}
+func (m *Circle) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Circle.Marshal(b, m, deterministic)
+}
+func (m *Circle) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Circle.Merge(m, src)
+}
+func (m *Circle) XXX_Size() int {
+	return xxx_messageInfo_Circle.Size(m)
+}
+func (m *Circle) XXX_DiscardUnknown() {
+	xxx_messageInfo_Circle.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Circle proto.InternalMessageInfo
+
+func (m *Circle) GetCenter() *LatLngPoint {
+	if m != nil {
+		return m.Center
+	}
+	return nil
+}
+
+func (m *Circle) GetRadius() float32 {
+	if m != nil {
+		return m.Radius
+	}
+	return 0
+}
+
 type DeleteIdentificationServiceAreaRequest struct {
 	// UUIDv4 of the Identification Service Area.
 	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -42,7 +124,7 @@ func (m *DeleteIdentificationServiceAreaRequest) Reset() {
 func (m *DeleteIdentificationServiceAreaRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteIdentificationServiceAreaRequest) ProtoMessage()    {}
 func (*DeleteIdentificationServiceAreaRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{0}
+	return fileDescriptor_e6b4bd547de77484, []int{1}
 }
 
 func (m *DeleteIdentificationServiceAreaRequest) XXX_Unmarshal(b []byte) error {
@@ -93,7 +175,7 @@ func (m *DeleteIdentificationServiceAreaResponse) Reset() {
 func (m *DeleteIdentificationServiceAreaResponse) String() string { return proto.CompactTextString(m) }
 func (*DeleteIdentificationServiceAreaResponse) ProtoMessage()    {}
 func (*DeleteIdentificationServiceAreaResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{1}
+	return fileDescriptor_e6b4bd547de77484, []int{2}
 }
 
 func (m *DeleteIdentificationServiceAreaResponse) XXX_Unmarshal(b []byte) error {
@@ -141,7 +223,7 @@ func (m *DeleteSubscriptionRequest) Reset()         { *m = DeleteSubscriptionReq
 func (m *DeleteSubscriptionRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteSubscriptionRequest) ProtoMessage()    {}
 func (*DeleteSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{2}
+	return fileDescriptor_e6b4bd547de77484, []int{3}
 }
 
 func (m *DeleteSubscriptionRequest) XXX_Unmarshal(b []byte) error {
@@ -188,7 +270,7 @@ func (m *DeleteSubscriptionResponse) Reset()         { *m = DeleteSubscriptionRe
 func (m *DeleteSubscriptionResponse) String() string { return proto.CompactTextString(m) }
 func (*DeleteSubscriptionResponse) ProtoMessage()    {}
 func (*DeleteSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{3}
+	return fileDescriptor_e6b4bd547de77484, []int{4}
 }
 
 func (m *DeleteSubscriptionResponse) XXX_Unmarshal(b []byte) error {
@@ -229,7 +311,7 @@ func (m *ErrorResponse) Reset()         { *m = ErrorResponse{} }
 func (m *ErrorResponse) String() string { return proto.CompactTextString(m) }
 func (*ErrorResponse) ProtoMessage()    {}
 func (*ErrorResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{4}
+	return fileDescriptor_e6b4bd547de77484, []int{5}
 }
 
 func (m *ErrorResponse) XXX_Unmarshal(b []byte) error {
@@ -274,7 +356,7 @@ func (m *GeoPolygon) Reset()         { *m = GeoPolygon{} }
 func (m *GeoPolygon) String() string { return proto.CompactTextString(m) }
 func (*GeoPolygon) ProtoMessage()    {}
 func (*GeoPolygon) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{5}
+	return fileDescriptor_e6b4bd547de77484, []int{6}
 }
 
 func (m *GeoPolygon) XXX_Unmarshal(b []byte) error {
@@ -314,7 +396,7 @@ func (m *GetIdentificationServiceAreaRequest) Reset()         { *m = GetIdentifi
 func (m *GetIdentificationServiceAreaRequest) String() string { return proto.CompactTextString(m) }
 func (*GetIdentificationServiceAreaRequest) ProtoMessage()    {}
 func (*GetIdentificationServiceAreaRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{6}
+	return fileDescriptor_e6b4bd547de77484, []int{7}
 }
 
 func (m *GetIdentificationServiceAreaRequest) XXX_Unmarshal(b []byte) error {
@@ -354,7 +436,7 @@ func (m *GetIdentificationServiceAreaResponse) Reset()         { *m = GetIdentif
 func (m *GetIdentificationServiceAreaResponse) String() string { return proto.CompactTextString(m) }
 func (*GetIdentificationServiceAreaResponse) ProtoMessage()    {}
 func (*GetIdentificationServiceAreaResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{7}
+	return fileDescriptor_e6b4bd547de77484, []int{8}
 }
 
 func (m *GetIdentificationServiceAreaResponse) XXX_Unmarshal(b []byte) error {
@@ -394,7 +476,7 @@ func (m *GetSubscriptionRequest) Reset()         { *m = GetSubscriptionRequest{}
 func (m *GetSubscriptionRequest) String() string { return proto.CompactTextString(m) }
 func (*GetSubscriptionRequest) ProtoMessage()    {}
 func (*GetSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{8}
+	return fileDescriptor_e6b4bd547de77484, []int{9}
 }
 
 func (m *GetSubscriptionRequest) XXX_Unmarshal(b []byte) error {
@@ -434,7 +516,7 @@ func (m *GetSubscriptionResponse) Reset()         { *m = GetSubscriptionResponse
 func (m *GetSubscriptionResponse) String() string { return proto.CompactTextString(m) }
 func (*GetSubscriptionResponse) ProtoMessage()    {}
 func (*GetSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{9}
+	return fileDescriptor_e6b4bd547de77484, []int{10}
 }
 
 func (m *GetSubscriptionResponse) XXX_Unmarshal(b []byte) error {
@@ -471,8 +553,12 @@ type IdentificationServiceArea struct {
 	// End time of service.  RFC 3339 format, per OpenAPI specification.
 	TimeEnd *timestamp.Timestamp `protobuf:"bytes,4,opt,name=time_end,json=timeEnd,proto3" json:"time_end,omitempty"`
 	// Beginning time of service.  RFC 3339 format, per OpenAPI specification.
-	TimeStart            *timestamp.Timestamp `protobuf:"bytes,5,opt,name=time_start,json=timeStart,proto3" json:"time_start,omitempty"`
-	Version              string               `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`
+	TimeStart *timestamp.Timestamp `protobuf:"bytes,5,opt,name=time_start,json=timeStart,proto3" json:"time_start,omitempty"`
+	Version   string               `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`
+	// Upper altitude bound of service in meters above the WGS84 ellipsoid, if any.
+	AltitudeHi *wrappers.FloatValue `protobuf:"bytes,7,opt,name=altitude_hi,json=altitudeHi,proto3" json:"altitude_hi,omitempty"`
+	// Lower altitude bound of service in meters above the WGS84 ellipsoid, if any.
+	AltitudeLo           *wrappers.FloatValue `protobuf:"bytes,8,opt,name=altitude_lo,json=altitudeLo,proto3" json:"altitude_lo,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
 	XXX_unrecognized     []byte               `json:"-"`
 	XXX_sizecache        int32                `json:"-"`
@@ -482,7 +568,7 @@ func (m *IdentificationServiceArea) Reset()         { *m = IdentificationService
 func (m *IdentificationServiceArea) String() string { return proto.CompactTextString(m) }
 func (*IdentificationServiceArea) ProtoMessage()    {}
 func (*IdentificationServiceArea) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{10}
+	return fileDescriptor_e6b4bd547de77484, []int{11}
 }
 
 func (m *IdentificationServiceArea) XXX_Unmarshal(b []byte) error {
@@ -545,6 +631,20 @@ func (m *IdentificationServiceArea) GetVersion() string {
 	return ""
 }
 
+func (m *IdentificationServiceArea) GetAltitudeHi() *wrappers.FloatValue {
+	if m != nil {
+		return m.AltitudeHi
+	}
+	return nil
+}
+
+func (m *IdentificationServiceArea) GetAltitudeLo() *wrappers.FloatValue {
+	if m != nil {
+		return m.AltitudeLo
+	}
+	return nil
+}
+
 // Point on the earth's surface.
 type LatLngPoint struct {
 	Lat                  float64  `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
@@ -558,7 +658,7 @@ func (m *LatLngPoint) Reset()         { *m = LatLngPoint{} }
 func (m *LatLngPoint) String() string { return proto.CompactTextString(m) }
 func (*LatLngPoint) ProtoMessage()    {}
 func (*LatLngPoint) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{11}
+	return fileDescriptor_e6b4bd547de77484, []int{12}
 }
 
 func (m *LatLngPoint) XXX_Unmarshal(b []byte) error {
@@ -609,7 +709,7 @@ func (m *PutIdentificationServiceAreaParameters) Reset() {
 func (m *PutIdentificationServiceAreaParameters) String() string { return proto.CompactTextString(m) }
 func (*PutIdentificationServiceAreaParameters) ProtoMessage()    {}
 func (*PutIdentificationServiceAreaParameters) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{12}
+	return fileDescriptor_e6b4bd547de77484, []int{13}
 }
 
 func (m *PutIdentificationServiceAreaParameters) XXX_Unmarshal(b []byte) error {
@@ -664,7 +764,7 @@ func (m *PutIdentificationServiceAreaRequest) Reset()         { *m = PutIdentifi
 func (m *PutIdentificationServiceAreaRequest) String() string { return proto.CompactTextString(m) }
 func (*PutIdentificationServiceAreaRequest) ProtoMessage()    {}
 func (*PutIdentificationServiceAreaRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{13}
+	return fileDescriptor_e6b4bd547de77484, []int{14}
 }
 
 func (m *PutIdentificationServiceAreaRequest) XXX_Unmarshal(b []byte) error {
@@ -713,7 +813,7 @@ func (m *PutIdentificationServiceAreaResponse) Reset()         { *m = PutIdentif
 func (m *PutIdentificationServiceAreaResponse) String() string { return proto.CompactTextString(m) }
 func (*PutIdentificationServiceAreaResponse) ProtoMessage()    {}
 func (*PutIdentificationServiceAreaResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{14}
+	return fileDescriptor_e6b4bd547de77484, []int{15}
 }
 
 func (m *PutIdentificationServiceAreaResponse) XXX_Unmarshal(b []byte) error {
@@ -762,7 +862,7 @@ func (m *PutSubscriptionParameters) Reset()         { *m = PutSubscriptionParame
 func (m *PutSubscriptionParameters) String() string { return proto.CompactTextString(m) }
 func (*PutSubscriptionParameters) ProtoMessage()    {}
 func (*PutSubscriptionParameters) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{15}
+	return fileDescriptor_e6b4bd547de77484, []int{16}
 }
 
 func (m *PutSubscriptionParameters) XXX_Unmarshal(b []byte) error {
@@ -817,7 +917,7 @@ func (m *PutSubscriptionRequest) Reset()         { *m = PutSubscriptionRequest{}
 func (m *PutSubscriptionRequest) String() string { return proto.CompactTextString(m) }
 func (*PutSubscriptionRequest) ProtoMessage()    {}
 func (*PutSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{16}
+	return fileDescriptor_e6b4bd547de77484, []int{17}
 }
 
 func (m *PutSubscriptionRequest) XXX_Unmarshal(b []byte) error {
@@ -866,7 +966,7 @@ func (m *PutSubscriptionResponse) Reset()         { *m = PutSubscriptionResponse
 func (m *PutSubscriptionResponse) String() string { return proto.CompactTextString(m) }
 func (*PutSubscriptionResponse) ProtoMessage()    {}
 func (*PutSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{17}
+	return fileDescriptor_e6b4bd547de77484, []int{18}
 }
 
 func (m *PutSubscriptionResponse) XXX_Unmarshal(b []byte) error {
@@ -902,7 +1002,7 @@ func (m *PutSubscriptionResponse) GetSubscription() *Subscription {
 }
 
 type SearchIdentificationServiceAreasRequest struct {
-	// The area in which to search for Identification Service Areas.  Some Identification Service Areas near this area but wholly outside it may also be returned.
+	// The area in which to search for Identification Service Areas.  Some Identification Service Areas near this area but wholly outside it may also be returned.  Either a comma-separated list of vertices `lat1,lng1,lat2,lng2,lat3,lng3,...` or a GeoJSON Polygon or MultiPolygon geometry, or a GeoJSON Feature with such a geometry.
 	Area string `protobuf:"bytes,1,opt,name=area,proto3" json:"area,omitempty"`
 	// If specified, indicates non-interest in any Identification Service Areas that end before this time.  RFC 3339 format, per OpenAPI specification.
 	EarliestTime *timestamp.Timestamp `protobuf:"bytes,2,opt,name=earliest_time,json=earliestTime,proto3" json:"earliest_time,omitempty"`
@@ -911,7 +1011,11 @@ type SearchIdentificationServiceAreasRequest struct {
 	// If specified, indicates non-interest in Identification Service Areas entirely above this altitude in meters above the WGS84 ellipsoid.
 	MaxAltitude float64 `protobuf:"fixed64,4,opt,name=max_altitude,json=maxAltitude,proto3" json:"max_altitude,omitempty"`
 	// If specified, indicates non-interest in Identification Service Areas entirely below this altitude in meters above the WGS84 ellipsoid.
-	MinAltitude          float64  `protobuf:"fixed64,5,opt,name=min_altitude,json=minAltitude,proto3" json:"min_altitude,omitempty"`
+	MinAltitude float64 `protobuf:"fixed64,5,opt,name=min_altitude,json=minAltitude,proto3" json:"min_altitude,omitempty"`
+	// Maximum number of Identification Service Areas to return.  If unset or above the maximum page size of the DSS, the maximum page size applies.
+	PageSize int32 `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token returned as `next_page_token` by a previous search with the same parameters, to retrieve the next page of results.
+	PageToken            string   `protobuf:"bytes,7,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -923,7 +1027,7 @@ func (m *SearchIdentificationServiceAreasRequest) Reset() {
 func (m *SearchIdentificationServiceAreasRequest) String() string { return proto.CompactTextString(m) }
 func (*SearchIdentificationServiceAreasRequest) ProtoMessage()    {}
 func (*SearchIdentificationServiceAreasRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{18}
+	return fileDescriptor_e6b4bd547de77484, []int{19}
 }
 
 func (m *SearchIdentificationServiceAreasRequest) XXX_Unmarshal(b []byte) error {
@@ -979,13 +1083,29 @@ func (m *SearchIdentificationServiceAreasRequest) GetMinAltitude() float64 {
 	return 0
 }
 
+func (m *SearchIdentificationServiceAreasRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *SearchIdentificationServiceAreasRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
 // Response to DSS query for Identification Service Areas in an area of interest.
 type SearchIdentificationServiceAreasResponse struct {
 	// Identification Service Areas in the area of interest.
-	ServiceAreas         []*IdentificationServiceArea `protobuf:"bytes,1,rep,name=service_areas,json=serviceAreas,proto3" json:"service_areas,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                     `json:"-"`
-	XXX_unrecognized     []byte                       `json:"-"`
-	XXX_sizecache        int32                        `json:"-"`
+	ServiceAreas []*IdentificationServiceArea `protobuf:"bytes,1,rep,name=service_areas,json=serviceAreas,proto3" json:"service_areas,omitempty"`
+	// Opaque token to pass as `page_token` to retrieve the next page of results.  Empty if there are no more results.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *SearchIdentificationServiceAreasResponse) Reset() {
@@ -994,7 +1114,7 @@ func (m *SearchIdentificationServiceAreasResponse) Reset() {
 func (m *SearchIdentificationServiceAreasResponse) String() string { return proto.CompactTextString(m) }
 func (*SearchIdentificationServiceAreasResponse) ProtoMessage()    {}
 func (*SearchIdentificationServiceAreasResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{19}
+	return fileDescriptor_e6b4bd547de77484, []int{20}
 }
 
 func (m *SearchIdentificationServiceAreasResponse) XXX_Unmarshal(b []byte) error {
@@ -1022,9 +1142,20 @@ func (m *SearchIdentificationServiceAreasResponse) GetServiceAreas() []*Identifi
 	return nil
 }
 
+func (m *SearchIdentificationServiceAreasResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
 type SearchSubscriptionsRequest struct {
-	// The area in which to search for Subscriptions.  Some Subscriptions near this area but wholly outside it may also be returned.
-	Area                 string   `protobuf:"bytes,1,opt,name=area,proto3" json:"area,omitempty"`
+	// The area in which to search for Subscriptions.  Some Subscriptions near this area but wholly outside it may also be returned.  Either a comma-separated list of vertices `lat1,lng1,lat2,lng2,lat3,lng3,...` or a GeoJSON Polygon or MultiPolygon geometry, or a GeoJSON Feature with such a geometry.
+	Area string `protobuf:"bytes,1,opt,name=area,proto3" json:"area,omitempty"`
+	// Maximum number of Subscriptions to return.  If unset or above the maximum page size of the DSS, the maximum page size applies.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token returned as `next_page_token` by a previous search with the same parameters, to retrieve the next page of results.
+	PageToken            string   `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1034,7 +1165,7 @@ func (m *SearchSubscriptionsRequest) Reset()         { *m = SearchSubscriptionsR
 func (m *SearchSubscriptionsRequest) String() string { return proto.CompactTextString(m) }
 func (*SearchSubscriptionsRequest) ProtoMessage()    {}
 func (*SearchSubscriptionsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{20}
+	return fileDescriptor_e6b4bd547de77484, []int{21}
 }
 
 func (m *SearchSubscriptionsRequest) XXX_Unmarshal(b []byte) error {
@@ -1062,20 +1193,36 @@ func (m *SearchSubscriptionsRequest) GetArea() string {
 	return ""
 }
 
+func (m *SearchSubscriptionsRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *SearchSubscriptionsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
 // Response to DSS query for subscriptions in a particular area.
 type SearchSubscriptionsResponse struct {
 	// Subscriptions that overlap the specified area.
-	Subscriptions        []*Subscription `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	Subscriptions []*Subscription `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// Opaque token to pass as `page_token` to retrieve the next page of results.  Empty if there are no more results.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *SearchSubscriptionsResponse) Reset()         { *m = SearchSubscriptionsResponse{} }
 func (m *SearchSubscriptionsResponse) String() string { return proto.CompactTextString(m) }
 func (*SearchSubscriptionsResponse) ProtoMessage()    {}
 func (*SearchSubscriptionsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{21}
+	return fileDescriptor_e6b4bd547de77484, []int{22}
 }
 
 func (m *SearchSubscriptionsResponse) XXX_Unmarshal(b []byte) error {
@@ -1103,6 +1250,13 @@ func (m *SearchSubscriptionsResponse) GetSubscriptions() []*Subscription {
 	return nil
 }
 
+func (m *SearchSubscriptionsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
 // Subscriber to notify of a creation/change/deletion of a change in the airspace.  This is provided by the DSS to a client changing the airspace, and it is the responsibility of the client changing the airspace (they will receive a set of these notification requests) to send a notification to each specified `url`.
 type SubscriberToNotify struct {
 	// Subscription(s) prompting this notification.
@@ -1117,7 +1271,7 @@ func (m *SubscriberToNotify) Reset()         { *m = SubscriberToNotify{} }
 func (m *SubscriberToNotify) String() string { return proto.CompactTextString(m) }
 func (*SubscriberToNotify) ProtoMessage()    {}
 func (*SubscriberToNotify) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{22}
+	return fileDescriptor_e6b4bd547de77484, []int{23}
 }
 
 func (m *SubscriberToNotify) XXX_Unmarshal(b []byte) error {
@@ -1161,18 +1315,22 @@ type Subscription struct {
 	Id                string               `protobuf:"bytes,4,opt,name=id,proto3" json:"id,omitempty"`
 	NotificationIndex int32                `protobuf:"varint,5,opt,name=notification_index,json=notificationIndex,proto3" json:"notification_index,omitempty"`
 	// Assigned by the DSS based on creating client’s ID (via access token).  Used for restricting mutation and deletion operations to owner.
-	Owner                string   `protobuf:"bytes,6,opt,name=owner,proto3" json:"owner,omitempty"`
-	Version              string   `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Owner   string `protobuf:"bytes,6,opt,name=owner,proto3" json:"owner,omitempty"`
+	Version string `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`
+	// Upper altitude bound of the subscription in meters above the WGS84 ellipsoid, if any.
+	AltitudeHi *wrappers.FloatValue `protobuf:"bytes,8,opt,name=altitude_hi,json=altitudeHi,proto3" json:"altitude_hi,omitempty"`
+	// Lower altitude bound of the subscription in meters above the WGS84 ellipsoid, if any.
+	AltitudeLo           *wrappers.FloatValue `protobuf:"bytes,9,opt,name=altitude_lo,json=altitudeLo,proto3" json:"altitude_lo,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *Subscription) Reset()         { *m = Subscription{} }
 func (m *Subscription) String() string { return proto.CompactTextString(m) }
 func (*Subscription) ProtoMessage()    {}
 func (*Subscription) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{23}
+	return fileDescriptor_e6b4bd547de77484, []int{24}
 }
 
 func (m *Subscription) XXX_Unmarshal(b []byte) error {
@@ -1242,6 +1400,20 @@ func (m *Subscription) GetVersion() string {
 	return ""
 }
 
+func (m *Subscription) GetAltitudeHi() *wrappers.FloatValue {
+	if m != nil {
+		return m.AltitudeHi
+	}
+	return nil
+}
+
+func (m *Subscription) GetAltitudeLo() *wrappers.FloatValue {
+	if m != nil {
+		return m.AltitudeLo
+	}
+	return nil
+}
+
 // Endpoints that should be called when an applicable event occurs.  At least one field must be specified.
 type SubscriptionCallbacks struct {
 	IdentificationServiceAreaUrl string   `protobuf:"bytes,1,opt,name=identification_service_area_url,json=identificationServiceAreaUrl,proto3" json:"identification_service_area_url,omitempty"`
@@ -1254,7 +1426,7 @@ func (m *SubscriptionCallbacks) Reset()         { *m = SubscriptionCallbacks{} }
 func (m *SubscriptionCallbacks) String() string { return proto.CompactTextString(m) }
 func (*SubscriptionCallbacks) ProtoMessage()    {}
 func (*SubscriptionCallbacks) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{24}
+	return fileDescriptor_e6b4bd547de77484, []int{25}
 }
 
 func (m *SubscriptionCallbacks) XXX_Unmarshal(b []byte) error {
@@ -1295,7 +1467,7 @@ func (m *SubscriptionState) Reset()         { *m = SubscriptionState{} }
 func (m *SubscriptionState) String() string { return proto.CompactTextString(m) }
 func (*SubscriptionState) ProtoMessage()    {}
 func (*SubscriptionState) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{25}
+	return fileDescriptor_e6b4bd547de77484, []int{26}
 }
 
 func (m *SubscriptionState) XXX_Unmarshal(b []byte) error {
@@ -1330,21 +1502,84 @@ func (m *SubscriptionState) GetSubscription() string {
 	return ""
 }
 
+// Change to an Identification Service Area intersecting a watched subscription.
+type SubscriptionEvent struct {
+	Type SubscriptionEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=dssproto.SubscriptionEvent_Type" json:"type,omitempty"`
+	// The Identification Service Area after the change, or as it was before its deletion.
+	ServiceArea *IdentificationServiceArea `protobuf:"bytes,2,opt,name=service_area,json=serviceArea,proto3" json:"service_area,omitempty"`
+	// The watched subscription and its notification index after the change.
+	Subscription         *SubscriptionState `protobuf:"bytes,3,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *SubscriptionEvent) Reset()         { *m = SubscriptionEvent{} }
+func (m *SubscriptionEvent) String() string { return proto.CompactTextString(m) }
+func (*SubscriptionEvent) ProtoMessage()    {}
+func (*SubscriptionEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e6b4bd547de77484, []int{27}
+}
+
+func (m *SubscriptionEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscriptionEvent.Unmarshal(m, b)
+}
+func (m *SubscriptionEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscriptionEvent.Marshal(b, m, deterministic)
+}
+func (m *SubscriptionEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscriptionEvent.Merge(m, src)
+}
+func (m *SubscriptionEvent) XXX_Size() int {
+	return xxx_messageInfo_SubscriptionEvent.Size(m)
+}
+func (m *SubscriptionEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscriptionEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscriptionEvent proto.InternalMessageInfo
+
+func (m *SubscriptionEvent) GetType() SubscriptionEvent_Type {
+	if m != nil {
+		return m.Type
+	}
+	return SubscriptionEvent_UNKNOWN
+}
+
+func (m *SubscriptionEvent) GetServiceArea() *IdentificationServiceArea {
+	if m != nil {
+		return m.ServiceArea
+	}
+	return nil
+}
+
+func (m *SubscriptionEvent) GetSubscription() *SubscriptionState {
+	if m != nil {
+		return m.Subscription
+	}
+	return nil
+}
+
 // A three-dimensional geographic volume consisting of a vertically-extruded polygon.
+// Exactly one of footprint, footprint_circle and footprint_geojson must be set.
 type Volume3D struct {
-	AltitudeHi           *wrappers.FloatValue `protobuf:"bytes,1,opt,name=altitude_hi,json=altitudeHi,proto3" json:"altitude_hi,omitempty"`
-	AltitudeLo           *wrappers.FloatValue `protobuf:"bytes,2,opt,name=altitude_lo,json=altitudeLo,proto3" json:"altitude_lo,omitempty"`
-	Footprint            *GeoPolygon          `protobuf:"bytes,3,opt,name=footprint,proto3" json:"footprint,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	AltitudeHi *wrappers.FloatValue `protobuf:"bytes,1,opt,name=altitude_hi,json=altitudeHi,proto3" json:"altitude_hi,omitempty"`
+	AltitudeLo *wrappers.FloatValue `protobuf:"bytes,2,opt,name=altitude_lo,json=altitudeLo,proto3" json:"altitude_lo,omitempty"`
+	Footprint  *GeoPolygon          `protobuf:"bytes,3,opt,name=footprint,proto3" json:"footprint,omitempty"`
+	// Circular footprint, e.g. a radius around a launch point.
+	FootprintCircle *Circle `protobuf:"bytes,4,opt,name=footprint_circle,json=footprintCircle,proto3" json:"footprint_circle,omitempty"`
+	// Footprint given as a GeoJSON Polygon or MultiPolygon geometry, or as a GeoJSON Feature with such a geometry.  Positions are `[longitude, latitude]` in degrees.
+	FootprintGeojson     string   `protobuf:"bytes,5,opt,name=footprint_geojson,json=footprintGeojson,proto3" json:"footprint_geojson,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Volume3D) Reset()         { *m = Volume3D{} }
 func (m *Volume3D) String() string { return proto.CompactTextString(m) }
 func (*Volume3D) ProtoMessage()    {}
 func (*Volume3D) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{26}
+	return fileDescriptor_e6b4bd547de77484, []int{28}
 }
 
 func (m *Volume3D) XXX_Unmarshal(b []byte) error {
@@ -1386,6 +1621,20 @@ func (m *Volume3D) GetFootprint() *GeoPolygon {
 	return nil
 }
 
+func (m *Volume3D) GetFootprintCircle() *Circle {
+	if m != nil {
+		return m.FootprintCircle
+	}
+	return nil
+}
+
+func (m *Volume3D) GetFootprintGeojson() string {
+	if m != nil {
+		return m.FootprintGeojson
+	}
+	return ""
+}
+
 // Contiguous block of geographic spacetime.
 type Volume4D struct {
 	SpatialVolume *Volume3D `protobuf:"bytes,1,opt,name=spatial_volume,json=spatialVolume,proto3" json:"spatial_volume,omitempty"`
@@ -1402,7 +1651,7 @@ func (m *Volume4D) Reset()         { *m = Volume4D{} }
 func (m *Volume4D) String() string { return proto.CompactTextString(m) }
 func (*Volume4D) ProtoMessage()    {}
 func (*Volume4D) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e6b4bd547de77484, []int{27}
+	return fileDescriptor_e6b4bd547de77484, []int{29}
 }
 
 func (m *Volume4D) XXX_Unmarshal(b []byte) error {
@@ -1444,7 +1693,49 @@ func (m *Volume4D) GetTimeStart() *timestamp.Timestamp {
 	return nil
 }
 
+type WatchSubscriptionEventsRequest struct {
+	// UUIDV4 of the subscription of interest.
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchSubscriptionEventsRequest) Reset()         { *m = WatchSubscriptionEventsRequest{} }
+func (m *WatchSubscriptionEventsRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchSubscriptionEventsRequest) ProtoMessage()    {}
+func (*WatchSubscriptionEventsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e6b4bd547de77484, []int{30}
+}
+
+func (m *WatchSubscriptionEventsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchSubscriptionEventsRequest.Unmarshal(m, b)
+}
+func (m *WatchSubscriptionEventsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchSubscriptionEventsRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchSubscriptionEventsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchSubscriptionEventsRequest.Merge(m, src)
+}
+func (m *WatchSubscriptionEventsRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchSubscriptionEventsRequest.Size(m)
+}
+func (m *WatchSubscriptionEventsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchSubscriptionEventsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchSubscriptionEventsRequest proto.InternalMessageInfo
+
+func (m *WatchSubscriptionEventsRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("dssproto.SubscriptionEvent_Type", SubscriptionEvent_Type_name, SubscriptionEvent_Type_value)
+	proto.RegisterType((*Circle)(nil), "dssproto.Circle")
 	proto.RegisterType((*DeleteIdentificationServiceAreaRequest)(nil), "dssproto.DeleteIdentificationServiceAreaRequest")
 	proto.RegisterType((*DeleteIdentificationServiceAreaResponse)(nil), "dssproto.DeleteIdentificationServiceAreaResponse")
 	proto.RegisterType((*DeleteSubscriptionRequest)(nil), "dssproto.DeleteSubscriptionRequest")
@@ -1471,97 +1762,129 @@ func init() {
 	proto.RegisterType((*Subscription)(nil), "dssproto.Subscription")
 	proto.RegisterType((*SubscriptionCallbacks)(nil), "dssproto.SubscriptionCallbacks")
 	proto.RegisterType((*SubscriptionState)(nil), "dssproto.SubscriptionState")
+	proto.RegisterType((*SubscriptionEvent)(nil), "dssproto.SubscriptionEvent")
 	proto.RegisterType((*Volume3D)(nil), "dssproto.Volume3D")
 	proto.RegisterType((*Volume4D)(nil), "dssproto.Volume4D")
+	proto.RegisterType((*WatchSubscriptionEventsRequest)(nil), "dssproto.WatchSubscriptionEventsRequest")
 }
 
 func init() { proto.RegisterFile("pkg/dssproto/dss.proto", fileDescriptor_e6b4bd547de77484) }
 
 var fileDescriptor_e6b4bd547de77484 = []byte{
-	// 1317 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x57, 0xdd, 0x6e, 0xdc, 0x44,
-	0x14, 0x96, 0x9d, 0xe6, 0x67, 0xcf, 0x26, 0x2d, 0x1d, 0xda, 0x74, 0xe3, 0x44, 0x24, 0x71, 0x42,
-	0x9b, 0x56, 0x74, 0xd3, 0x6e, 0x5b, 0xa4, 0xfe, 0x40, 0x55, 0x91, 0xf4, 0x47, 0xaa, 0x50, 0xe4,
-	0x6d, 0x2b, 0x24, 0x24, 0x56, 0xb3, 0xbb, 0xb3, 0xdb, 0x51, 0xbd, 0xb6, 0x99, 0x99, 0x4d, 0x53,
-	0x21, 0x28, 0x70, 0xcb, 0x15, 0xe2, 0x01, 0x90, 0xb8, 0xe0, 0x0e, 0x24, 0x24, 0x7e, 0x24, 0x5e,
-	0x83, 0x17, 0xe0, 0x82, 0x6b, 0x9e, 0x01, 0x79, 0x76, 0xbc, 0x1e, 0xef, 0xda, 0x5e, 0x6f, 0xd5,
-	0x0b, 0xee, 0xec, 0xe3, 0xef, 0xcc, 0x7c, 0xe7, 0x7c, 0x67, 0x8e, 0xcf, 0xc0, 0x72, 0xf0, 0xac,
-	0xbb, 0xdb, 0xe6, 0x3c, 0x60, 0xbe, 0xf0, 0xc3, 0x87, 0xaa, 0x7c, 0x42, 0x0b, 0x91, 0xcd, 0x5a,
-	0xeb, 0xfa, 0x7e, 0xd7, 0x25, 0xbb, 0x38, 0xa0, 0xbb, 0xd8, 0xf3, 0x7c, 0x81, 0x05, 0xf5, 0x3d,
-	0x85, 0xb3, 0xd6, 0xd5, 0x57, 0xf9, 0xd6, 0xec, 0x77, 0x76, 0x05, 0xed, 0x11, 0x2e, 0x70, 0x2f,
-	0x50, 0x80, 0xb7, 0x46, 0x01, 0xcf, 0x19, 0x0e, 0x02, 0xc2, 0xd4, 0x02, 0xb6, 0x03, 0x67, 0xf7,
-	0x88, 0x4b, 0x04, 0x79, 0xd0, 0x26, 0x9e, 0xa0, 0x1d, 0xda, 0x92, 0xeb, 0xd7, 0x09, 0x3b, 0xa4,
-	0x2d, 0x72, 0x87, 0x11, 0xec, 0x90, 0x4f, 0xfb, 0x84, 0x0b, 0x74, 0x1c, 0x4c, 0xda, 0xae, 0x18,
-	0x1b, 0xc6, 0x4e, 0xc9, 0x31, 0x69, 0x1b, 0x55, 0x60, 0xfe, 0x90, 0x30, 0x4e, 0x7d, 0xaf, 0x62,
-	0x4a, 0x63, 0xf4, 0x6a, 0xff, 0x62, 0xc0, 0xb9, 0x89, 0x8b, 0xf2, 0xc0, 0xf7, 0x38, 0x41, 0x77,
-	0x61, 0x91, 0x0f, 0xcc, 0x0d, 0xcc, 0x08, 0x96, 0xeb, 0x97, 0x6b, 0x5b, 0xd5, 0x28, 0xfe, 0x6a,
-	0xf6, 0x12, 0x65, 0x1e, 0xbf, 0xa0, 0xf7, 0xa1, 0xcc, 0xfb, 0x4d, 0xde, 0x62, 0xb4, 0x49, 0x18,
-	0xaf, 0x98, 0x1b, 0x33, 0x3b, 0xe5, 0xda, 0x5a, 0xbc, 0x4c, 0x7d, 0xf8, 0xf1, 0x91, 0xff, 0xa1,
-	0x2f, 0x68, 0xe7, 0x85, 0xa3, 0x3b, 0xd8, 0xfb, 0xb0, 0x32, 0xa0, 0xac, 0x80, 0x41, 0xb8, 0xdb,
-	0xf4, 0xa1, 0x7f, 0x04, 0x56, 0xda, 0x32, 0x2a, 0xd8, 0x1b, 0xb0, 0xc8, 0x35, 0xbb, 0x0a, 0x76,
-	0x79, 0x8c, 0xe5, 0xc0, 0x2b, 0x81, 0xb5, 0xcf, 0xc3, 0xd2, 0x3e, 0x63, 0x3e, 0x1b, 0x2e, 0x56,
-	0x81, 0xf9, 0x1e, 0xe1, 0x1c, 0x77, 0x89, 0x62, 0x16, 0xbd, 0xda, 0xb7, 0x01, 0xee, 0x11, 0xff,
-	0xc0, 0x77, 0x5f, 0x74, 0x7d, 0x0f, 0x5d, 0x86, 0x85, 0x43, 0xc2, 0x04, 0x6d, 0x11, 0x5e, 0x31,
-	0x64, 0x5a, 0x4e, 0xc7, 0x1b, 0x3e, 0xc4, 0xe2, 0xa1, 0xd7, 0x3d, 0xf0, 0xa9, 0x27, 0x9c, 0x21,
-	0xcc, 0xbe, 0x06, 0x5b, 0xf7, 0x88, 0x98, 0xb6, 0x22, 0xec, 0x6f, 0x0c, 0xd8, 0xce, 0xf7, 0x53,
-	0xd4, 0x5b, 0xb0, 0x4a, 0x13, 0xa0, 0xc6, 0xab, 0xd6, 0xc0, 0x0a, 0xcd, 0xfa, 0x64, 0xef, 0xc0,
-	0xf2, 0x3d, 0x22, 0x0a, 0xc8, 0x69, 0x3f, 0x86, 0x33, 0x63, 0xc8, 0xd7, 0xa0, 0xd8, 0xbf, 0x06,
-	0xac, 0x64, 0x32, 0x47, 0xeb, 0x50, 0xee, 0xb8, 0xb4, 0xfb, 0x54, 0xf0, 0x46, 0x9f, 0xb9, 0x8a,
-	0x0d, 0x28, 0xd3, 0x63, 0xe6, 0x2a, 0x96, 0xe6, 0xb0, 0xe8, 0x4e, 0xc1, 0xac, 0xff, 0xdc, 0x23,
-	0xac, 0x32, 0x23, 0x4d, 0x83, 0x17, 0x74, 0x0d, 0x16, 0xc2, 0x23, 0xdf, 0x20, 0x5e, 0xbb, 0x72,
-	0x4c, 0x92, 0xb3, 0xaa, 0x83, 0x23, 0x5f, 0x8d, 0x8e, 0x7c, 0xf5, 0x51, 0xd4, 0x13, 0x9c, 0xf9,
-	0x10, 0xbb, 0xef, 0xb5, 0xd1, 0x75, 0x00, 0xe9, 0xc6, 0x05, 0x66, 0xa2, 0x32, 0x3b, 0xd1, 0xb1,
-	0x14, 0xa2, 0xeb, 0x21, 0x58, 0x2f, 0xfe, 0xb9, 0x64, 0xf1, 0x5f, 0x86, 0xb2, 0x56, 0x4f, 0xe8,
-	0x0d, 0x98, 0x71, 0xb1, 0x90, 0x91, 0x19, 0x4e, 0xf8, 0x28, 0x2d, 0x5e, 0x57, 0xc6, 0x14, 0x5a,
-	0xbc, 0xae, 0xfd, 0xad, 0x01, 0x67, 0x0f, 0xfa, 0xd9, 0x25, 0x73, 0x80, 0x19, 0xee, 0x11, 0x41,
-	0x18, 0x47, 0xef, 0xc0, 0x3c, 0x39, 0x12, 0xc4, 0x13, 0x5c, 0xa9, 0x80, 0x62, 0x15, 0x9e, 0xf8,
-	0x6e, 0xbf, 0x47, 0xae, 0xee, 0x39, 0x11, 0x64, 0x34, 0xbd, 0xe6, 0x58, 0x7a, 0xb5, 0x30, 0x66,
-	0x92, 0x61, 0xbc, 0x84, 0xad, 0x3c, 0x4a, 0x59, 0x4d, 0xe1, 0x3e, 0xcc, 0x05, 0x21, 0x5b, 0x2e,
-	0x37, 0x2b, 0xd7, 0x2e, 0xc5, 0xf4, 0x8a, 0x45, 0xe8, 0x28, 0x7f, 0xfb, 0x67, 0x03, 0xb6, 0xf3,
-	0x19, 0xfc, 0xcf, 0x9a, 0xe7, 0x0f, 0x06, 0xac, 0x1c, 0xf4, 0x13, 0x27, 0x48, 0x13, 0xee, 0x3d,
-	0x28, 0xb5, 0xb0, 0xeb, 0x36, 0x71, 0xeb, 0x59, 0x24, 0xdd, 0x7a, 0xfa, 0x01, 0xfa, 0x20, 0x82,
-	0x39, 0xb1, 0x87, 0xae, 0xbb, 0x39, 0x59, 0xf7, 0x6c, 0x59, 0x09, 0x2c, 0x8f, 0x70, 0xcc, 0x52,
-	0xf2, 0xe6, 0x88, 0x92, 0x5b, 0x09, 0x25, 0xd3, 0xa3, 0x1c, 0x8a, 0xf7, 0xbd, 0x01, 0x67, 0xc6,
-	0xf6, 0x51, 0x7a, 0xdd, 0x87, 0x25, 0x5d, 0xaf, 0xa8, 0x1f, 0x17, 0x12, 0x6c, 0x51, 0x13, 0x8c,
-	0x8f, 0xf5, 0x25, 0x73, 0x8a, 0xbe, 0xf4, 0x95, 0x09, 0xe7, 0xea, 0x04, 0xb3, 0xd6, 0xd3, 0xcc,
-	0xdd, 0x78, 0x94, 0x1a, 0x04, 0xc7, 0x86, 0x95, 0x55, 0x72, 0xe4, 0x33, 0xba, 0x0d, 0x4b, 0x04,
-	0x33, 0x97, 0x12, 0x2e, 0x1a, 0x61, 0x5b, 0x50, 0x9b, 0xe7, 0xb5, 0x8f, 0xc5, 0xc8, 0x21, 0x34,
-	0xa1, 0x9b, 0x50, 0x76, 0xb1, 0x18, 0xba, 0xcf, 0x4c, 0x74, 0x87, 0x01, 0x5c, 0x3a, 0x6f, 0xc2,
-	0x62, 0x0f, 0x1f, 0x35, 0xb0, 0x2b, 0xa8, 0xe8, 0xb7, 0x89, 0x6c, 0x7a, 0x86, 0x53, 0xee, 0xe1,
-	0xa3, 0x3b, 0xca, 0x24, 0x21, 0xd4, 0x8b, 0x21, 0xb3, 0x0a, 0x42, 0xbd, 0x08, 0x62, 0x0b, 0xd8,
-	0x99, 0x9c, 0x82, 0xd7, 0xad, 0x9a, 0x7d, 0x09, 0xac, 0xc1, 0xae, 0xba, 0x3a, 0x79, 0xb9, 0xb6,
-	0x3f, 0x86, 0xd5, 0x54, 0x0f, 0x45, 0xed, 0x16, 0x2c, 0xe9, 0xd2, 0x46, 0xd4, 0xb2, 0xea, 0x20,
-	0x09, 0xb6, 0x29, 0xa0, 0xf1, 0x93, 0x8d, 0xee, 0xa4, 0xaf, 0xb9, 0x9a, 0xbe, 0x66, 0x5d, 0x60,
-	0x41, 0x46, 0x16, 0x0e, 0xfb, 0x7c, 0xdc, 0x74, 0xc3, 0x47, 0xfb, 0x47, 0x13, 0x16, 0x75, 0x37,
-	0x54, 0x83, 0xb9, 0x26, 0xe9, 0x52, 0x2f, 0xea, 0x08, 0x79, 0xf2, 0x2b, 0x64, 0xb2, 0x91, 0x98,
-	0x53, 0x37, 0x92, 0xab, 0x61, 0x23, 0x09, 0x28, 0x23, 0xbc, 0x40, 0xc9, 0x45, 0x50, 0xd5, 0x1c,
-	0x8e, 0x0d, 0x9b, 0xc3, 0x45, 0x40, 0x9e, 0xaf, 0x4d, 0x2e, 0xd4, 0x6b, 0x93, 0x23, 0x59, 0x62,
-	0xb3, 0xce, 0x49, 0xfd, 0xcb, 0x83, 0xf0, 0x43, 0xfc, 0xd7, 0x9e, 0xd3, 0xff, 0xda, 0x5a, 0x97,
-	0x9a, 0x4f, 0x76, 0xa9, 0x4f, 0xe0, 0x74, 0x6a, 0x20, 0x68, 0x1f, 0xd6, 0x73, 0x66, 0x26, 0x6d,
-	0x86, 0x58, 0xcb, 0x1c, 0x89, 0x1e, 0x33, 0xd7, 0xee, 0xc0, 0xc9, 0x31, 0xf9, 0x32, 0x62, 0x32,
-	0xb2, 0x62, 0xb2, 0x53, 0x9a, 0x4f, 0x69, 0xa4, 0xc9, 0xfc, 0x69, 0xc0, 0xc2, 0xa0, 0x3b, 0x5f,
-	0xd9, 0x43, 0xb7, 0xa0, 0x1c, 0x1d, 0xc6, 0xc6, 0x53, 0xaa, 0x14, 0x5f, 0x1d, 0xcb, 0xfe, 0x5d,
-	0xd7, 0xc7, 0xe2, 0x09, 0x76, 0xfb, 0xc4, 0x81, 0x08, 0x7f, 0x9f, 0x26, 0xbc, 0x5d, 0x5f, 0x09,
-	0x5f, 0xcc, 0xfb, 0xa1, 0x8f, 0x6a, 0x50, 0xea, 0xf8, 0xbe, 0x08, 0x18, 0xf5, 0x84, 0xd2, 0xfd,
-	0x54, 0x5c, 0x34, 0xf1, 0x9c, 0xec, 0xc4, 0x30, 0xfb, 0x8f, 0x21, 0xf9, 0xab, 0x7b, 0xe8, 0x3a,
-	0x1c, 0xe7, 0x01, 0x16, 0x14, 0xbb, 0x8d, 0x43, 0x69, 0xcb, 0x1a, 0x3f, 0xae, 0xec, 0x39, 0x4b,
-	0x0a, 0x39, 0x30, 0x24, 0x86, 0x33, 0xf3, 0x55, 0x87, 0xb3, 0x99, 0x29, 0x86, 0xb3, 0xda, 0xdf,
-	0x25, 0x28, 0xed, 0xd5, 0x95, 0xe6, 0xe8, 0x77, 0x03, 0xd6, 0x27, 0x5c, 0xc4, 0x90, 0x36, 0xa6,
-	0x14, 0xbb, 0x08, 0x5a, 0x97, 0xa7, 0xf0, 0x18, 0xf4, 0x29, 0xbb, 0xfa, 0xf5, 0x5f, 0xff, 0x7c,
-	0x67, 0xee, 0x5c, 0x38, 0x1b, 0x5e, 0x71, 0x77, 0x73, 0xea, 0x98, 0xef, 0x7e, 0x46, 0xdb, 0x9f,
-	0xa3, 0x2f, 0x0d, 0x40, 0xe3, 0xf7, 0x28, 0xb4, 0x35, 0xba, 0x73, 0xca, 0xdf, 0xdc, 0xda, 0xce,
-	0x07, 0x29, 0x46, 0xeb, 0x92, 0xd1, 0xca, 0x85, 0x33, 0x92, 0x51, 0xa2, 0x7d, 0x0d, 0x28, 0xfc,
-	0x64, 0xc0, 0x5a, 0xde, 0x65, 0x06, 0x5d, 0xd4, 0xab, 0x68, 0xe2, 0xb8, 0x68, 0x55, 0x8b, 0xc2,
-	0x93, 0x29, 0x43, 0x45, 0x53, 0xf6, 0x1c, 0x4e, 0x8c, 0x5c, 0x62, 0xd0, 0x46, 0x62, 0xcb, 0xb4,
-	0x5c, 0x6d, 0xe6, 0x20, 0x92, 0x89, 0x42, 0x99, 0x89, 0xfa, 0xcd, 0x80, 0xb5, 0xbc, 0x69, 0x55,
-	0x4f, 0x54, 0x81, 0xb9, 0x5a, 0x4f, 0x54, 0x91, 0x21, 0xd8, 0x7e, 0x57, 0x12, 0xbc, 0x64, 0x15,
-	0x4c, 0xd4, 0x0d, 0x35, 0xa8, 0xa1, 0x97, 0x70, 0x62, 0x64, 0x4e, 0xd3, 0x13, 0x96, 0x3e, 0x2a,
-	0xea, 0x09, 0xcb, 0x18, 0xf2, 0xec, 0x73, 0x92, 0xcf, 0xa6, 0x95, 0x95, 0xb0, 0x21, 0x81, 0x5f,
-	0x0d, 0xd8, 0x98, 0x34, 0x84, 0x20, 0xed, 0xb0, 0x15, 0x9c, 0xd9, 0xac, 0xda, 0x34, 0x2e, 0x8a,
-	0xf4, 0x79, 0x49, 0x7a, 0x0b, 0x6d, 0x4e, 0x4c, 0x22, 0xfa, 0x02, 0xde, 0x4c, 0x19, 0x49, 0xd0,
-	0xf6, 0xe8, 0xae, 0x69, 0x33, 0x8e, 0xf5, 0xf6, 0x04, 0x94, 0xa2, 0x63, 0x49, 0x3a, 0xa7, 0x10,
-	0x1a, 0xcf, 0x61, 0x73, 0x4e, 0xba, 0x5f, 0xf9, 0x2f, 0x00, 0x00, 0xff, 0xff, 0xa1, 0x62, 0xb0,
-	0xb9, 0x3b, 0x13, 0x00, 0x00,
+	// 1808 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x58, 0x5f, 0x6f, 0x1b, 0xc7,
+	0x11, 0xcf, 0x1d, 0x2d, 0x4a, 0x1c, 0x4a, 0xb6, 0xbc, 0x75, 0x64, 0x8a, 0x52, 0xa3, 0xf3, 0xc9,
+	0xb5, 0x95, 0xd4, 0xa2, 0x64, 0xda, 0x29, 0x10, 0xdb, 0x8d, 0xa1, 0x98, 0x8c, 0x6c, 0xd4, 0x70,
+	0x88, 0xa3, 0x9c, 0x14, 0x45, 0x51, 0x62, 0x79, 0x5c, 0x52, 0x5b, 0x9f, 0xf6, 0xd8, 0xdd, 0xa5,
+	0x2c, 0xb9, 0x68, 0x53, 0xb4, 0x8f, 0x05, 0x0a, 0xb4, 0x05, 0xfa, 0x54, 0xa0, 0x45, 0x90, 0x3e,
+	0xb6, 0x40, 0x81, 0xfe, 0x79, 0xea, 0x5b, 0xbf, 0x41, 0xbf, 0x42, 0x9f, 0xfa, 0x29, 0x8a, 0xdb,
+	0xdb, 0x23, 0xef, 0x48, 0x1e, 0xff, 0x38, 0x79, 0xc8, 0x13, 0xb9, 0x73, 0xbf, 0x99, 0xf9, 0xed,
+	0xcc, 0xdc, 0xdc, 0xec, 0xc2, 0x5a, 0xf7, 0x45, 0x67, 0xaf, 0x25, 0x44, 0x97, 0xfb, 0xd2, 0x0f,
+	0xfe, 0x94, 0xd4, 0x3f, 0xb4, 0x14, 0xc9, 0x8a, 0x9b, 0x1d, 0xdf, 0xef, 0x78, 0x64, 0x0f, 0x77,
+	0xe9, 0x1e, 0x66, 0xcc, 0x97, 0x58, 0x52, 0x9f, 0x69, 0x5c, 0x71, 0x4b, 0x3f, 0x55, 0xab, 0x66,
+	0xaf, 0xbd, 0x27, 0xe9, 0x09, 0x11, 0x12, 0x9f, 0x74, 0x35, 0xe0, 0xad, 0x61, 0xc0, 0x4b, 0x8e,
+	0xbb, 0x5d, 0xc2, 0x23, 0x03, 0xb7, 0xd4, 0x8f, 0xbb, 0xdb, 0x21, 0x6c, 0x57, 0xbc, 0xc4, 0x9d,
+	0x0e, 0xe1, 0x7b, 0x7e, 0x57, 0xb9, 0x18, 0x75, 0x67, 0x7f, 0x04, 0xd9, 0x47, 0x94, 0xbb, 0x1e,
+	0x41, 0xbb, 0x90, 0x75, 0x09, 0x93, 0x84, 0x17, 0x0c, 0xcb, 0xd8, 0xc9, 0x97, 0xdf, 0x2c, 0x45,
+	0x8c, 0x4b, 0x4f, 0xb1, 0x7c, 0xca, 0x3a, 0x35, 0x9f, 0x32, 0xe9, 0x68, 0x10, 0x5a, 0x83, 0x2c,
+	0xc7, 0x2d, 0xda, 0x13, 0x05, 0xd3, 0x32, 0x76, 0x4c, 0x47, 0xaf, 0x6c, 0x07, 0x6e, 0x54, 0x88,
+	0x47, 0x24, 0x79, 0xd2, 0x22, 0x4c, 0xd2, 0x36, 0x75, 0x95, 0xbf, 0x3a, 0xe1, 0xa7, 0xd4, 0x25,
+	0x07, 0x9c, 0x60, 0x87, 0xfc, 0xa8, 0x47, 0x84, 0x44, 0x17, 0xc1, 0xa4, 0x2d, 0xe5, 0x2c, 0xe7,
+	0x98, 0xb4, 0x85, 0x0a, 0xb0, 0x78, 0x4a, 0xb8, 0xa0, 0x3e, 0x53, 0x26, 0x73, 0x4e, 0xb4, 0xb4,
+	0xff, 0x6a, 0xc0, 0xcd, 0xa9, 0x46, 0x45, 0xd7, 0x67, 0x82, 0xa0, 0x0f, 0x61, 0x59, 0x84, 0xe2,
+	0x06, 0xe6, 0x04, 0xeb, 0xcd, 0x6c, 0x0f, 0x36, 0x93, 0x6e, 0x22, 0x2f, 0x06, 0x0b, 0xf4, 0x3e,
+	0xe4, 0x45, 0xaf, 0x29, 0x5c, 0x4e, 0x9b, 0x84, 0x07, 0x9b, 0xcc, 0xec, 0xe4, 0xcb, 0x9b, 0x03,
+	0x33, 0xf5, 0xfe, 0xc3, 0x23, 0xff, 0x99, 0x2f, 0x69, 0xfb, 0xdc, 0x89, 0x2b, 0xd8, 0x55, 0x58,
+	0x0f, 0x29, 0x6b, 0xa0, 0xca, 0xc0, 0xfc, 0x5b, 0xff, 0x2e, 0x14, 0xc7, 0x99, 0xd1, 0x9b, 0xbd,
+	0x07, 0xcb, 0x22, 0x26, 0xd7, 0x9b, 0x5d, 0x1b, 0x61, 0x19, 0x6a, 0x25, 0xb0, 0xf6, 0xdb, 0xb0,
+	0x52, 0xe5, 0xdc, 0xe7, 0x7d, 0x63, 0x05, 0x58, 0x3c, 0x21, 0x42, 0xe0, 0x0e, 0xd1, 0xcc, 0xa2,
+	0xa5, 0xfd, 0x10, 0xe0, 0x90, 0xf8, 0x35, 0xdf, 0x3b, 0xef, 0xf8, 0x0c, 0xdd, 0x86, 0xa5, 0x53,
+	0xc2, 0x25, 0x75, 0x89, 0x28, 0x18, 0x56, 0x26, 0xbd, 0x54, 0xfa, 0x30, 0xfb, 0x5d, 0xd8, 0x3e,
+	0x24, 0x72, 0xde, 0x8a, 0xb0, 0x7f, 0x69, 0xc0, 0xf5, 0xc9, 0x7a, 0x9a, 0xba, 0x0b, 0x1b, 0x34,
+	0x01, 0x6a, 0xbc, 0x6e, 0x0d, 0xac, 0xd3, 0xb4, 0x47, 0xf6, 0x0e, 0xac, 0x1d, 0x12, 0x39, 0x43,
+	0x3a, 0xed, 0xe7, 0x70, 0x75, 0x04, 0xf9, 0x25, 0x64, 0xec, 0x7f, 0x26, 0xac, 0xa7, 0x32, 0x47,
+	0x5b, 0x90, 0x6f, 0x7b, 0xb4, 0x73, 0x2c, 0x45, 0xa3, 0xc7, 0x3d, 0xcd, 0x06, 0xb4, 0xe8, 0x39,
+	0xf7, 0x34, 0x4b, 0xb3, 0x5f, 0x74, 0x57, 0x60, 0xc1, 0x7f, 0xc9, 0x08, 0x2f, 0x64, 0x94, 0x28,
+	0x5c, 0xa0, 0x77, 0x61, 0x29, 0xe8, 0x38, 0x0d, 0xc2, 0x5a, 0x85, 0x0b, 0x8a, 0x5c, 0xb1, 0x14,
+	0x76, 0x9c, 0x52, 0xd4, 0x71, 0x4a, 0x47, 0x51, 0x4b, 0x72, 0x16, 0x03, 0x6c, 0x95, 0xb5, 0xd0,
+	0x7b, 0x00, 0x4a, 0x4d, 0x48, 0xcc, 0x65, 0x61, 0x61, 0xaa, 0x62, 0x2e, 0x40, 0xd7, 0x03, 0x70,
+	0xbc, 0xf8, 0xb3, 0x89, 0xe2, 0x47, 0x0f, 0x20, 0x8f, 0x3d, 0x49, 0x65, 0xaf, 0x45, 0x1a, 0xc7,
+	0xb4, 0xb0, 0xa8, 0xac, 0x6e, 0x8c, 0x58, 0xfd, 0xd0, 0xf3, 0xb1, 0xfc, 0x18, 0x7b, 0x3d, 0xe2,
+	0x40, 0x84, 0x7f, 0x4c, 0x13, 0xda, 0x9e, 0x5f, 0x58, 0x9a, 0x43, 0xfb, 0xa9, 0x6f, 0xdf, 0x86,
+	0x7c, 0xac, 0x96, 0xd1, 0x2a, 0x64, 0x3c, 0x2c, 0x55, 0x54, 0x0d, 0x27, 0xf8, 0xab, 0x24, 0xac,
+	0x53, 0x30, 0xb5, 0x84, 0x75, 0xec, 0x5f, 0x1b, 0x70, 0xa3, 0xd6, 0x4b, 0x2f, 0xd7, 0x1a, 0xe6,
+	0xf8, 0x84, 0x48, 0xc2, 0x05, 0xba, 0x05, 0x8b, 0xe4, 0x4c, 0x12, 0x26, 0x85, 0xae, 0x00, 0x34,
+	0xa8, 0x80, 0x8f, 0x7d, 0xaf, 0x77, 0x42, 0xee, 0x56, 0x9c, 0x08, 0x32, 0x9c, 0x5a, 0x73, 0x24,
+	0xb5, 0xb1, 0x10, 0x66, 0x92, 0xfd, 0xe3, 0x53, 0xd8, 0x9e, 0x44, 0x29, 0xad, 0x21, 0x3d, 0x86,
+	0x6c, 0x37, 0x60, 0x1b, 0x76, 0xf7, 0x7c, 0x79, 0x7f, 0x40, 0x6f, 0xb6, 0x1d, 0x3a, 0x5a, 0xdf,
+	0xfe, 0x8b, 0x01, 0xd7, 0x27, 0x33, 0xf8, 0x8a, 0x35, 0xee, 0xcf, 0x0c, 0x58, 0xaf, 0xf5, 0x12,
+	0x6f, 0x6f, 0x2c, 0x71, 0xdf, 0x86, 0x9c, 0x8b, 0x3d, 0xaf, 0x89, 0xdd, 0x17, 0x51, 0xea, 0xb6,
+	0xc6, 0xbf, 0xbc, 0x8f, 0x22, 0x98, 0x33, 0xd0, 0x88, 0xe7, 0xdd, 0x9c, 0x9e, 0xf7, 0xf4, 0xb4,
+	0x12, 0x58, 0x1b, 0xe2, 0x98, 0x96, 0xc9, 0xfb, 0x43, 0x99, 0xdc, 0x4e, 0x64, 0x72, 0xfc, 0x2e,
+	0xfb, 0xc9, 0xfb, 0x83, 0x01, 0x57, 0x47, 0xfc, 0xe8, 0x7c, 0x3d, 0x86, 0x95, 0x78, 0xbe, 0xa2,
+	0x6f, 0xc1, 0x4c, 0x09, 0x5b, 0x8e, 0x25, 0x4c, 0x8c, 0xf4, 0x44, 0x73, 0x8e, 0x9e, 0xf8, 0x2f,
+	0x13, 0x6e, 0xd6, 0x09, 0xe6, 0xee, 0x71, 0xaa, 0x37, 0x11, 0x85, 0x06, 0xc1, 0x85, 0x7e, 0x65,
+	0xe5, 0x1c, 0xf5, 0x1f, 0x3d, 0x84, 0x15, 0x82, 0xb9, 0x47, 0x89, 0x90, 0x8d, 0xa0, 0x25, 0x15,
+	0xcc, 0xa9, 0xad, 0x6b, 0x39, 0x52, 0x08, 0x44, 0xe8, 0x3e, 0xe4, 0x3d, 0x2c, 0xfb, 0xea, 0x99,
+	0xa9, 0xea, 0x10, 0xc2, 0x95, 0xf2, 0x35, 0x58, 0x3e, 0xc1, 0x67, 0x8d, 0xa8, 0xed, 0xa8, 0x86,
+	0x6b, 0x38, 0xf9, 0x13, 0x7c, 0x76, 0xa0, 0x45, 0x0a, 0x42, 0xd9, 0x00, 0xb2, 0xa0, 0x21, 0x94,
+	0xf5, 0x21, 0x1b, 0x90, 0xeb, 0xe2, 0x0e, 0x69, 0x08, 0xfa, 0x8a, 0xa8, 0x16, 0xba, 0xe0, 0x2c,
+	0x05, 0x82, 0x3a, 0x7d, 0x45, 0xd0, 0xd7, 0x01, 0xd4, 0x43, 0xe9, 0xbf, 0x20, 0x4c, 0xb5, 0xd0,
+	0x9c, 0xa3, 0xe0, 0x47, 0x81, 0xc0, 0xfe, 0xbd, 0x01, 0x3b, 0xd3, 0xe3, 0xf7, 0xa5, 0xa7, 0xfc,
+	0x06, 0x5c, 0x62, 0xe4, 0x4c, 0x36, 0x62, 0xd4, 0xc2, 0xae, 0xb6, 0x12, 0x88, 0x6b, 0x7d, 0x7a,
+	0x1e, 0x14, 0x43, 0x76, 0xf1, 0x12, 0x98, 0x98, 0xd0, 0x44, 0x30, 0xcc, 0x89, 0xc1, 0xc8, 0x0c,
+	0x07, 0xe3, 0x17, 0x06, 0x6c, 0x8c, 0x75, 0xa7, 0xf7, 0xff, 0x00, 0x56, 0xe2, 0xc5, 0x17, 0xed,
+	0x3f, 0xad, 0x52, 0x93, 0xe0, 0x99, 0xf7, 0x4c, 0x01, 0x8d, 0xf6, 0x28, 0x74, 0x30, 0xde, 0xf7,
+	0xc6, 0x78, 0xdf, 0x75, 0x89, 0x25, 0x19, 0x26, 0xb0, 0x0a, 0x99, 0xc1, 0xe7, 0x23, 0xf8, 0x6b,
+	0x7f, 0x96, 0x81, 0xe5, 0xb8, 0x1a, 0x2a, 0x43, 0xb6, 0x49, 0x3a, 0x94, 0x45, 0xbd, 0x6d, 0x52,
+	0x21, 0x6b, 0x64, 0xb2, 0x25, 0x9a, 0x73, 0xb7, 0xc4, 0xbb, 0x41, 0x4b, 0xec, 0x52, 0x4e, 0xc4,
+	0x0c, 0x2f, 0x4f, 0x04, 0xd5, 0x6d, 0xee, 0x42, 0xbf, 0xcd, 0xed, 0x02, 0x62, 0x7e, 0x6c, 0xfe,
+	0xa3, 0xac, 0x45, 0xce, 0xd4, 0xcb, 0xb2, 0xe0, 0x5c, 0x8e, 0x3f, 0x79, 0x12, 0x3c, 0x18, 0xcc,
+	0x3e, 0xd9, 0xf8, 0xec, 0x13, 0xeb, 0xb7, 0x8b, 0x13, 0x27, 0x91, 0xa5, 0x2f, 0x34, 0x89, 0xe4,
+	0xe6, 0x9b, 0x44, 0x7e, 0x00, 0x6f, 0x8e, 0x0d, 0x22, 0xaa, 0xc2, 0xd6, 0x84, 0xa9, 0x37, 0x36,
+	0x05, 0x6e, 0xa6, 0x0e, 0xb5, 0xcf, 0xb9, 0x67, 0xb7, 0xe1, 0xf2, 0x48, 0xe9, 0xa4, 0xc4, 0xd3,
+	0x48, 0x8b, 0xa7, 0x3d, 0xa6, 0x85, 0xe7, 0x86, 0x5a, 0xf5, 0xaf, 0xcc, 0xa4, 0xa3, 0xea, 0x29,
+	0x61, 0x12, 0xdd, 0x85, 0x0b, 0xf2, 0xbc, 0x1b, 0x1e, 0x39, 0x2e, 0x96, 0xad, 0xf1, 0x85, 0xa3,
+	0xa0, 0xa5, 0xa3, 0xf3, 0x2e, 0x71, 0x14, 0x7a, 0x64, 0x58, 0x30, 0x5f, 0x73, 0x58, 0x78, 0x38,
+	0xc4, 0x3b, 0xa3, 0x53, 0x33, 0xe1, 0xa5, 0x4a, 0x6e, 0xea, 0x1e, 0x5c, 0x08, 0x68, 0xa1, 0x3c,
+	0x2c, 0x3e, 0x7f, 0xf6, 0x9d, 0x67, 0x1f, 0x7d, 0xf2, 0x6c, 0xf5, 0x8d, 0x60, 0xf1, 0xc8, 0xa9,
+	0x1e, 0x1c, 0x55, 0x2b, 0xab, 0x86, 0x7a, 0x52, 0xab, 0xa8, 0x85, 0x19, 0x2c, 0x2a, 0xd5, 0xa7,
+	0xd5, 0x60, 0x91, 0xb1, 0x3f, 0x37, 0x61, 0x29, 0xfc, 0xe8, 0xdf, 0xa9, 0x0c, 0x57, 0x98, 0xf1,
+	0x85, 0x2a, 0xcc, 0x9c, 0xab, 0xc2, 0x50, 0x19, 0x72, 0x6d, 0xdf, 0x97, 0x5d, 0x4e, 0x99, 0xd4,
+	0x21, 0xb8, 0x32, 0x08, 0xc1, 0xe0, 0xe8, 0xe7, 0x0c, 0x60, 0xe8, 0x3e, 0xac, 0xf6, 0x17, 0x0d,
+	0x57, 0x5d, 0x21, 0xe8, 0xf3, 0xc2, 0xea, 0x40, 0x35, 0xbc, 0x5a, 0x70, 0x2e, 0xf5, 0x91, 0xa1,
+	0x00, 0x7d, 0x13, 0x2e, 0x0f, 0x94, 0x3b, 0xc4, 0xff, 0xa1, 0xf0, 0x99, 0x7a, 0x59, 0x73, 0xce,
+	0xc0, 0xea, 0x61, 0x28, 0xb7, 0xff, 0x69, 0x44, 0x61, 0xba, 0x5b, 0x41, 0xef, 0xc1, 0x45, 0xd1,
+	0xc5, 0x92, 0x62, 0xaf, 0x71, 0xaa, 0x64, 0x69, 0xf3, 0xf3, 0x9d, 0x8a, 0xb3, 0xa2, 0x91, 0xa1,
+	0x20, 0x71, 0xb2, 0x31, 0x5f, 0xf7, 0x64, 0x93, 0x99, 0xe3, 0x64, 0x63, 0xef, 0xc3, 0x5b, 0x9f,
+	0x60, 0xe9, 0x1e, 0x8f, 0x94, 0xb2, 0x48, 0x99, 0xd6, 0xca, 0xff, 0x06, 0xc8, 0x55, 0xea, 0xba,
+	0x5c, 0xd1, 0x3f, 0x0c, 0xd8, 0x9a, 0x72, 0xef, 0x81, 0x62, 0x93, 0xf9, 0x6c, 0xf7, 0x2e, 0xc5,
+	0xdb, 0x73, 0x68, 0x84, 0x1f, 0x3e, 0xbb, 0xf4, 0xf3, 0xff, 0xfc, 0xf7, 0xb7, 0xe6, 0xce, 0x3b,
+	0x37, 0x82, 0x0b, 0xad, 0xbd, 0x09, 0x4d, 0x47, 0xec, 0xfd, 0x98, 0xb6, 0x7e, 0x82, 0x7e, 0x66,
+	0x00, 0x1a, 0xbd, 0xb6, 0x40, 0xdb, 0xc3, 0x9e, 0xc7, 0x0c, 0xb0, 0xc5, 0xeb, 0x93, 0x41, 0x9a,
+	0xd1, 0x96, 0x62, 0xb4, 0xfe, 0xce, 0x55, 0xc5, 0x28, 0xf1, 0x9d, 0x0b, 0x29, 0xfc, 0xd9, 0x80,
+	0xcd, 0x49, 0x77, 0x07, 0x68, 0x37, 0x5e, 0xe1, 0x53, 0x4f, 0x48, 0xc5, 0xd2, 0xac, 0xf0, 0x64,
+	0xc8, 0xd0, 0xac, 0x21, 0x7b, 0x09, 0x97, 0x86, 0xee, 0x0c, 0x90, 0x95, 0x70, 0x39, 0x2e, 0x56,
+	0xd7, 0x26, 0x20, 0x92, 0x81, 0x42, 0xa9, 0x81, 0xfa, 0xbb, 0x01, 0x9b, 0xb5, 0xde, 0x6c, 0x81,
+	0xaa, 0xf5, 0xe6, 0x0a, 0xd4, 0x2c, 0xe7, 0x3e, 0xfb, 0x5b, 0x8a, 0xe0, 0xfe, 0x3d, 0x7d, 0xe6,
+	0x28, 0xce, 0x1a, 0xb0, 0x4f, 0xe1, 0x52, 0xad, 0x97, 0x1a, 0xb0, 0x5a, 0x6f, 0x5a, 0xc0, 0x52,
+	0xce, 0x35, 0xf6, 0x4d, 0xc5, 0xe7, 0x5a, 0x31, 0x2d, 0x60, 0x11, 0x51, 0xf4, 0x37, 0x03, 0xac,
+	0x69, 0xa3, 0x33, 0x8a, 0xbd, 0x6c, 0x33, 0x1e, 0x53, 0x8a, 0xe5, 0x79, 0x54, 0x34, 0xe9, 0xb7,
+	0x15, 0xe9, 0x6d, 0x74, 0x6d, 0x6a, 0xf0, 0xd0, 0x4f, 0xe1, 0x6b, 0x63, 0x66, 0x5c, 0x74, 0x7d,
+	0xd8, 0xeb, 0xb8, 0x89, 0xbb, 0xf8, 0x8d, 0x29, 0x28, 0x4d, 0xa7, 0xa8, 0xe8, 0x5c, 0x41, 0x68,
+	0x34, 0x86, 0xa8, 0x09, 0x57, 0x53, 0x9a, 0x22, 0xda, 0x19, 0x58, 0x9f, 0xdc, 0x37, 0x8b, 0x1b,
+	0x13, 0xe6, 0x84, 0x7d, 0xe3, 0x83, 0xcf, 0xcd, 0xdf, 0x1c, 0xfc, 0xd1, 0x44, 0x7f, 0x32, 0x20,
+	0x53, 0xa9, 0xd7, 0xcb, 0x0b, 0xfb, 0xa5, 0xfd, 0x52, 0x19, 0xfd, 0xce, 0x78, 0xc2, 0x24, 0xe1,
+	0x6d, 0xec, 0x12, 0x4b, 0xfa, 0x56, 0x85, 0x0a, 0xd7, 0x3f, 0x25, 0xfc, 0xdc, 0xc2, 0xac, 0x65,
+	0xd5, 0xcf, 0x99, 0x7b, 0xcc, 0x7d, 0x46, 0x5f, 0xa9, 0xa0, 0x59, 0x3a, 0xcc, 0xea, 0x99, 0x0e,
+	0xa0, 0xd5, 0xe5, 0xfe, 0x29, 0x6d, 0x11, 0x2e, 0xac, 0x9e, 0x20, 0x2d, 0xab, 0x79, 0x6e, 0x75,
+	0x31, 0x97, 0xd4, 0xa5, 0xc1, 0x77, 0x86, 0x75, 0x2c, 0xd7, 0xa3, 0x01, 0xc9, 0xc0, 0x78, 0x4b,
+	0x1b, 0x57, 0xfa, 0x94, 0xb5, 0x7d, 0x7e, 0x62, 0xf9, 0xf2, 0x98, 0xf0, 0x51, 0x63, 0xa5, 0xef,
+	0x1d, 0x43, 0x1b, 0xb2, 0x1f, 0x10, 0xcc, 0x09, 0x47, 0xdf, 0xb7, 0xcc, 0xe2, 0xca, 0x41, 0x4f,
+	0x1e, 0xfb, 0x5c, 0x53, 0x41, 0x87, 0x07, 0xae, 0x4b, 0x44, 0x60, 0xf6, 0x05, 0x61, 0x16, 0x15,
+	0xa2, 0x17, 0x3a, 0xc7, 0xcc, 0xc2, 0x71, 0x60, 0xb4, 0x92, 0xe7, 0xb7, 0x2c, 0x2c, 0x2c, 0x3b,
+	0xb4, 0x68, 0x3d, 0x50, 0x7a, 0xef, 0xdb, 0xa5, 0x25, 0xb3, 0xb9, 0x0c, 0xd0, 0xf7, 0xf4, 0x46,
+	0x33, 0xab, 0x22, 0x78, 0xe7, 0xff, 0x03, 0x00, 0x5a, 0xf4, 0xa4, 0x12, 0xb4, 0x18, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1612,6 +1935,10 @@ type DSServiceClient interface {
 	//
 	// Only Subscriptions belonging to the caller are returned.  This endpoint would be used if a USS lost track of Subscriptions they had created and/or wanted to resolve an error indicating that they had too many existing Subscriptions in an area.
 	SearchSubscriptions(ctx context.Context, in *SearchSubscriptionsRequest, opts ...grpc.CallOption) (*SearchSubscriptionsResponse, error)
+	// Stream changes to Identification Service Areas intersecting a subscription owned by the caller.
+	//
+	// Every change that increments the notification index of the subscription is delivered as a SubscriptionEvent, allowing clients to receive notifications without exposing a callback endpoint.  The stream ends when the subscription is deleted.  Only changes written through the DSS instance serving the stream are delivered: changes handled by other instances of a replicated deployment, and the expiry of Identification Service Areas, are not.  Clients must not rely on the stream alone and should search periodically.  Clients that fall behind are disconnected with RESOURCE_EXHAUSTED and are expected to reconnect and use notification indices to detect missed events.
+	WatchSubscriptionEvents(ctx context.Context, in *WatchSubscriptionEventsRequest, opts ...grpc.CallOption) (DSService_WatchSubscriptionEventsClient, error)
 }
 
 type dSServiceClient struct {
@@ -1694,6 +2021,38 @@ func (c *dSServiceClient) SearchSubscriptions(ctx context.Context, in *SearchSub
 	return out, nil
 }
 
+func (c *dSServiceClient) WatchSubscriptionEvents(ctx context.Context, in *WatchSubscriptionEventsRequest, opts ...grpc.CallOption) (DSService_WatchSubscriptionEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DSService_serviceDesc.Streams[0], "/dssproto.DSService/WatchSubscriptionEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dSServiceWatchSubscriptionEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DSService_WatchSubscriptionEventsClient interface {
+	Recv() (*SubscriptionEvent, error)
+	grpc.ClientStream
+}
+
+type dSServiceWatchSubscriptionEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *dSServiceWatchSubscriptionEventsClient) Recv() (*SubscriptionEvent, error) {
+	m := new(SubscriptionEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DSServiceServer is the server API for DSService service.
 type DSServiceServer interface {
 	// /dss/identification_service_areas/{id}
@@ -1732,6 +2091,10 @@ type DSServiceServer interface {
 	//
 	// Only Subscriptions belonging to the caller are returned.  This endpoint would be used if a USS lost track of Subscriptions they had created and/or wanted to resolve an error indicating that they had too many existing Subscriptions in an area.
 	SearchSubscriptions(context.Context, *SearchSubscriptionsRequest) (*SearchSubscriptionsResponse, error)
+	// Stream changes to Identification Service Areas intersecting a subscription owned by the caller.
+	//
+	// Every change that increments the notification index of the subscription is delivered as a SubscriptionEvent, allowing clients to receive notifications without exposing a callback endpoint.  The stream ends when the subscription is deleted.  Only changes written through the DSS instance serving the stream are delivered: changes handled by other instances of a replicated deployment, and the expiry of Identification Service Areas, are not.  Clients must not rely on the stream alone and should search periodically.  Clients that fall behind are disconnected with RESOURCE_EXHAUSTED and are expected to reconnect and use notification indices to detect missed events.
+	WatchSubscriptionEvents(*WatchSubscriptionEventsRequest, DSService_WatchSubscriptionEventsServer) error
 }
 
 // UnimplementedDSServiceServer can be embedded to have forward compatible implementations.
@@ -1762,6 +2125,9 @@ func (*UnimplementedDSServiceServer) SearchIdentificationServiceAreas(ctx contex
 func (*UnimplementedDSServiceServer) SearchSubscriptions(ctx context.Context, req *SearchSubscriptionsRequest) (*SearchSubscriptionsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SearchSubscriptions not implemented")
 }
+func (*UnimplementedDSServiceServer) WatchSubscriptionEvents(req *WatchSubscriptionEventsRequest, srv DSService_WatchSubscriptionEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchSubscriptionEvents not implemented")
+}
 
 func RegisterDSServiceServer(s *grpc.Server, srv DSServiceServer) {
 	s.RegisterService(&_DSService_serviceDesc, srv)
@@ -1911,6 +2277,27 @@ func _DSService_SearchSubscriptions_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DSService_WatchSubscriptionEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchSubscriptionEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DSServiceServer).WatchSubscriptionEvents(m, &dSServiceWatchSubscriptionEventsServer{stream})
+}
+
+type DSService_WatchSubscriptionEventsServer interface {
+	Send(*SubscriptionEvent) error
+	grpc.ServerStream
+}
+
+type dSServiceWatchSubscriptionEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *dSServiceWatchSubscriptionEventsServer) Send(m *SubscriptionEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _DSService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "dssproto.DSService",
 	HandlerType: (*DSServiceServer)(nil),
@@ -1948,6 +2335,12 @@ var _DSService_serviceDesc = grpc.ServiceDesc{
 			Handler:    _DSService_SearchSubscriptions_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchSubscriptionEvents",
+			Handler:       _DSService_WatchSubscriptionEvents_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "pkg/dssproto/dss.proto",
 }
@@ -0,0 +1,764 @@
+// Code generated by gen_swagger.go from dss.swagger.json. DO NOT EDIT.
+
+package dssproto
+
+// SwaggerJSON is the OpenAPI 2.0 document of the HTTP API of DSService
+// served by the handlers in dss.pb.gw.go.
+const SwaggerJSON = `{
+  "swagger": "2.0",
+  "info": {
+    "title": "DSS",
+    "description": "Interface to Discovery and Synchronization Service and service providers used by participating clients to discover and inform other service providers.",
+    "version": "0.0.2"
+  },
+  "schemes": [
+    "http",
+    "https"
+  ],
+  "consumes": [
+    "application/json"
+  ],
+  "produces": [
+    "application/json"
+  ],
+  "paths": {
+    "/dss/identification_service_areas": {
+      "get": {
+        "summary": "/dss/identification_service_areas",
+        "description": "Retrieve all Identification Service Areas in the DAR for a given area during the given time.  Note that some Identification Service Areas returned may lie entirely outside the requested area.",
+        "operationId": "SearchIdentificationServiceAreas",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoSearchIdentificationServiceAreasResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "area",
+            "description": "The area in which to search for Identification Service Areas.  Some Identification Service Areas near this area but wholly outside it may also be returned.  Either a comma-separated list of vertices ` + "`" + `lat1,lng1,lat2,lng2,lat3,lng3,...` + "`" + ` or a GeoJSON Polygon or MultiPolygon geometry, or a GeoJSON Feature with such a geometry.",
+            "in": "query",
+            "required": false,
+            "type": "string"
+          },
+          {
+            "name": "earliest_time",
+            "description": "If specified, indicates non-interest in any Identification Service Areas that end before this time.  RFC 3339 format, per OpenAPI specification.",
+            "in": "query",
+            "required": false,
+            "type": "string",
+            "format": "date-time"
+          },
+          {
+            "name": "latest_time",
+            "description": "If specified, indicates non-interest in any Identification Service Areas that start after this time.  RFC 3339 format, per OpenAPI specification.",
+            "in": "query",
+            "required": false,
+            "type": "string",
+            "format": "date-time"
+          },
+          {
+            "name": "max_altitude",
+            "description": "If specified, indicates non-interest in Identification Service Areas entirely above this altitude in meters above the WGS84 ellipsoid.",
+            "in": "query",
+            "required": false,
+            "type": "number",
+            "format": "double"
+          },
+          {
+            "name": "min_altitude",
+            "description": "If specified, indicates non-interest in Identification Service Areas entirely below this altitude in meters above the WGS84 ellipsoid.",
+            "in": "query",
+            "required": false,
+            "type": "number",
+            "format": "double"
+          },
+          {
+            "name": "page_size",
+            "description": "Maximum number of Identification Service Areas to return.  If unset or above the maximum page size of the DSS, the maximum page size applies.",
+            "in": "query",
+            "required": false,
+            "type": "integer",
+            "format": "int32"
+          },
+          {
+            "name": "page_token",
+            "description": "Token returned as ` + "`" + `next_page_token` + "`" + ` by a previous search with the same parameters, to retrieve the next page of results.",
+            "in": "query",
+            "required": false,
+            "type": "string"
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      }
+    },
+    "/dss/identification_service_areas/{id}": {
+      "get": {
+        "summary": "/dss/identification_service_areas/{id}",
+        "description": "Verify the existence/valdity and state of a particular IdentificationServiceArea.",
+        "operationId": "GetIdentificationServiceArea",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoGetIdentificationServiceAreaResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "id",
+            "description": "UUIDv4 of the Identification Service Area.",
+            "in": "path",
+            "required": true,
+            "type": "string"
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      },
+      "delete": {
+        "summary": "/dss/identification_service_areas/{id}",
+        "description": "Delete an Identification Service Area.  USSs should not delete Identification Service Areas before the end of the last managed flight plus the retention period.",
+        "operationId": "DeleteIdentificationServiceArea",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoDeleteIdentificationServiceAreaResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "id",
+            "description": "UUIDv4 of the Identification Service Area.",
+            "in": "path",
+            "required": true,
+            "type": "string"
+          },
+          {
+            "name": "version",
+            "in": "query",
+            "required": false,
+            "type": "string"
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      },
+      "put": {
+        "summary": "/dss/identification_service_areas/{id}",
+        "description": "Create or update an Identification Service Area.\n\nThe DSS assumes the USS has already added the appropriate retention period to operation end time in ` + "`" + `time_end` + "`" + ` field before storing it.  Updating ` + "`" + `time_start` + "`" + ` is not allowed if it is before the current time.",
+        "operationId": "PutIdentificationServiceArea",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoPutIdentificationServiceAreaResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "id",
+            "description": "UUIDv4 of the Identification Service Area.",
+            "in": "path",
+            "required": true,
+            "type": "string"
+          },
+          {
+            "name": "body",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/dssprotoPutIdentificationServiceAreaParameters"
+            }
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      }
+    },
+    "/dss/subscriptions": {
+      "get": {
+        "summary": "/dss/subscriptions",
+        "description": "Retrieve subscriptions intersecting an area of interest.  Subscription notifications are only triggered by (and contain full information of) changes to, creation of, or deletion of, Entities referenced by or stored in the DSS; they do not involve any data transfer (such as remote ID telemetry updates) apart from Entity information.\n\nOnly Subscriptions belonging to the caller are returned.  This endpoint would be used if a USS lost track of Subscriptions they had created and/or wanted to resolve an error indicating that they had too many existing Subscriptions in an area.",
+        "operationId": "SearchSubscriptions",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoSearchSubscriptionsResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "area",
+            "description": "The area in which to search for Subscriptions.  Some Subscriptions near this area but wholly outside it may also be returned.  Either a comma-separated list of vertices ` + "`" + `lat1,lng1,lat2,lng2,lat3,lng3,...` + "`" + ` or a GeoJSON Polygon or MultiPolygon geometry, or a GeoJSON Feature with such a geometry.",
+            "in": "query",
+            "required": false,
+            "type": "string"
+          },
+          {
+            "name": "page_size",
+            "description": "Maximum number of Subscriptions to return.  If unset or above the maximum page size of the DSS, the maximum page size applies.",
+            "in": "query",
+            "required": false,
+            "type": "integer",
+            "format": "int32"
+          },
+          {
+            "name": "page_token",
+            "description": "Token returned as ` + "`" + `next_page_token` + "`" + ` by a previous search with the same parameters, to retrieve the next page of results.",
+            "in": "query",
+            "required": false,
+            "type": "string"
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      }
+    },
+    "/dss/subscriptions/{id}": {
+      "get": {
+        "summary": "/dss/subscriptions/{id}",
+        "description": "Verify the existence/valdity and state of a particular subscription.",
+        "operationId": "GetSubscription",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoGetSubscriptionResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "id",
+            "description": "UUIDv4 of the Identification Service Area.",
+            "in": "path",
+            "required": true,
+            "type": "string"
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      },
+      "delete": {
+        "summary": "/dss/subscriptions/{id}",
+        "description": "Delete a subscription.",
+        "operationId": "DeleteSubscription",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoDeleteSubscriptionResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "id",
+            "description": "UUIDV4 of the subscription of interest.",
+            "in": "path",
+            "required": true,
+            "type": "string"
+          },
+          {
+            "name": "version",
+            "in": "query",
+            "required": false,
+            "type": "string"
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      },
+      "put": {
+        "summary": "/dss/subscriptions/{id}",
+        "description": "Create or update a subscription.  Subscription notifications are only triggered by (and contain full information of) changes to, creation of, or deletion of, Entities referenced by or stored in the DSS; they do not involve any data transfer (such as remote ID telemetry updates) apart from Entity information.",
+        "operationId": "PutSubscription",
+        "responses": {
+          "200": {
+            "description": "A successful response.",
+            "schema": {
+              "$ref": "#/definitions/dssprotoPutSubscriptionResponse"
+            }
+          }
+        },
+        "parameters": [
+          {
+            "name": "id",
+            "description": "UUIDV4 of the subscription of interest.  Must be created by client before ` + "`" + `PUT` + "`" + ` call to create AreaSubscription in DSS because the client may receive a notification at that subscription before receiving a response from the DSS.",
+            "in": "path",
+            "required": true,
+            "type": "string"
+          },
+          {
+            "name": "body",
+            "in": "body",
+            "required": true,
+            "schema": {
+              "$ref": "#/definitions/dssprotoPutSubscriptionParameters"
+            }
+          }
+        ],
+        "tags": [
+          "DSService"
+        ]
+      }
+    }
+  },
+  "definitions": {
+    "dssprotoCircle": {
+      "type": "object",
+      "properties": {
+        "center": {
+          "$ref": "#/definitions/dssprotoLatLngPoint",
+          "description": "Center of the circle."
+        },
+        "radius": {
+          "type": "number",
+          "format": "float",
+          "description": "Radius of the circle in meters, measured along the earth's surface."
+        }
+      },
+      "description": "A circular area on the earth."
+    },
+    "dssprotoDeleteIdentificationServiceAreaResponse": {
+      "type": "object",
+      "properties": {
+        "service_area": {
+          "$ref": "#/definitions/dssprotoIdentificationServiceArea"
+        },
+        "subscribers": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/dssprotoSubscriberToNotify"
+          },
+          "description": "DSS subscribers that this client now has the obligation to notify of the Identification Service Area just deleted.  This client must call DELETE for each provided URL according to the ` + "`" + `/uss/identification_service_areas` + "`" + ` path API."
+        }
+      },
+      "description": "Response for a request to delete an Identification Service Area."
+    },
+    "dssprotoDeleteSubscriptionResponse": {
+      "type": "object",
+      "properties": {
+        "subscription": {
+          "$ref": "#/definitions/dssprotoSubscription"
+        }
+      },
+      "description": "Response for a successful request to delete an Subscription."
+    },
+    "dssprotoGeoPolygon": {
+      "type": "object",
+      "properties": {
+        "vertices": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/dssprotoLatLngPoint"
+          }
+        }
+      },
+      "description": "An enclosed area on the earth.\nThe bounding edges of this polygon shall be the shortest paths between connected vertices.  This means, for instance, that the edge between two points both defined at a particular latitude is not generally contained at that latitude.\nThe winding order shall be interpreted as the order which produces the smaller area.\nThe path between two vertices shall be the shortest possible path between those vertices.\nEdges may not cross.\nVertices may not be duplicated.  In particular, the final polygon vertex shall not be identical to the first vertex."
+    },
+    "dssprotoGetIdentificationServiceAreaResponse": {
+      "type": "object",
+      "properties": {
+        "identification_service_area": {
+          "$ref": "#/definitions/dssprotoIdentificationServiceArea"
+        }
+      },
+      "description": "Response to DSS request for the identification service area with the given id."
+    },
+    "dssprotoGetSubscriptionResponse": {
+      "type": "object",
+      "properties": {
+        "subscription": {
+          "$ref": "#/definitions/dssprotoSubscription"
+        }
+      },
+      "description": "Response to DSS request for the subscription with the given id."
+    },
+    "dssprotoIdentificationServiceArea": {
+      "type": "object",
+      "properties": {
+        "flights_url": {
+          "type": "string"
+        },
+        "id": {
+          "type": "string"
+        },
+        "owner": {
+          "type": "string",
+          "description": "Assigned by the DSS based on creating client’s ID (via access token).  Used for restricting mutation and deletion operations to owner, and only requiring EntitySignatures for unowned Entities."
+        },
+        "time_end": {
+          "type": "string",
+          "format": "date-time",
+          "description": "End time of service.  RFC 3339 format, per OpenAPI specification."
+        },
+        "time_start": {
+          "type": "string",
+          "format": "date-time",
+          "description": "Beginning time of service.  RFC 3339 format, per OpenAPI specification."
+        },
+        "version": {
+          "type": "string"
+        },
+        "altitude_hi": {
+          "type": "number",
+          "format": "float",
+          "description": "Upper altitude bound of service in meters above the WGS84 ellipsoid, if any."
+        },
+        "altitude_lo": {
+          "type": "number",
+          "format": "float",
+          "description": "Lower altitude bound of service in meters above the WGS84 ellipsoid, if any."
+        }
+      },
+      "description": "An Identification Service Area (area in which remote ID services are being provided).  The DSS reports only these declarations and clients must exchange flight information peer-to-peer."
+    },
+    "dssprotoLatLngPoint": {
+      "type": "object",
+      "properties": {
+        "lat": {
+          "type": "number",
+          "format": "double"
+        },
+        "lng": {
+          "type": "number",
+          "format": "double"
+        }
+      },
+      "description": "Point on the earth's surface."
+    },
+    "dssprotoPutIdentificationServiceAreaParameters": {
+      "type": "object",
+      "properties": {
+        "extents": {
+          "$ref": "#/definitions/dssprotoVolume4D"
+        },
+        "flights_url": {
+          "type": "string"
+        },
+        "version": {
+          "type": "string"
+        }
+      },
+      "description": "Parameters for a request to create or update a reference to an Identification Service Area in the DSS."
+    },
+    "dssprotoPutIdentificationServiceAreaResponse": {
+      "type": "object",
+      "properties": {
+        "service_area": {
+          "$ref": "#/definitions/dssprotoIdentificationServiceArea"
+        },
+        "subscribers": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/dssprotoSubscriberToNotify"
+          },
+          "description": "DSS subscribers that this client now has the obligation to notify of the Identification Service Area changes just made.  This client must call PUT for each provided URL according to the ` + "`" + `/uss/identification_service_areas/{id}` + "`" + ` path API."
+        }
+      },
+      "description": "Response to a request to create or update a reference to an Identification Service Area in the DSS."
+    },
+    "dssprotoPutSubscriptionParameters": {
+      "type": "object",
+      "properties": {
+        "callbacks": {
+          "$ref": "#/definitions/dssprotoSubscriptionCallbacks"
+        },
+        "extents": {
+          "$ref": "#/definitions/dssprotoVolume4D"
+        },
+        "version": {
+          "type": "string"
+        }
+      },
+      "description": "Parameters for a request to create or update a subscription in the DSS."
+    },
+    "dssprotoPutSubscriptionResponse": {
+      "type": "object",
+      "properties": {
+        "service_areas": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/dssprotoIdentificationServiceArea"
+          },
+          "description": "Identification Service Areas in or near the subscription area at the time of creation/update, if ` + "`" + `identification_service_area_url` + "`" + ` callback was specified."
+        },
+        "subscription": {
+          "$ref": "#/definitions/dssprotoSubscription"
+        }
+      },
+      "description": "Response for a request to create or update a subscription."
+    },
+    "dssprotoSearchIdentificationServiceAreasResponse": {
+      "type": "object",
+      "properties": {
+        "service_areas": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/dssprotoIdentificationServiceArea"
+          },
+          "description": "Identification Service Areas in the area of interest."
+        },
+        "next_page_token": {
+          "type": "string",
+          "description": "Opaque token to pass as ` + "`" + `page_token` + "`" + ` to retrieve the next page of results.  Empty if there are no more results."
+        }
+      },
+      "description": "Response to DSS query for Identification Service Areas in an area of interest."
+    },
+    "dssprotoSearchSubscriptionsResponse": {
+      "type": "object",
+      "properties": {
+        "subscriptions": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/dssprotoSubscription"
+          },
+          "description": "Subscriptions that overlap the specified area."
+        },
+        "next_page_token": {
+          "type": "string",
+          "description": "Opaque token to pass as ` + "`" + `page_token` + "`" + ` to retrieve the next page of results.  Empty if there are no more results."
+        }
+      },
+      "description": "Response to DSS query for subscriptions in a particular area."
+    },
+    "dssprotoSubscriberToNotify": {
+      "type": "object",
+      "properties": {
+        "subscriptions": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/dssprotoSubscriptionState"
+          },
+          "description": "Subscription(s) prompting this notification."
+        },
+        "url": {
+          "type": "string"
+        }
+      },
+      "description": "Subscriber to notify of a creation/change/deletion of a change in the airspace.  This is provided by the DSS to a client changing the airspace, and it is the responsibility of the client changing the airspace (they will receive a set of these notification requests) to send a notification to each specified ` + "`" + `url` + "`" + `."
+    },
+    "dssprotoSubscription": {
+      "type": "object",
+      "properties": {
+        "begins": {
+          "type": "string",
+          "format": "date-time"
+        },
+        "callbacks": {
+          "$ref": "#/definitions/dssprotoSubscriptionCallbacks"
+        },
+        "expires": {
+          "type": "string",
+          "format": "date-time",
+          "description": "If set, this subscription will be automatically removed after this time.  RFC 3339 format, per OpenAPI specification."
+        },
+        "id": {
+          "type": "string"
+        },
+        "notification_index": {
+          "type": "integer",
+          "format": "int32"
+        },
+        "owner": {
+          "type": "string",
+          "description": "Assigned by the DSS based on creating client’s ID (via access token).  Used for restricting mutation and deletion operations to owner."
+        },
+        "version": {
+          "type": "string"
+        },
+        "altitude_hi": {
+          "type": "number",
+          "format": "float",
+          "description": "Upper altitude bound of the subscription in meters above the WGS84 ellipsoid, if any."
+        },
+        "altitude_lo": {
+          "type": "number",
+          "format": "float",
+          "description": "Lower altitude bound of the subscription in meters above the WGS84 ellipsoid, if any."
+        }
+      },
+      "description": "Specification of a geographic area that a client is interested in on an ongoing basis (e.g., “planning area”).  Internal to the DSS."
+    },
+    "dssprotoSubscriptionCallbacks": {
+      "type": "object",
+      "properties": {
+        "identification_service_area_url": {
+          "type": "string"
+        }
+      },
+      "description": "Endpoints that should be called when an applicable event occurs.  At least one field must be specified."
+    },
+    "dssprotoSubscriptionEvent": {
+      "type": "object",
+      "properties": {
+        "type": {
+          "$ref": "#/definitions/dssprotoSubscriptionEventType"
+        },
+        "service_area": {
+          "$ref": "#/definitions/dssprotoIdentificationServiceArea",
+          "description": "The Identification Service Area after the change, or as it was before its deletion."
+        },
+        "subscription": {
+          "$ref": "#/definitions/dssprotoSubscriptionState",
+          "description": "The watched subscription and its notification index after the change."
+        }
+      },
+      "description": "Change to an Identification Service Area intersecting a watched subscription."
+    },
+    "dssprotoSubscriptionEventType": {
+      "type": "string",
+      "enum": [
+        "UNKNOWN",
+        "CREATED",
+        "UPDATED",
+        "DELETED"
+      ],
+      "default": "UNKNOWN"
+    },
+    "dssprotoSubscriptionState": {
+      "type": "object",
+      "properties": {
+        "notification_index": {
+          "type": "integer",
+          "format": "int32"
+        },
+        "subscription": {
+          "type": "string"
+        }
+      },
+      "description": "State of AreaSubscription which is causing a notification to be sent."
+    },
+    "dssprotoVolume3D": {
+      "type": "object",
+      "properties": {
+        "altitude_hi": {
+          "type": "number",
+          "format": "float"
+        },
+        "altitude_lo": {
+          "type": "number",
+          "format": "float"
+        },
+        "footprint": {
+          "$ref": "#/definitions/dssprotoGeoPolygon"
+        },
+        "footprint_circle": {
+          "$ref": "#/definitions/dssprotoCircle",
+          "description": "Circular footprint, e.g. a radius around a launch point."
+        },
+        "footprint_geojson": {
+          "type": "string",
+          "description": "Footprint given as a GeoJSON Polygon or MultiPolygon geometry, or as a GeoJSON Feature with such a geometry.  Positions are ` + "`" + `[longitude, latitude]` + "`" + ` in degrees."
+        }
+      },
+      "description": "A three-dimensional geographic volume consisting of a vertically-extruded polygon.\nExactly one of footprint, footprint_circle and footprint_geojson must be set."
+    },
+    "dssprotoVolume4D": {
+      "type": "object",
+      "properties": {
+        "spatial_volume": {
+          "$ref": "#/definitions/dssprotoVolume3D"
+        },
+        "time_end": {
+          "type": "string",
+          "format": "date-time",
+          "description": "End time of this volume.  RFC 3339 format, per OpenAPI specification."
+        },
+        "time_start": {
+          "type": "string",
+          "format": "date-time",
+          "description": "Beginning time of this volume.  RFC 3339 format, per OpenAPI specification."
+        }
+      },
+      "description": "Contiguous block of geographic spacetime."
+    },
+    "protobufAny": {
+      "type": "object",
+      "properties": {
+        "type_url": {
+          "type": "string",
+          "description": "A URL/resource name that uniquely identifies the type of the serialized\nprotocol buffer message. The last segment of the URL's path must represent\nthe fully qualified name of the type (as in\n` + "`" + `path/google.protobuf.Duration` + "`" + `). The name should be in a canonical form\n(e.g., leading \".\" is not accepted).\n\nIn practice, teams usually precompile into the binary all types that they\nexpect it to use in the context of Any. However, for URLs which use the\nscheme ` + "`" + `http` + "`" + `, ` + "`" + `https` + "`" + `, or no scheme, one can optionally set up a type\nserver that maps type URLs to message definitions as follows:\n\n* If no scheme is provided, ` + "`" + `https` + "`" + ` is assumed.\n* An HTTP GET on the URL must yield a [google.protobuf.Type][]\n  value in binary format, or produce an error.\n* Applications are allowed to cache lookup results based on the\n  URL, or have them precompiled into a binary to avoid any\n  lookup. Therefore, binary compatibility needs to be preserved\n  on changes to types. (Use versioned type names to manage\n  breaking changes.)\n\nNote: this functionality is not currently available in the official\nprotobuf release, and it is not used for type URLs beginning with\ntype.googleapis.com.\n\nSchemes other than ` + "`" + `http` + "`" + `, ` + "`" + `https` + "`" + ` (or the empty scheme) might be\nused with implementation specific semantics."
+        },
+        "value": {
+          "type": "string",
+          "format": "byte",
+          "description": "Must be a valid serialized protocol buffer of the above specified type."
+        }
+      },
+      "description": "` + "`" + `Any` + "`" + ` contains an arbitrary serialized protocol buffer message along with a\nURL that describes the type of the serialized message.\n\nProtobuf library provides support to pack/unpack Any values in the form\nof utility functions or additional generated methods of the Any type.\n\nExample 1: Pack and unpack a message in C++.\n\n    Foo foo = ...;\n    Any any;\n    any.PackFrom(foo);\n    ...\n    if (any.UnpackTo(\u0026foo)) {\n      ...\n    }\n\nExample 2: Pack and unpack a message in Java.\n\n    Foo foo = ...;\n    Any any = Any.pack(foo);\n    ...\n    if (any.is(Foo.class)) {\n      foo = any.unpack(Foo.class);\n    }\n\n Example 3: Pack and unpack a message in Python.\n\n    foo = Foo(...)\n    any = Any()\n    any.Pack(foo)\n    ...\n    if any.Is(Foo.DESCRIPTOR):\n      any.Unpack(foo)\n      ...\n\n Example 4: Pack and unpack a message in Go\n\n     foo := \u0026pb.Foo{...}\n     any, err := ptypes.MarshalAny(foo)\n     ...\n     foo := \u0026pb.Foo{}\n     if err := ptypes.UnmarshalAny(any, foo); err != nil {\n       ...\n     }\n\nThe pack methods provided by protobuf library will by default use\n'type.googleapis.com/full.type.name' as the type URL and the unpack\nmethods only use the fully qualified type name after the last '/'\nin the type URL, for example \"foo.bar.com/x/y.z\" will yield type\nname \"y.z\".\n\n\nJSON\n====\nThe JSON representation of an ` + "`" + `Any` + "`" + ` value uses the regular\nrepresentation of the deserialized, embedded message, with an\nadditional field ` + "`" + `@type` + "`" + ` which contains the type URL. Example:\n\n    package google.profile;\n    message Person {\n      string first_name = 1;\n      string last_name = 2;\n    }\n\n    {\n      \"@type\": \"type.googleapis.com/google.profile.Person\",\n      \"firstName\": \u003cstring\u003e,\n      \"lastName\": \u003cstring\u003e\n    }\n\nIf the embedded message type is well-known and has a custom JSON\nrepresentation, that representation will be embedded adding a field\n` + "`" + `value` + "`" + ` which holds the custom JSON in addition to the ` + "`" + `@type` + "`" + `\nfield. Example (for message [google.protobuf.Duration][]):\n\n    {\n      \"@type\": \"type.googleapis.com/google.protobuf.Duration\",\n      \"value\": \"1.212s\"\n    }"
+    },
+    "runtimeStreamError": {
+      "type": "object",
+      "properties": {
+        "grpc_code": {
+          "type": "integer",
+          "format": "int32"
+        },
+        "http_code": {
+          "type": "integer",
+          "format": "int32"
+        },
+        "message": {
+          "type": "string"
+        },
+        "http_status": {
+          "type": "string"
+        },
+        "details": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/protobufAny"
+          }
+        }
+      }
+    }
+  },
+  "x-stream-definitions": {
+    "dssprotoSubscriptionEvent": {
+      "type": "object",
+      "properties": {
+        "result": {
+          "$ref": "#/definitions/dssprotoSubscriptionEvent"
+        },
+        "error": {
+          "$ref": "#/definitions/runtimeStreamError"
+        }
+      },
+      "title": "Stream result of dssprotoSubscriptionEvent"
+    }
+  },
+  "securityDefinitions": {
+    "Bearer": {
+      "type": "apiKey",
+      "description": "Access token issued by an authorization authority, as \"Bearer \u003ctoken\u003e\".",
+      "name": "Authorization",
+      "in": "header"
+    }
+  },
+  "security": [
+    {
+      "Bearer": []
+    }
+  ]
+}
+`
@@ -0,0 +1,45 @@
+//go:build ignore
+// +build ignore
+
+// gen_swagger.go embeds dss.swagger.json, generated by protoc-gen-swagger
+// from dss.proto, in dss.swagger.go so that binaries serve the OpenAPI
+// document of the DSS without reading it from disk.
+//
+// Run it from pkg/dssproto with "go run gen_swagger.go".
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+const (
+	input  = "dss.swagger.json"
+	output = "dss.swagger.go"
+)
+
+func main() {
+	spec, err := ioutil.ReadFile(input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !json.Valid(spec) {
+		log.Fatalf("%s is not valid JSON", input)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_swagger.go from " + input + ". DO NOT EDIT.\n\n")
+	b.WriteString("package dssproto\n\n")
+	b.WriteString("// SwaggerJSON is the OpenAPI 2.0 document of the HTTP API of DSService\n")
+	b.WriteString("// served by the handlers in dss.pb.gw.go.\n")
+	// Raw strings cannot contain backquotes, so they are concatenated as
+	// interpreted strings.
+	b.WriteString("const SwaggerJSON = `" + strings.Replace(string(spec), "`", "` + \"`\" + `", -1) + "`\n")
+
+	if err := ioutil.WriteFile(output, b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+package dssproto
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type swaggerSpec struct {
+	Paths map[string]map[string]struct {
+		OperationID string `json:"operationId"`
+	} `json:"paths"`
+}
+
+func TestSwaggerJSONIsUpToDate(t *testing.T) {
+	spec, err := ioutil.ReadFile("dss.swagger.json")
+	require.NoError(t, err)
+	require.Equal(t, string(spec), SwaggerJSON, "run go run gen_swagger.go")
+}
+
+// TestSwaggerMatchesGatewayRoutes checks that every operation of SwaggerJSON
+// is routed to the RPC of the same name by the handlers in dss.pb.gw.go, and
+// that every unary RPC is described.
+func TestSwaggerMatchesGatewayRoutes(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		listener = bufconn.Listen(1 << 16)
+		called   = make(chan string, 1)
+		s        = grpc.NewServer(grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			called <- path.Base(method)
+			return status.Error(codes.Unimplemented, "not implemented")
+		}))
+	)
+	go s.Serve(listener)
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	mux := runtime.NewServeMux()
+	require.NoError(t, RegisterDSServiceHandler(ctx, mux, conn))
+
+	var spec swaggerSpec
+	require.NoError(t, json.Unmarshal([]byte(SwaggerJSON), &spec))
+
+	described := map[string]bool{}
+	for p, operations := range spec.Paths {
+		for method, operation := range operations {
+			target := strings.Replace(p, "{id}", "8c6b7f84-4b47-4b30-a36f-a3e22b5e3b6b", -1)
+			r := httptest.NewRequest(strings.ToUpper(method), target, strings.NewReader("{}"))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusNotImplemented, w.Code, "%s %s", method, p)
+			require.Equal(t, operation.OperationID, <-called, "%s %s", method, p)
+			described[operation.OperationID] = true
+		}
+	}
+
+	client := reflect.TypeOf((*DSServiceClient)(nil)).Elem()
+	for i := 0; i < client.NumMethod(); i++ {
+		m := client.Method(i)
+		// Streaming RPCs return a stream interface and are not served over
+		// HTTP.
+		if m.Type.Out(0).Kind() != reflect.Ptr {
+			continue
+		}
+		require.True(t, described[m.Name], "%s is not described", m.Name)
+	}
+}
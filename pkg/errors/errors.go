@@ -19,25 +19,39 @@ var (
 func Interceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		resp, err = handler(ctx, req)
-		status, ok := status.FromError(err)
-
-		switch {
-		case !ok:
-			logger.Error("encountered error during unary server call", zap.String("method", info.FullMethod), zap.Error(err))
-			err = errInternal
-		case status.Code() == codes.Internal, status.Code() == codes.Unknown:
-			logger.Error("encountered internal error during unary server call",
-				zap.String("method", info.FullMethod),
-				zap.Stringer("code", status.Code()),
-				zap.String("message", status.Message()),
-				zap.Any("details", status.Details()),
-				zap.Error(err))
-			err = errInternal
-		}
-		return
+		return resp, sanitize(logger, info.FullMethod, err)
 	}
 }
 
+// StreamInterceptor returns a grpc.StreamServerInterceptor that treats errors
+// returned by streaming calls like Interceptor does.
+func StreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return sanitize(logger, info.FullMethod, handler(srv, ss))
+	}
+}
+
+// sanitize logs and replaces "err" if it is not a *status.Status instance or
+// indicates an internal/unknown error.
+func sanitize(logger *zap.Logger, method string, err error) error {
+	status, ok := status.FromError(err)
+
+	switch {
+	case !ok:
+		logger.Error("encountered error during server call", zap.String("method", method), zap.Error(err))
+		err = errInternal
+	case status.Code() == codes.Internal, status.Code() == codes.Unknown:
+		logger.Error("encountered internal error during server call",
+			zap.String("method", method),
+			zap.Stringer("code", status.Code()),
+			zap.String("message", status.Message()),
+			zap.Any("details", status.Details()),
+			zap.Error(err))
+		err = errInternal
+	}
+	return err
+}
+
 func AlreadyExists(id string) error {
 	return status.Error(codes.AlreadyExists, "resource already exists: "+id)
 }
@@ -74,3 +88,7 @@ func PermissionDenied(msg string) error {
 func Unauthenticated(msg string) error {
 	return status.Error(codes.Unauthenticated, msg)
 }
+
+func Unimplemented(msg string) error {
+	return status.Error(codes.Unimplemented, msg)
+}
@@ -0,0 +1,231 @@
+// Package health tracks the readiness of a process and reports it through
+// the standard gRPC health protocol and HTTP probe endpoints.
+//
+// A Monitor periodically runs a set of checks of the dependencies of the
+// process. The process is ready while all of them succeed and it is not
+// draining. Draining is final: a process draining before shutdown reports not
+// ready until it exits, so that load balancers stop sending it traffic before
+// it stops accepting connections.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ServiceName is the name of the gRPC health service.
+const ServiceName = "grpc.health.v1.Health"
+
+var (
+	// DefaultConfig is the default configuration of a Monitor.
+	DefaultConfig = Config{
+		Interval: 5 * time.Second,
+		Timeout:  2 * time.Second,
+	}
+
+	errDraining        = errors.New("draining")
+	errNotChecked      = errors.New("not checked yet")
+	errInvalidInterval = errors.New("interval must be positive")
+	errInvalidTimeout  = errors.New("timeout must be positive")
+)
+
+// Check returns an error if a dependency of the process is unavailable.
+type Check func(ctx context.Context) error
+
+// Config configures a Monitor.
+type Config struct {
+	// Interval is the duration between two runs of the checks.
+	Interval time.Duration
+	// Timeout bounds the duration of a single check.
+	Timeout time.Duration
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c Config) Validate() error {
+	switch {
+	case c.Interval <= 0:
+		return errInvalidInterval
+	case c.Timeout <= 0:
+		return errInvalidTimeout
+	}
+	return nil
+}
+
+// Monitor tracks the readiness of a process. The methods of a Monitor are
+// safe for concurrent use.
+type Monitor struct {
+	checks   map[string]Check
+	services []string
+	config   Config
+	logger   *zap.Logger
+	server   *health.Server
+
+	mu       sync.Mutex
+	err      error
+	draining bool
+}
+
+// New returns a new Monitor running "checks", keyed by the name of the
+// checked dependency. Besides the overall health of the process, the gRPC
+// health service reports the health of "services", e.g.
+// "dssproto.DSService". The Monitor reports not ready until Run completed the
+// first round of checks.
+func New(checks map[string]Check, services []string, config Config, logger *zap.Logger) (*Monitor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	m := &Monitor{
+		checks:   checks,
+		services: services,
+		config:   config,
+		logger:   logger,
+		server:   health.NewServer(),
+		err:      errNotChecked,
+	}
+	m.update()
+	return m, nil
+}
+
+// Register registers the gRPC health service of m with "s".
+func (m *Monitor) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, m.server)
+}
+
+// Run runs the checks of m every config.Interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	for {
+		m.setErr(m.check(ctx))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs all checks of m and returns the first error, in the order of the
+// names of the checks.
+func (m *Monitor) check(ctx context.Context) error {
+	names := make([]string, 0, len(m.checks))
+	for name := range m.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ctx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+		err := m.checks[name](ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setErr records the outcome "err" of the last round of checks.
+func (m *Monitor) setErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case err != nil && (m.err == nil || m.err == errNotChecked):
+		m.logger.Warn("Not ready", zap.Error(err))
+	case err == nil && m.err != nil:
+		m.logger.Info("Ready")
+	}
+	m.err = err
+	m.updateLocked()
+}
+
+// Drain makes m report not ready from now on.
+func (m *Monitor) Drain() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.draining = true
+	m.updateLocked()
+}
+
+// Ready returns nil if the process is ready and the reason why it is not
+// otherwise.
+func (m *Monitor) Ready() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.readyLocked()
+}
+
+func (m *Monitor) readyLocked() error {
+	if m.draining {
+		return errDraining
+	}
+	return m.err
+}
+
+func (m *Monitor) update() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updateLocked()
+}
+
+// updateLocked updates the status reported by the gRPC health service.
+func (m *Monitor) updateLocked() {
+	status := healthpb.HealthCheckResponse_SERVING
+	if m.readyLocked() != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	m.server.SetServingStatus("", status)
+	for _, service := range m.services {
+		m.server.SetServingStatus(service, status)
+	}
+}
+
+// ServeLiveness answers liveness probes. A process serving HTTP requests is
+// alive, so it always succeeds.
+func (m *Monitor) ServeLiveness(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// ServeReadiness answers readiness probes, failing with 503 Service
+// Unavailable if the process is not ready.
+func (m *Monitor) ServeReadiness(w http.ResponseWriter, r *http.Request) {
+	if err := m.Ready(); err != nil {
+		http.Error(w, fmt.Sprintf("not ready: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// Handle registers the liveness and readiness probes of m with "mux" at
+// /healthz and /readyz.
+func (m *Monitor) Handle(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", m.ServeLiveness)
+	mux.HandleFunc("/readyz", m.ServeReadiness)
+}
+
+// GRPCCheck returns a Check calling the gRPC health service of the server at
+// the other end of "conn" for "service", which fails unless the server
+// reports SERVING.
+func GRPCCheck(conn *grpc.ClientConn, service string) Check {
+	client := healthpb.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if s := resp.GetStatus(); s != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("status is %s", s)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const service = "dssproto.DSService"
+
+var testConfig = Config{Interval: time.Hour, Timeout: 10 * time.Millisecond}
+
+// checkable is a Check whose outcome is controlled by a test.
+type checkable struct {
+	err error
+}
+
+func (c *checkable) check(ctx context.Context) error {
+	return c.err
+}
+
+func newTestMonitor(t *testing.T, checks map[string]Check) *Monitor {
+	m, err := New(checks, []string{service}, testConfig, zap.NewNop())
+	require.NoError(t, err)
+	return m
+}
+
+func probe(handler http.HandlerFunc) int {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func servingStatus(t *testing.T, m *Monitor, service string) healthpb.HealthCheckResponse_ServingStatus {
+	resp, err := m.server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestReadinessFollowsChecks(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = &checkable{}
+		m     = newTestMonitor(t, map[string]Check{"store": store.check})
+	)
+
+	// Not ready before the first round of checks.
+	require.Error(t, m.Ready())
+	require.Equal(t, http.StatusServiceUnavailable, probe(m.ServeReadiness))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, ""))
+
+	m.setErr(m.check(ctx))
+	require.NoError(t, m.Ready())
+	require.Equal(t, http.StatusOK, probe(m.ServeReadiness))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, m, ""))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, m, service))
+
+	store.err = errors.New("connection refused")
+	m.setErr(m.check(ctx))
+	require.EqualError(t, m.Ready(), "store: connection refused")
+	require.Equal(t, http.StatusServiceUnavailable, probe(m.ServeReadiness))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, service))
+
+	// Liveness does not depend on readiness.
+	require.Equal(t, http.StatusOK, probe(m.ServeLiveness))
+}
+
+func TestDrainingIsFinal(t *testing.T) {
+	var (
+		ctx = context.Background()
+		m   = newTestMonitor(t, nil)
+	)
+	m.setErr(m.check(ctx))
+	require.NoError(t, m.Ready())
+
+	m.Drain()
+	require.Equal(t, errDraining, m.Ready())
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, ""))
+
+	m.setErr(m.check(ctx))
+	require.Equal(t, errDraining, m.Ready())
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, m, service))
+}
+
+func TestChecksTimeOut(t *testing.T) {
+	m := newTestMonitor(t, map[string]Check{"slow": func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	require.Error(t, m.check(context.Background()))
+}
+
+func TestRunChecksUntilDone(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		m           = newTestMonitor(t, nil)
+		done        = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		m.Run(ctx)
+	}()
+	require.Eventually(t, func() bool { return m.Ready() == nil }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestGRPCCheck(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		listener = bufconn.Listen(1 << 16)
+		backend  = newTestMonitor(t, nil)
+		s        = grpc.NewServer()
+	)
+	backend.Register(s)
+	go s.Serve(listener)
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	check := GRPCCheck(conn, service)
+	require.Error(t, check(ctx))
+
+	backend.setErr(backend.check(ctx))
+	require.NoError(t, check(ctx))
+
+	backend.Drain()
+	require.Error(t, check(ctx))
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, DefaultConfig.Validate())
+	require.Error(t, Config{Timeout: time.Second}.Validate())
+	require.Error(t, Config{Interval: time.Second}.Validate())
+}
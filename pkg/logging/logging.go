@@ -87,6 +87,18 @@ func Interceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 	)
 }
 
+// StreamInterceptor returns a grpc.StreamServerInterceptor that logs incoming
+// streaming calls and associated tags to "logger".
+func StreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	opts := []grpc_zap.Option{
+		grpc_zap.WithLevels(grpc_zap.DefaultCodeToLevel),
+	}
+	return grpc_middleware.ChainStreamServer(
+		grpc_ctxtags.StreamServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
+		grpc_zap.StreamServerInterceptor(logger, opts...),
+	)
+}
+
 // WithValuesFromContext augments logger with relevant fields from ctx and returns
 // the the resulting logger.
 func WithValuesFromContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
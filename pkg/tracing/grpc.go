@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataCarrier adapts metadata.MD to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// rpcAttributes returns the attributes describing a call to "fullMethod",
+// e.g. "/dssproto.DSService/GetIdentificationServiceArea".
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{attribute.String("rpc.system", "grpc")}
+	parts := strings.Split(strings.TrimPrefix(fullMethod, "/"), "/")
+	if len(parts) == 2 {
+		attributes = append(attributes,
+			attribute.String("rpc.service", parts[0]),
+			attribute.String("rpc.method", parts[1]),
+		)
+	}
+	return attributes
+}
+
+// startRPC starts the span of a call to "fullMethod" in ctx.
+func startRPC(ctx context.Context, fullMethod string, kind trace.SpanKind) (context.Context, trace.Span) {
+	return tracer().Start(ctx, strings.TrimPrefix(fullMethod, "/"),
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(rpcAttributes(fullMethod)...))
+}
+
+// endRPC records the outcome "err" of a call on "span" and ends it.
+func endRPC(span trace.Span, err error) {
+	span.SetAttributes(attribute.Int64("rpc.grpc.status_code", int64(status.Code(err))))
+	RecordError(span, err)
+	span.End()
+}
+
+// extract returns ctx with the trace context sent by the client of the call
+// in ctx.
+func extract(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// inject returns ctx with the trace context of ctx added to the outgoing
+// metadata.
+func inject(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// Interceptor traces unary calls, continuing the traces of their clients.
+func Interceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startRPC(extract(ctx), info.FullMethod, trace.SpanKindServer)
+	resp, err := handler(ctx, req)
+	endRPC(span, err)
+	return resp, err
+}
+
+// StreamInterceptor traces streaming calls, continuing the traces of their
+// clients.
+func StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startRPC(extract(ss.Context()), info.FullMethod, trace.SpanKindServer)
+	wrapped := grpc_middleware.WrapServerStream(ss)
+	wrapped.WrappedContext = ctx
+	err := handler(srv, wrapped)
+	endRPC(span, err)
+	return err
+}
+
+// UnaryClientInterceptor traces unary calls and sends the trace context to
+// the server.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := startRPC(ctx, method, trace.SpanKindClient)
+	err := invoker(inject(ctx), method, req, reply, cc, opts...)
+	endRPC(span, err)
+	return err
+}
+
+// StreamClientInterceptor sends the trace context to the server of streaming
+// calls. Streams outlive the call establishing them, so no span is recorded
+// for them on the client side.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(inject(ctx), desc, cc, method, opts...)
+}
@@ -0,0 +1,140 @@
+// Package tracing sets up OpenTelemetry tracing and propagates trace context
+// across the HTTP gateway, the gRPC backend and their clients.
+//
+// Trace context is carried in W3C traceparent and baggage headers over HTTP,
+// and in the equally named metadata keys over gRPC. Spans are exported by the
+// exporter selected in Config, or discarded if no exporter is configured. The
+// trace context is propagated either way, so a component without an exporter
+// does not break traces recorded by others.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
+)
+
+const (
+	// ExporterNone discards all spans.
+	ExporterNone = "none"
+	// ExporterStdout writes spans as JSON to stdout.
+	ExporterStdout = "stdout"
+	// ExporterFile writes spans as JSON to Config.File.
+	ExporterFile = "file"
+
+	// instrumentationName names the tracers of this package.
+	instrumentationName = "github.com/steeling/InterUSS-Platform/pkg/tracing"
+)
+
+var (
+	// DefaultExporter is the default exporter.
+	DefaultExporter = ExporterNone
+	// DefaultSampleRatio is the default fraction of traces sampled.
+	DefaultSampleRatio = 1.0
+
+	errMissingFile        = errors.New("missing file for the file exporter")
+	errInvalidSampleRatio = errors.New("sample ratio must be in [0, 1]")
+)
+
+// Config configures tracing.
+type Config struct {
+	// ServiceName identifies the component recording the spans.
+	ServiceName string
+	// Exporter is one of ExporterNone, ExporterStdout and ExporterFile.
+	Exporter string
+	// File is the path spans are appended to by ExporterFile.
+	File string
+	// SampleRatio is the fraction of new traces that are sampled. Traces
+	// started by clients keep the sampling decision of their parent.
+	SampleRatio float64
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case ExporterNone, ExporterStdout:
+	case ExporterFile:
+		if c.File == "" {
+			return errMissingFile
+		}
+	default:
+		return fmt.Errorf("unknown trace exporter: %s", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return errInvalidSampleRatio
+	}
+	return nil
+}
+
+// Configure installs the global propagator and, unless config selects
+// ExporterNone, the global tracer provider exporting spans as configured by
+// "config". The returned function flushes pending spans and releases the
+// exporter, it must be called before the process exits.
+func Configure(config Config) (func(context.Context) error, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var w io.Writer
+	switch config.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w = os.Stdout
+	case ExporterFile:
+		f, err := os.OpenFile(config.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", config.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if c, ok := w.(io.Closer); ok && w != os.Stdout {
+			err = multierr.Append(err, c.Close())
+		}
+		return err
+	}, nil
+}
+
+// tracer returns the tracer of this package from the global tracer provider.
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// RecordError marks "span" as failed with "err" if "err" is not nil.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(otelcodes.Error, err.Error())
+}
@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+	fullMethod  = "/dssproto.DSService/GetIdentificationServiceArea"
+)
+
+// setUp installs a tracer provider recording all spans.
+func setUp(t *testing.T) *tracetest.SpanRecorder {
+	_, err := Configure(Config{Exporter: ExporterNone, SampleRatio: 1})
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"none", Config{Exporter: ExporterNone, SampleRatio: 1}, true},
+		{"stdout", Config{Exporter: ExporterStdout, SampleRatio: 0.5}, true},
+		{"file", Config{Exporter: ExporterFile, File: "spans.json", SampleRatio: 1}, true},
+		{"file without path", Config{Exporter: ExporterFile, SampleRatio: 1}, false},
+		{"unknown exporter", Config{Exporter: "jaeger", SampleRatio: 1}, false},
+		{"negative ratio", Config{Exporter: ExporterNone, SampleRatio: -1}, false},
+		{"ratio above one", Config{Exporter: ExporterNone, SampleRatio: 2}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestPropagatesFromHTTPToGRPC(t *testing.T) {
+	recorder := setUp(t)
+
+	// server stands in for the backend, receiving the metadata sent by the
+	// client.
+	server := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		ctx = metadata.NewIncomingContext(context.Background(), md)
+		_, err := Interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			require.Equal(t, traceID, trace.SpanContextFromContext(ctx).TraceID().String())
+			return nil, status.Error(codes.NotFound, "not found")
+		})
+		return err
+	}
+
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := UnaryClientInterceptor(r.Context(), fullMethod, nil, nil, nil, server)
+		require.Error(t, err)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/v1/dss/identification_service_areas/foo", nil)
+	req.Header.Set("traceparent", traceparent)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	var (
+		serverSpan = spans[0]
+		clientSpan = spans[1]
+		httpSpan   = spans[2]
+	)
+	for _, span := range spans {
+		require.Equal(t, traceID, span.SpanContext().TraceID().String())
+	}
+	require.Equal(t, "00f067aa0ba902b7", httpSpan.Parent().SpanID().String())
+	require.Equal(t, httpSpan.SpanContext().SpanID(), clientSpan.Parent().SpanID())
+	require.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID())
+
+	require.Equal(t, "dssproto.DSService/GetIdentificationServiceArea", serverSpan.Name())
+	require.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	require.Equal(t, trace.SpanKindClient, clientSpan.SpanKind())
+	require.Equal(t, "HTTP GET", httpSpan.Name())
+}
+
+func TestStreamInterceptorPassesTraceContext(t *testing.T) {
+	recorder := setUp(t)
+
+	md := metadata.Pairs("traceparent", traceparent)
+	ss := &serverStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	err := StreamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: fullMethod}, func(srv interface{}, ss grpc.ServerStream) error {
+		require.Equal(t, traceID, trace.SpanContextFromContext(ss.Context()).TraceID().String())
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, recorder.Ended(), 1)
+}
+
+func TestFileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "spans.json")
+
+	shutdown, err := Configure(Config{ServiceName: "test", Exporter: ExporterFile, File: path, SampleRatio: 1})
+	require.NoError(t, err)
+	_, span := otel.Tracer("test").Start(context.Background(), "exported-span")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "exported-span")
+	require.Contains(t, string(data), `"Value":"test"`)
+}
+
+// serverStream is a grpc.ServerStream with a fixed context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}